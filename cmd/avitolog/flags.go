@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/itcaat/avitolog/internal/parser"
+)
+
+// Output formats accepted by -output
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputJSONL = "jsonl"
+	outputCSV   = "csv"
+)
+
+// config holds the command line options
+type config struct {
+	category string
+	limit    int
+	output   string
+	region   string
+	outFile  string
+}
+
+// parseFlags parses and validates the command line arguments.
+// Usage is printed to stderr when they are invalid.
+func parseFlags(args []string, stderr io.Writer) (config, error) {
+	var cfg config
+
+	fs := flag.NewFlagSet("avitolog", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.category, "category", "", "category URL or name to scrape, e.g. \"https://www.avito.ru/all/telefony\" or \"Телефоны\" (default: crawl all categories)")
+	fs.IntVar(&cfg.limit, "limit", 0, "maximum number of listings per category (default 5 per category and 2 per subcategory when crawling, otherwise no limit)")
+	fs.StringVar(&cfg.output, "output", outputText, "output format: text, json, jsonl (one JSON object per line, written as listings are scraped) or csv")
+	fs.StringVar(&cfg.region, "region", "", "region or city slug such as \"moskva\" (default: all regions)")
+	fs.StringVar(&cfg.outFile, "out-file", "", "write the output to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: avitolog [flags]\n\nScrapes listings from Avito. Without -category every category is crawled.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	err := cfg.validate()
+	if err == nil && fs.NArg() > 0 {
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err != nil {
+		fmt.Fprintln(fs.Output(), "Error:", err)
+		fs.Usage()
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// validate checks that the flags are usable together
+func (cfg config) validate() error {
+	switch cfg.output {
+	case outputText, outputJSON, outputJSONL, outputCSV:
+	default:
+		return fmt.Errorf("unknown output format %q, want text, json, jsonl or csv", cfg.output)
+	}
+
+	if cfg.limit < 0 {
+		return errors.New("-limit must not be negative")
+	}
+
+	if cfg.region != "" {
+		if _, ok := parser.Regions[cfg.region]; !ok {
+			return fmt.Errorf("unknown region %q", cfg.region)
+		}
+	}
+
+	return nil
+}
@@ -1,90 +1,302 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
 
+	"github.com/itcaat/avitolog/internal/export"
+	"github.com/itcaat/avitolog/internal/models"
 	"github.com/itcaat/avitolog/internal/parser"
 )
 
+// Listing limits used when crawling every category without -limit
+const (
+	defaultListingsLimit    = 5
+	defaultSubListingsLimit = 2
+)
+
 func main() {
-	fmt.Println("Starting Avitolog parser...")
+	cfg, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run scrapes according to cfg and writes the results
+func run(cfg config) (err error) {
+	opts := parser.DefaultParserOptions()
+	opts.Region = cfg.region
+	p, err := parser.NewParser(opts)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if cfg.outFile != "" {
+		file, err := os.Create(cfg.outFile)
+		if err != nil {
+			return fmt.Errorf("error creating output file: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("error writing output file: %w", closeErr)
+			}
+		}()
+		w = file
+	}
+
+	var listings []models.Listing
+	switch {
+	case cfg.category == "":
+		listings, err = crawl(p, cfg, w)
+	case cfg.output == outputJSONL:
+		err = streamCategory(p, cfg, w)
+	default:
+		listings, err = scrapeCategory(p, cfg, w)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch cfg.output {
+	case outputJSON:
+		return export.WriteJSON(w, listings)
+	case outputCSV:
+		return export.WriteCSV(w, listings)
+	}
+	return nil
+}
+
+// scrapeCategory scrapes the category given by -category. In text mode the listings
+// are printed to w, otherwise they are returned for export.
+func scrapeCategory(p *parser.Parser, cfg config, w io.Writer) ([]models.Listing, error) {
+	categoryURL, err := resolveCategory(cfg.category)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching listings from %s", categoryURL)
+	listings, err := p.GetListings(categoryURL, cfg.limit)
+	if err != nil {
+		if len(listings) == 0 {
+			return nil, fmt.Errorf("error fetching listings: %w", err)
+		}
+		log.Printf("Error fetching listings, keeping %d found so far: %v", len(listings), err)
+	}
+
+	if cfg.output != outputText {
+		return listings, nil
+	}
+
+	fmt.Fprintf(w, "Found %d listings\n", len(listings))
+	for i, listing := range listings {
+		fmt.Fprintf(w, "%d. %s\n", i+1, listing.Title)
+		printListing(w, listing, "   ")
+	}
+	return nil, nil
+}
+
+// streamCategory scrapes the category given by -category, writing each listing to w
+// as a line of JSON as soon as its details are fetched. An interrupt stops scraping
+// and keeps the lines written so far.
+func streamCategory(p *parser.Parser, cfg config, w io.Writer) error {
+	categoryURL, err := resolveCategory(cfg.category)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Streaming listings from %s", categoryURL)
+	listings, errs := p.StreamListings(ctx, categoryURL, cfg.limit)
+
+	// Both channels are drained until closed so the scraping goroutine can finish
+	writer := export.NewJSONLWriter(w)
+	written := 0
+	var writeErr error
+	for listings != nil || errs != nil {
+		select {
+		case listing, ok := <-listings:
+			if !ok {
+				listings = nil
+				continue
+			}
+			if writeErr != nil {
+				continue
+			}
+			if writeErr = writer.Write(listing); writeErr != nil {
+				stop()
+				continue
+			}
+			written++
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			log.Printf("Error: %v", err)
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	log.Printf("Wrote %d listings", written)
+	return nil
+}
+
+// resolveCategory turns a -category value into a URL. Values that aren't URLs
+// are looked up by name among the known categories and subcategories.
+func resolveCategory(category string) (string, error) {
+	if strings.HasPrefix(category, "http://") || strings.HasPrefix(category, "https://") || strings.HasPrefix(category, "/") {
+		return category, nil
+	}
+
+	categories, err := parser.GetCategories()
+	if err != nil {
+		return "", fmt.Errorf("error getting categories: %w", err)
+	}
+
+	for _, known := range parser.FlattenCategories(categories, false) {
+		if strings.EqualFold(known.Name, category) {
+			return known.URL, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown category %q, pass a category URL or one of the names from GetCategories", category)
+}
+
+// crawl fetches a few listings from every category and subcategory. In text mode
+// the progress and listings are printed to w. In JSONL mode each category's listings
+// are written to w as soon as they're fetched, otherwise they are returned for export.
+func crawl(p *parser.Parser, cfg config, w io.Writer) ([]models.Listing, error) {
+	text := cfg.output == outputText
+	out := w
+	if !text {
+		w = io.Discard
+	}
+
+	listingsLimit, subListingsLimit := defaultListingsLimit, defaultSubListingsLimit
+	if cfg.limit > 0 {
+		listingsLimit, subListingsLimit = cfg.limit, cfg.limit
+	}
+
+	fmt.Fprintln(w, "Starting Avitolog parser...")
 
 	// Get categories from Avito
 	categories, err := parser.GetCategories()
 	if err != nil {
-		log.Fatalf("Error getting categories: %v", err)
+		return nil, fmt.Errorf("error getting categories: %w", err)
+	}
+
+	var all []models.Listing
+	var jsonl *export.JSONLWriter
+	if cfg.output == outputJSONL {
+		jsonl = export.NewJSONLWriter(out)
+	}
+	// collect keeps listings for export, or writes them right away as JSON lines
+	collect := func(listings []models.Listing) error {
+		if jsonl == nil {
+			if !text {
+				all = append(all, listings...)
+			}
+			return nil
+		}
+		for _, listing := range listings {
+			if err := jsonl.Write(listing); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
 	// Display found categories
-	fmt.Printf("Found %d main categories\n", len(categories))
+	fmt.Fprintf(w, "Found %d main categories\n", len(categories))
 	for i, category := range categories {
-		fmt.Printf("\n%d. %s (%s)\n", i+1, category.Name, category.URL)
-
-		// Limit the number of listings to fetch per category
-		listingsLimit := 5
+		fmt.Fprintf(w, "\n%d. %s (%s)\n", i+1, category.Name, category.URL)
 
 		// Fetch listings for this category
-		fmt.Printf("   Fetching listings for %s...\n", category.Name)
-		listings, err := parser.GetListings(category.URL, listingsLimit)
+		fmt.Fprintf(w, "   Fetching listings for %s...\n", category.Name)
+		listings, err := p.GetListings(category.URL, listingsLimit)
 		if err != nil {
 			log.Printf("   Error fetching listings for %s: %v", category.Name, err)
-			continue
+		}
+		if err := collect(listings); err != nil {
+			return nil, err
 		}
 
 		// Display the listings
-		fmt.Printf("   Found %d listings\n", len(listings))
-		for j, listing := range listings {
-			fmt.Printf("   %d.%d. %s\n", i+1, j+1, listing.Title)
-			fmt.Printf("      URL: %s\n", listing.URL)
-
-			// Print price info if available
-			if listing.Price.Value > 0 {
-				fmt.Printf("      Price: %.2f %s\n", listing.Price.Value, listing.Price.Currency)
-			} else if listing.Price.Text != "" {
-				fmt.Printf("      Price: %s\n", listing.Price.Text)
-			}
-
-			// Print location if available
-			if listing.Location != "" {
-				fmt.Printf("      Location: %s\n", listing.Location)
+		if len(listings) > 0 {
+			fmt.Fprintf(w, "   Found %d listings\n", len(listings))
+			for j, listing := range listings {
+				fmt.Fprintf(w, "   %d.%d. %s\n", i+1, j+1, listing.Title)
+				printListing(w, listing, "      ")
 			}
 		}
 
 		// Check if the category has subcategories
 		if len(category.Subcategories) > 0 {
-			fmt.Printf("\n   Subcategories for %s:\n", category.Name)
-
-			// For each subcategory, fetch a smaller number of listings
-			subListingsLimit := 2
+			fmt.Fprintf(w, "\n   Subcategories for %s:\n", category.Name)
 
 			for k, subcategory := range category.Subcategories {
-				fmt.Printf("   %d.%d. %s (%s)\n", i+1, k+1, subcategory.Name, subcategory.URL)
+				fmt.Fprintf(w, "   %d.%d. %s (%s)\n", i+1, k+1, subcategory.Name, subcategory.URL)
 
 				// Fetch listings for this subcategory
-				fmt.Printf("      Fetching listings for %s...\n", subcategory.Name)
-				subListings, err := parser.GetListings(subcategory.URL, subListingsLimit)
+				fmt.Fprintf(w, "      Fetching listings for %s...\n", subcategory.Name)
+				subListings, err := p.GetListings(subcategory.URL, subListingsLimit)
 				if err != nil {
 					log.Printf("      Error fetching listings for %s: %v", subcategory.Name, err)
-					continue
+					if len(subListings) == 0 {
+						continue
+					}
+				}
+				if err := collect(subListings); err != nil {
+					return nil, err
 				}
 
 				// Display the listings
-				fmt.Printf("      Found %d listings\n", len(subListings))
+				fmt.Fprintf(w, "      Found %d listings\n", len(subListings))
 				for l, subListing := range subListings {
-					fmt.Printf("      %d.%d.%d. %s\n", i+1, k+1, l+1, subListing.Title)
-					fmt.Printf("         URL: %s\n", subListing.URL)
-
-					// Print price info if available
-					if subListing.Price.Value > 0 {
-						fmt.Printf("         Price: %.2f %s\n", subListing.Price.Value, subListing.Price.Currency)
-					} else if subListing.Price.Text != "" {
-						fmt.Printf("         Price: %s\n", subListing.Price.Text)
-					}
+					fmt.Fprintf(w, "      %d.%d.%d. %s\n", i+1, k+1, l+1, subListing.Title)
+					printListing(w, subListing, "         ")
 				}
 			}
 		}
 
-		fmt.Println("\n-------------------------------------------")
+		fmt.Fprintln(w, "\n-------------------------------------------")
+	}
+
+	return all, nil
+}
+
+// printListing prints a listing's URL, price and location, each line starting with indent
+func printListing(w io.Writer, listing models.Listing, indent string) {
+	fmt.Fprintf(w, "%sURL: %s\n", indent, listing.URL)
+
+	// Print price info if available
+	if listing.Price.Value > 0 {
+		fmt.Fprintf(w, "%sPrice: %.2f %s\n", indent, listing.Price.Value, listing.Price.Currency)
+	} else if listing.Price.Text != "" {
+		fmt.Fprintf(w, "%sPrice: %s\n", indent, listing.Price.Text)
+	}
+
+	// Print location if available
+	if listing.Location != "" {
+		fmt.Fprintf(w, "%sLocation: %s\n", indent, listing.Location)
 	}
 }
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// ImageURLSeparator joins the image URLs of a listing into a single CSV cell
+const ImageURLSeparator = "|"
+
+// csvHeader lists the CSV columns in the order they are written
+var csvHeader = []string{
+	"id",
+	"title",
+	"description",
+	"price_value",
+	"price_currency",
+	"price_text",
+	"url",
+	"image_urls",
+	"location",
+	"latitude",
+	"longitude",
+	"category_id",
+	"category_url",
+	"published_at",
+	"seller_name",
+	"seller_type",
+	"seller_url",
+	"attributes",
+}
+
+// WriteCSV writes listings as CSV with a header row. The price is flattened into
+// value and currency columns, image URLs are joined with ImageURLSeparator and
+// attributes are written as "key: value" pairs separated by "; ".
+func WriteCSV(w io.Writer, listings []models.Listing) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	for _, listing := range listings {
+		if err := writer.Write(csvRecord(listing)); err != nil {
+			return fmt.Errorf("error writing listing %s: %w", listing.ID, err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRecord converts a listing into a row matching csvHeader
+func csvRecord(listing models.Listing) []string {
+	return []string{
+		listing.ID,
+		listing.Title,
+		listing.Description,
+		formatFloat(listing.Price.Value),
+		listing.Price.Currency,
+		listing.Price.Text,
+		listing.URL,
+		strings.Join(listing.ImageURLs, ImageURLSeparator),
+		listing.Location,
+		formatFloat(listing.Latitude),
+		formatFloat(listing.Longitude),
+		listing.CategoryID,
+		listing.CategoryURL,
+		formatTime(listing.PublishedAt),
+		listing.SellerName,
+		listing.SellerType,
+		listing.SellerURL,
+		formatAttributes(listing.Attributes),
+	}
+}
+
+// formatFloat writes zero values as empty cells
+func formatFloat(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatTime writes times as RFC 3339 and zero times as empty cells
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatAttributes joins attributes sorted by key so the output is stable
+func formatAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+": "+attributes[key])
+	}
+	return strings.Join(pairs, "; ")
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// readCSV parses the output of WriteCSV, checking the header row
+func readCSV(t *testing.T, data []byte) []map[string]string {
+	t.Helper()
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("output isn't valid CSV: %v\n%s", err, data)
+	}
+	if len(records) == 0 {
+		t.Fatal("no header row")
+	}
+
+	header := records[0]
+	if len(header) != len(csvHeader) {
+		t.Fatalf("header = %v, want %v", header, csvHeader)
+	}
+
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestWriteCSVEmpty(t *testing.T) {
+	for _, listings := range [][]models.Listing{nil, {}} {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, listings); err != nil {
+			t.Fatalf("WriteCSV: %v", err)
+		}
+		if rows := readCSV(t, buf.Bytes()); len(rows) != 0 {
+			t.Errorf("WriteCSV(%#v) wrote %d rows, want only the header", listings, len(rows))
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	listings := []models.Listing{
+		{
+			ID:          "1111111111",
+			Title:       `iPhone 15, "как новый"`,
+			Description: "Первая строка\nвторая строка",
+			Price:       models.Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+			URL:         "https://www.avito.ru/moskva/telefony/iphone_15_1111111111",
+			ImageURLs:   []string{"https://img.avito.st/1.jpg", "https://img.avito.st/2.jpg"},
+			Latitude:    55.75,
+			Longitude:   37.61,
+			PublishedAt: time.Date(2024, time.March, 5, 10, 15, 0, 0, time.UTC),
+			Attributes:  map[string]string{"Состояние": "Б/у", "Память": "128 ГБ"},
+		},
+		{ID: "2222222222"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, listings); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+
+	rows := readCSV(t, buf.Bytes())
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	full := map[string]string{
+		"id":             "1111111111",
+		"title":          `iPhone 15, "как новый"`,
+		"description":    "Первая строка\nвторая строка",
+		"price_value":    "65000",
+		"price_currency": "RUB",
+		"image_urls":     "https://img.avito.st/1.jpg|https://img.avito.st/2.jpg",
+		"latitude":       "55.75",
+		"longitude":      "37.61",
+		"published_at":   "2024-03-05T10:15:00Z",
+		"attributes":     "Память: 128 ГБ; Состояние: Б/у",
+	}
+	for column, want := range full {
+		if got := rows[0][column]; got != want {
+			t.Errorf("%s = %q, want %q", column, got, want)
+		}
+	}
+
+	// Missing fields are written as empty cells rather than zeros
+	for _, column := range csvHeader {
+		if column == "id" {
+			continue
+		}
+		if got := rows[1][column]; got != "" {
+			t.Errorf("%s of a sparse listing = %q, want an empty cell", column, got)
+		}
+	}
+}
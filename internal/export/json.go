@@ -0,0 +1,91 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// WriteJSON writes listings as an indented JSON array. A nil slice is written as [].
+func WriteJSON(w io.Writer, listings []models.Listing) error {
+	if listings == nil {
+		listings = []models.Listing{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(listings); err != nil {
+		return fmt.Errorf("error encoding listings: %w", err)
+	}
+
+	return nil
+}
+
+// ExportJSONFields writes listings as a JSON array whose objects contain only the named fields.
+// Field names are the JSON keys of models.Listing (e.g. "id", "title", "price", "url").
+func ExportJSONFields(w io.Writer, listings []models.Listing, fields []string) error {
+	known := listingJSONFields()
+	for _, field := range fields {
+		if !known[field] {
+			return fmt.Errorf("unknown listing field: %s", field)
+		}
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	for i, listing := range listings {
+		data, err := json.Marshal(listing)
+		if err != nil {
+			return fmt.Errorf("error encoding listing %s: %w", listing.ID, err)
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("error encoding listing %s: %w", listing.ID, err)
+		}
+
+		// Build the object by hand to keep the requested field order
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			value, ok := values[field]
+			if !ok {
+				continue // omitted because empty
+			}
+			key, _ := json.Marshal(field)
+			parts = append(parts, string(key)+":"+string(value))
+		}
+
+		separator := ""
+		if i > 0 {
+			separator = ","
+		}
+		if _, err := io.WriteString(w, separator+"{"+strings.Join(parts, ",")+"}"); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// listingJSONFields returns the set of JSON keys declared on models.Listing
+func listingJSONFields() map[string]bool {
+	fields := make(map[string]bool)
+
+	t := reflect.TypeOf(models.Listing{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = true
+	}
+
+	return fields
+}
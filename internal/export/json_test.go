@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestWriteJSONEmpty(t *testing.T) {
+	for _, listings := range [][]models.Listing{nil, {}} {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, listings); err != nil {
+			t.Fatalf("WriteJSON: %v", err)
+		}
+		if got := buf.String(); got != "[]\n" {
+			t.Errorf("WriteJSON(%#v) = %q, want %q", listings, got, "[]\n")
+		}
+	}
+}
+
+func TestWriteJSONRoundTrip(t *testing.T) {
+	listings := []models.Listing{
+		{
+			ID:          "1111111111",
+			Title:       "iPhone 15",
+			Price:       models.Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+			URL:         "https://www.avito.ru/moskva/telefony/iphone_15_1111111111",
+			ImageURLs:   []string{"https://img.avito.st/1.jpg"},
+			PublishedAt: time.Date(2024, time.March, 5, 10, 15, 0, 0, time.UTC),
+			Attributes:  map[string]string{"Память": "128 ГБ"},
+			IsActive:    true,
+		},
+		// A listing with only an ID, as collected when its page couldn't be fetched
+		{ID: "2222222222"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, listings); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	var decoded []models.Listing
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output isn't a JSON array of listings: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("decoded %d listings, want 2", len(decoded))
+	}
+	if got := decoded[0]; got.Title != "iPhone 15" || got.Price != listings[0].Price || !got.PublishedAt.Equal(listings[0].PublishedAt) {
+		t.Errorf("decoded listing = %+v, want %+v", got, listings[0])
+	}
+	if got := decoded[1]; got.ID != "2222222222" || got.Title != "" || !got.PublishedAt.IsZero() {
+		t.Errorf("decoded sparse listing = %+v", got)
+	}
+}
+
+func TestExportJSONFieldsOrder(t *testing.T) {
+	listings := []models.Listing{
+		{ID: "1111111111", Title: "iPhone 15", URL: "https://www.avito.ru/moskva/telefony/iphone_15_1111111111", Location: "Москва"},
+		// Empty optional fields are left out as in WriteJSON
+		{ID: "2222222222", Title: "Samsung Galaxy S24"},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportJSONFields(&buf, listings, []string{"url", "location", "id", "title"}); err != nil {
+		t.Fatalf("ExportJSONFields: %v", err)
+	}
+
+	want := `[{"url":"https://www.avito.ru/moskva/telefony/iphone_15_1111111111","location":"Москва","id":"1111111111","title":"iPhone 15"},` +
+		`{"url":"","id":"2222222222","title":"Samsung Galaxy S24"}]` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("ExportJSONFields wrote\n%s\nwant\n%s", got, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("output isn't valid JSON: %s", buf.String())
+	}
+}
+
+func TestExportJSONFieldsUnknownField(t *testing.T) {
+	var buf bytes.Buffer
+	err := ExportJSONFields(&buf, []models.Listing{{ID: "1111111111"}}, []string{"id", "Title"})
+	if err == nil {
+		t.Fatal("ExportJSONFields accepted a Go field name instead of its JSON key")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("ExportJSONFields wrote %q before failing", buf.String())
+	}
+}
@@ -0,0 +1,28 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// JSONLWriter writes listings as newline-delimited JSON, one complete object per line,
+// so output can be consumed while scraping is still in progress
+type JSONLWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONLWriter creates a JSONLWriter writing to w
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{encoder: json.NewEncoder(w)}
+}
+
+// Write writes a single listing followed by a newline
+func (w *JSONLWriter) Write(listing models.Listing) error {
+	if err := w.encoder.Encode(listing); err != nil {
+		return fmt.Errorf("error encoding listing %s: %w", listing.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint returns a hash of the fields that identify a listing and its offer: the
+// ID, the title, the URL without its query or fragment, and the price. It is the same
+// across runs for a listing that hasn't changed, and differs once any of these fields
+// do, so stores can use it to spot new or changed listings without comparing every
+// field. Whitespace in the title and the case of the URL's host don't affect it.
+func (l Listing) Fingerprint() string {
+	fields := []string{
+		strings.TrimSpace(l.ID),
+		strings.Join(strings.Fields(l.Title), " "),
+		fingerprintURL(l.URL),
+		strconv.FormatFloat(l.Price.Value, 'f', -1, 64),
+		strconv.FormatFloat(l.Price.Min, 'f', -1, 64),
+		strconv.FormatFloat(l.Price.Max, 'f', -1, 64),
+		l.Price.Currency,
+		l.Price.Unit,
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprintURL canonicalizes a listing URL like the parser does for the links it
+// follows, then drops the whole query and a trailing slash. The parser keeps queries
+// other than tracking parameters because they select what a page shows, but no query
+// parameter identifies a listing, and the listing must keep its fingerprint however it
+// was reached.
+func fingerprintURL(rawURL string) string {
+	parsed, err := CanonicalURL(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+
+	parsed.RawQuery, parsed.ForceQuery = "", false
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	parsed.RawPath = strings.TrimSuffix(parsed.RawPath, "/")
+	return parsed.String()
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// fingerprintListing is a listing as a scrape would find it
+func fingerprintListing() Listing {
+	return Listing{
+		ID:          "1111111111",
+		Title:       "iPhone 15",
+		URL:         "https://www.avito.ru/moskva/telefony/iphone_15_1111111111",
+		Price:       Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+		Description: "Отличный телефон",
+		PublishedAt: time.Date(2024, time.March, 5, 10, 15, 0, 0, time.UTC),
+	}
+}
+
+func TestFingerprintEqualListings(t *testing.T) {
+	a, b := fingerprintListing(), fingerprintListing()
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("equal listings have fingerprints %s and %s", a.Fingerprint(), b.Fingerprint())
+	}
+	if len(a.Fingerprint()) != 64 {
+		t.Errorf("Fingerprint() = %q, want a hex SHA-256", a.Fingerprint())
+	}
+}
+
+func TestFingerprintStableAcrossRuns(t *testing.T) {
+	// Stores keep fingerprints between runs, so the hash of a listing mustn't change
+	const want = "247b37857cb566f619a4b910b829f027860174c812e4fef75012c80874279b2f"
+	if got := fingerprintListing().Fingerprint(); got != want {
+		t.Errorf("Fingerprint() = %s, want %s", got, want)
+	}
+}
+
+func TestFingerprintChanges(t *testing.T) {
+	base := fingerprintListing().Fingerprint()
+
+	changes := map[string]func(*Listing){
+		"price":    func(l *Listing) { l.Price.Value = 59990 },
+		"currency": func(l *Listing) { l.Price.Currency = "USD" },
+		"range":    func(l *Listing) { l.Price.Max = 70000 },
+		"unit":     func(l *Listing) { l.Price.Unit = PriceUnitMonth },
+		"title":    func(l *Listing) { l.Title = "iPhone 15 Pro" },
+		"ID":       func(l *Listing) { l.ID = "2222222222" },
+		"URL path": func(l *Listing) { l.URL = "https://www.avito.ru/moskva/telefony/iphone_15_2222222222" },
+	}
+	for name, change := range changes {
+		listing := fingerprintListing()
+		change(&listing)
+		if listing.Fingerprint() == base {
+			t.Errorf("changing the %s kept the fingerprint", name)
+		}
+	}
+}
+
+func TestFingerprintIgnoresNonIdentifyingFields(t *testing.T) {
+	base := fingerprintListing().Fingerprint()
+
+	same := map[string]func(*Listing){
+		"description":      func(l *Listing) { l.Description = "Продан" },
+		"publication date": func(l *Listing) { l.PublishedAt = time.Now() },
+		"price text":       func(l *Listing) { l.Price.Text = "65 000 ₽" },
+		"title whitespace": func(l *Listing) { l.Title = "  iPhone   15 " },
+		"tracking query":   func(l *Listing) { l.URL += "?context=abc#photos" },
+		"host case":        func(l *Listing) { l.URL = "https://WWW.Avito.ru/moskva/telefony/iphone_15_1111111111/" },
+		"repeated slashes": func(l *Listing) { l.URL = "https://www.avito.ru//moskva/telefony//iphone_15_1111111111" },
+	}
+	for name, change := range same {
+		listing := fingerprintListing()
+		change(&listing)
+		if got := listing.Fingerprint(); got != base {
+			t.Errorf("changing the %s changed the fingerprint", name)
+		}
+	}
+}
@@ -4,22 +4,159 @@ import "time"
 
 // Listing represents an individual listing from Avito.ru
 type Listing struct {
-	ID          string            `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description,omitempty"`
-	Price       Price             `json:"price"`
-	URL         string            `json:"url"`
-	ImageURLs   []string          `json:"imageUrls,omitempty"`
-	Location    string            `json:"location,omitempty"`
-	CategoryID  string            `json:"categoryId,omitempty"`
-	CategoryURL string            `json:"categoryUrl,omitempty"`
-	PublishedAt time.Time         `json:"publishedAt,omitempty"`
-	Attributes  map[string]string `json:"attributes,omitempty"`
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Price       Price    `json:"price"`
+	URL         string   `json:"url"`
+	ImageURLs   []string `json:"imageUrls,omitempty"`
+	Location    string   `json:"location,omitempty"`
+	Latitude    float64  `json:"latitude,omitempty"`
+	Longitude   float64  `json:"longitude,omitempty"`
+	// HasCoordinates is set when Latitude and Longitude were found on the page
+	HasCoordinates bool `json:"hasCoordinates,omitempty"`
+	// CategoryID is the slug of the deepest category in the listing's breadcrumbs,
+	// e.g. "mobilnye_telefony", and CategoryPath holds the breadcrumb names from the
+	// top-level category down, e.g. ["Электроника", "Телефоны", "Мобильные телефоны"]
+	CategoryID   string   `json:"categoryId,omitempty"`
+	CategoryPath []string `json:"categoryPath,omitempty"`
+	// CategoryURL is the category or search URL the listing was found through
+	CategoryURL string    `json:"categoryUrl,omitempty"`
+	PublishedAt time.Time `json:"publishedAt,omitempty"`
+	// Attributes maps each parameter name to its value; repeated parameters are joined with ", "
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// AttributesList holds the parameters in page order, including repeated ones
+	AttributesList []KeyValue `json:"attributesList,omitempty"`
+	HasVideo       bool       `json:"hasVideo,omitempty"`
+	// DeliveryAvailable is set when the item can be bought with Avito Delivery
+	DeliveryAvailable bool `json:"deliveryAvailable,omitempty"`
+	// IsActive is set for listings that are still published. It is false when the
+	// listing page says the item was sold or the listing was closed or removed.
+	IsActive bool `json:"isActive"`
+	// Condition is whether the item is new or used, one of the Condition constants
+	Condition string `json:"condition,omitempty"`
+	// IsPromoted is set for listings the seller paid to promote, such as VIP,
+	// premium or highlighted ones, which Avito mixes into the organic results
+	IsPromoted bool `json:"isPromoted,omitempty"`
+
+	// Views and Favorites are the view count and the number of users who added the
+	// listing to their favorites, as shown on the listing page (0 when not shown)
+	Views     int `json:"views,omitempty"`
+	Favorites int `json:"favorites,omitempty"`
+
+	// PhotoCount is the number of photos the listing has, which may exceed
+	// len(ImageURLs) when only some are shown or MaxImages caps them
+	PhotoCount int `json:"photoCount,omitempty"`
+
+	// DescriptionHTML is the inner HTML of the description on the listing page, for
+	// consumers that render it; Description holds the same text without markup
+	DescriptionHTML string `json:"descriptionHtml,omitempty"`
+
+	// PricePerSquareMeter is the price divided by the area in м² for real estate, with
+	// the area taken from the "Общая площадь" or "Площадь" parameter or the title.
+	// It is 0 when the area or a total price is unknown.
+	PricePerSquareMeter float64 `json:"pricePerSquareMeter,omitempty"`
+
+	// Car holds the structured parameters of car listings, parsed from the listing
+	// page; it is nil for other listings and for cars found only on category pages
+	Car *CarDetails `json:"car,omitempty"`
+
+	SellerName      string `json:"sellerName,omitempty"`
+	SellerType      string `json:"sellerType,omitempty"`
+	SellerURL       string `json:"sellerUrl,omitempty"`
+	SellerItemCount int    `json:"sellerItemCount,omitempty"`
+
+	DiscountEndsAt time.Time `json:"discountEndsAt,omitempty"`
+}
+
+// KeyValue is a single listing parameter such as "Количество комнат: 2"
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+
+	// Number and Unit hold the normalized value of numeric parameters,
+	// e.g. 54.5 and "м²" for "54,5 м²"; IsNumeric is set when they are present
+	Number    float64 `json:"number,omitempty"`
+	Unit      string  `json:"unit,omitempty"`
+	IsNumeric bool    `json:"isNumeric,omitempty"`
+}
+
+// CarDetails are the parameters of a car listing, such as "Год выпуска: 2018" or
+// "Пробег: 85 000 км". Text fields hold the values as written on Avito, e.g.
+// "Автомат" or "Передний"; fields the listing doesn't state are left empty.
+type CarDetails struct {
+	Make  string `json:"make,omitempty"`
+	Model string `json:"model,omitempty"`
+	Year  int    `json:"year,omitempty"`
+
+	// Mileage is in kilometers
+	Mileage int `json:"mileage,omitempty"`
+
+	// EngineVolume is in liters and EnginePower in horsepower
+	EngineVolume float64 `json:"engineVolume,omitempty"`
+	EnginePower  int     `json:"enginePower,omitempty"`
+	EngineType   string  `json:"engineType,omitempty"`
+
+	Transmission string `json:"transmission,omitempty"`
+	DriveType    string `json:"driveType,omitempty"`
+	BodyType     string `json:"bodyType,omitempty"`
+	Color        string `json:"color,omitempty"`
+	Steering     string `json:"steering,omitempty"`
+
+	// Owners is the number of owners in the vehicle passport, 4 for "4+"
+	Owners int `json:"owners,omitempty"`
+
+	// VIN is the VIN or body number as shown, usually partly masked with "*".
+	// HasVIN is set when the listing states one.
+	VIN    string `json:"vin,omitempty"`
+	HasVIN bool   `json:"hasVin,omitempty"`
 }
 
+// Seller types as reported in Listing.SellerType
+const (
+	SellerTypePrivate = "private"
+	SellerTypeCompany = "company"
+)
+
+// Item conditions as reported in Listing.Condition
+const (
+	ConditionNew  = "new"
+	ConditionUsed = "used"
+	// ConditionUnknown is reported when the listing doesn't state the condition
+	ConditionUnknown = ""
+)
+
 // Price represents a price with currency information
 type Price struct {
 	Value    float64 `json:"value"`
 	Currency string  `json:"currency"`
 	Text     string  `json:"text"`
+	From     bool    `json:"from,omitempty"`
+
+	// Min and Max hold the bounds of ranges and "от"/"до" prices
+	Min        float64 `json:"min,omitempty"`
+	Max        float64 `json:"max,omitempty"`
+	IsRange    bool    `json:"isRange,omitempty"`
+	Negotiable bool    `json:"negotiable,omitempty"`
+
+	// Unit is the period or quantity the price refers to, one of the PriceUnit constants
+	Unit string `json:"unit,omitempty"`
 }
+
+// PriceHistory is a price of a listing as observed by a scrape
+type PriceHistory struct {
+	ListingID  string    `json:"listingId"`
+	Value      float64   `json:"value"`
+	Currency   string    `json:"currency"`
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// Price units as reported in Price.Unit
+const (
+	PriceUnitMonth       = "month"
+	PriceUnitDay         = "day"
+	PriceUnitHour        = "hour"
+	PriceUnitSquareMeter = "m2"
+	PriceUnitSotka       = "sotka"
+	PriceUnitPiece       = "piece"
+)
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CanonicalURL parses rawURL and normalizes the parts that never change which page it
+// points to: the host is lowercased, runs of slashes in the path are collapsed and the
+// fragment is removed. The query is left as it is, since only the caller knows which
+// of its parameters matter.
+func CanonicalURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Path = collapseSlashes(parsed.Path)
+	parsed.RawPath = collapseSlashes(parsed.RawPath)
+	parsed.Fragment, parsed.RawFragment = "", ""
+	return parsed, nil
+}
+
+// collapseSlashes replaces runs of slashes in a URL path with a single slash
+func collapseSlashes(path string) string {
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+	return path
+}
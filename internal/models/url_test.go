@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://WWW.Avito.ru/moskva/telefony", "https://www.avito.ru/moskva/telefony"},
+		{"https://www.avito.ru//moskva///telefony", "https://www.avito.ru/moskva/telefony"},
+		{"https://www.avito.ru/moskva/telefony?p=2&context=abc#photos", "https://www.avito.ru/moskva/telefony?p=2&context=abc"},
+		{"/moskva/telefony#top", "/moskva/telefony"},
+	}
+
+	for _, tt := range tests {
+		got, err := CanonicalURL(tt.rawURL)
+		if err != nil {
+			t.Errorf("CanonicalURL(%q): %v", tt.rawURL, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("CanonicalURL(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+
+	if _, err := CanonicalURL("https://www.avito.ru/%zz"); err == nil {
+		t.Error("CanonicalURL accepted a malformed escape")
+	}
+}
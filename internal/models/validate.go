@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidListing is returned by Listing.Validate; the error message lists the problems found
+var ErrInvalidListing = errors.New("invalid listing")
+
+// Validate checks the fields every listing is expected to have: a non-empty ID,
+// an absolute http or https URL, and a parsed price. A price counts as parsed when
+// it has a positive value or bound, is negotiable, or the item is given away for
+// free. Other fields, such as the description or the publication date, are often
+// missing from category pages and aren't checked. The returned error wraps
+// ErrInvalidListing and lists every problem found.
+func (l Listing) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(l.ID) == "" {
+		problems = append(problems, "missing ID")
+	}
+
+	if l.URL == "" {
+		problems = append(problems, "missing URL")
+	} else if parsed, err := url.Parse(l.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		problems = append(problems, fmt.Sprintf("URL %q is not absolute", l.URL))
+	}
+
+	if !l.Price.isParsed() {
+		if l.Price.Text == "" {
+			problems = append(problems, "missing price")
+		} else {
+			problems = append(problems, fmt.Sprintf("unparsed price %q", l.Price.Text))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	if id := strings.TrimSpace(l.ID); id != "" {
+		return fmt.Errorf("%w %s: %s", ErrInvalidListing, id, strings.Join(problems, ", "))
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidListing, strings.Join(problems, ", "))
+}
+
+// isParsed reports whether the price holds a usable value
+func (p Price) isParsed() bool {
+	if p.Value > 0 || p.Min > 0 || p.Max > 0 || p.Negotiable {
+		return true
+	}
+
+	lower := strings.ToLower(p.Text)
+	return strings.Contains(lower, "бесплатно") || strings.Contains(lower, "даром")
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Listing{
+		ID:    "1111111111",
+		URL:   "https://www.avito.ru/moskva/telefony/iphone_15_1111111111",
+		Price: Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*Listing)
+		problems []string
+	}{
+		{name: "valid", modify: func(*Listing) {}},
+		{name: "negotiable price", modify: func(l *Listing) { l.Price = Price{Negotiable: true, Text: "Договорная"} }},
+		{name: "free", modify: func(l *Listing) { l.Price = Price{Text: "Бесплатно"} }},
+		{name: "price range", modify: func(l *Listing) { l.Price = Price{Min: 1000, Max: 2000, IsRange: true} }},
+		{name: "missing ID", modify: func(l *Listing) { l.ID = " " }, problems: []string{"missing ID"}},
+		{name: "missing URL", modify: func(l *Listing) { l.URL = "" }, problems: []string{"missing URL"}},
+		{name: "relative URL", modify: func(l *Listing) { l.URL = "/moskva/telefony/iphone_15_1111111111" }, problems: []string{"is not absolute"}},
+		{name: "other scheme", modify: func(l *Listing) { l.URL = "ftp://www.avito.ru/item" }, problems: []string{"is not absolute"}},
+		{name: "missing price", modify: func(l *Listing) { l.Price = Price{} }, problems: []string{"missing price"}},
+		{name: "unparsed price", modify: func(l *Listing) { l.Price = Price{Text: "Цена не указана"} }, problems: []string{`unparsed price "Цена не указана"`}},
+		{name: "everything missing", modify: func(l *Listing) { *l = Listing{} }, problems: []string{"missing ID", "missing URL", "missing price"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := valid
+			tt.modify(&listing)
+
+			err := listing.Validate()
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidListing) {
+				t.Fatalf("Validate() = %v, want ErrInvalidListing", err)
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Validate() = %q, want it to mention %q", err, problem)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateNamesListing(t *testing.T) {
+	err := Listing{ID: "1111111111"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "1111111111") {
+		t.Errorf("Validate() = %v, want the listing's ID in the error", err)
+	}
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterRisesThenFalls(t *testing.T) {
+	const step = 10 * time.Millisecond
+	l := NewAdaptiveLimiter(step, 4*step, step)
+
+	want := func(interval time.Duration) {
+		t.Helper()
+		if got := l.Interval(); got != interval {
+			t.Fatalf("Interval = %v, want %v", got, interval)
+		}
+	}
+
+	want(step)
+
+	// Each 429 lengthens the interval by a step, up to the ceiling
+	for _, interval := range []time.Duration{2 * step, 3 * step, 4 * step, 4 * step} {
+		l.Throttled()
+		want(interval)
+	}
+
+	// Successes only shorten it once they make a streak
+	for i := 1; i < adaptiveSuccessStreak; i++ {
+		l.Success()
+	}
+	want(4 * step)
+	l.Success()
+	want(3 * step)
+
+	// A 429 in the middle of a streak starts it over
+	for i := 1; i < adaptiveSuccessStreak; i++ {
+		l.Success()
+	}
+	l.Throttled()
+	want(4 * step)
+	for i := 1; i < adaptiveSuccessStreak; i++ {
+		l.Success()
+	}
+	want(4 * step)
+
+	// Long runs of successes bring it down to the floor and no further
+	for i := 0; i < 10*adaptiveSuccessStreak; i++ {
+		l.Success()
+	}
+	want(step)
+}
+
+func TestAdaptiveLimiterCeilingBelowFloor(t *testing.T) {
+	l := NewAdaptiveLimiter(time.Second, time.Millisecond, time.Second)
+	l.Throttled()
+	if got := l.Interval(); got != time.Second {
+		t.Errorf("Interval = %v, want the floor as the ceiling", got)
+	}
+}
+
+func TestAdaptiveLimiterSpacesWaits(t *testing.T) {
+	const step = 30 * time.Millisecond
+	l := NewAdaptiveLimiter(0, step, step)
+	l.Throttled()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*step {
+		t.Errorf("3 waits took %v, want at least %v at the raised interval", elapsed, 2*step)
+	}
+}
+
+func TestAdaptiveLimiterHearsResponses(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two requests are rate limited, the rest succeed
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= 2 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+
+	const step = time.Millisecond
+	l := NewAdaptiveLimiter(step, 10*step, step)
+	p := newTestParser(t, srv.URL, ParserOptions{Limiter: l, MaxRetries: 2, SkipDetails: true})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got := l.Interval(); got != 3*step {
+		t.Fatalf("Interval after two 429s = %v, want %v", got, 3*step)
+	}
+
+	// The retry was the first success of the streak
+	for i := 1; i < 2*adaptiveSuccessStreak; i++ {
+		if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+			t.Fatalf("GetListings: %v", err)
+		}
+	}
+	if got := l.Interval(); got != step {
+		t.Errorf("Interval after two streaks of successes = %v, want %v", got, step)
+	}
+}
+
+func TestAdaptiveLimiterHearsBlockPages(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "blocked.html"})
+	l := NewAdaptiveLimiter(time.Millisecond, time.Second, time.Millisecond)
+	p := newFixtureParser(t, srv, ParserOptions{Limiter: l, SkipDetails: true})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("GetListings error = %v, want ErrBlocked", err)
+	}
+	if got := l.Interval(); got != 2*time.Millisecond {
+		t.Errorf("Interval after a block page = %v, want it raised a step", got)
+	}
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestApartmentPricePerSquareMeter(t *testing.T) {
+	// 12 500 000 ₽ for 45,5 м²
+	listing := fetchFixtureListing(t, "item_flat.html", ParserOptions{})
+	if listing.PricePerSquareMeter != 274725.27 {
+		t.Errorf("PricePerSquareMeter = %v, want 274725.27", listing.PricePerSquareMeter)
+	}
+
+	// Cars have no area
+	if car := fetchFixtureListing(t, "item_car.html", ParserOptions{}); car.PricePerSquareMeter != 0 {
+		t.Errorf("car PricePerSquareMeter = %v, want 0", car.PricePerSquareMeter)
+	}
+}
+
+func TestCardPricePerSquareMeter(t *testing.T) {
+	listings, err := ParseItemsFromHTML(`<div data-marker="item" data-item-id="1111111111">
+		<a href="/moskva/kvartiry/1-k_kvartira_1111111111"><h3 itemprop="name">1-к. квартира, 32 м², 3/9 эт.</h3></a>
+		<span data-marker="item-price" data-price="8000000">8 000 000 ₽</span>
+	</div>`)
+	if err != nil {
+		t.Fatalf("ParseItemsFromHTML: %v", err)
+	}
+	if len(listings) != 1 {
+		t.Fatalf("got %d listings, want 1", len(listings))
+	}
+	if got := listings[0].PricePerSquareMeter; got != 250000 {
+		t.Errorf("PricePerSquareMeter = %v, want 250000", got)
+	}
+}
+
+func TestPricePerSquareMeter(t *testing.T) {
+	area := func(key, unit string, value float64) []models.KeyValue {
+		return []models.KeyValue{{Key: key, Number: value, Unit: unit, IsNumeric: true}}
+	}
+
+	tests := []struct {
+		name    string
+		listing models.Listing
+		want    float64
+	}{
+		{
+			name:    "total area",
+			listing: models.Listing{Price: models.Price{Value: 9000000}, AttributesList: area("Общая площадь", "м²", 60)},
+			want:    150000,
+		},
+		{
+			name:    "area in кв. м",
+			listing: models.Listing{Price: models.Price{Value: 3000000}, AttributesList: area("Площадь помещения", "кв. м", 40)},
+			want:    75000,
+		},
+		{
+			name:    "area from the title",
+			listing: models.Listing{Title: "Студия, 25,5 м², 2/5 эт.", Price: models.Price{Value: 5100000}},
+			want:    200000,
+		},
+		{
+			name:    "rounded to kopecks",
+			listing: models.Listing{Price: models.Price{Value: 1000000}, AttributesList: area("Площадь", "м²", 3)},
+			want:    333333.33,
+		},
+		{
+			name:    "price already per м²",
+			listing: models.Listing{Price: models.Price{Value: 120000, Unit: models.PriceUnitSquareMeter}},
+			want:    120000,
+		},
+		{
+			name:    "monthly rent",
+			listing: models.Listing{Price: models.Price{Value: 45000, Unit: models.PriceUnitMonth}, AttributesList: area("Общая площадь", "м²", 40)},
+		},
+		{
+			name:    "land in сотки",
+			listing: models.Listing{Price: models.Price{Value: 900000}, AttributesList: area("Площадь", "сот.", 6)},
+		},
+		{
+			name:    "price range",
+			listing: models.Listing{Price: models.Price{Value: 1000, Min: 1000, Max: 2000, IsRange: true}, AttributesList: area("Площадь", "м²", 10)},
+		},
+		{
+			name:    "no area",
+			listing: models.Listing{Title: "Гараж", Price: models.Price{Value: 500000}},
+		},
+		{
+			name:    "no price",
+			listing: models.Listing{AttributesList: area("Общая площадь", "м²", 40)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pricePerSquareMeter(tt.listing); got != tt.want {
+				t.Errorf("pricePerSquareMeter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestApartmentAttributes(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_flat.html", ParserOptions{})
+
+	wantList := []models.KeyValue{
+		{Key: "Количество комнат", Value: "2", Number: 2, IsNumeric: true},
+		{Key: "Общая площадь", Value: "45,5 м²", Number: 45.5, Unit: "м²", IsNumeric: true},
+		{Key: "Этаж", Value: "5 из 9"},
+		{Key: "Ремонт", Value: "косметический"},
+		{Key: "Балкон"},
+	}
+	if !reflect.DeepEqual(listing.AttributesList, wantList) {
+		t.Errorf("AttributesList = %+v, want %+v", listing.AttributesList, wantList)
+	}
+
+	wantMap := map[string]string{
+		"Количество комнат": "2",
+		"Общая площадь":     "45,5 м²",
+		"Этаж":              "5 из 9",
+		"Ремонт":            "косметический",
+		"Балкон":            "",
+	}
+	if !reflect.DeepEqual(listing.Attributes, wantMap) {
+		t.Errorf("Attributes = %v, want %v", listing.Attributes, wantMap)
+	}
+}
+
+func TestCarAttributes(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_car.html", ParserOptions{})
+
+	if n := len(listing.AttributesList); n != 16 {
+		t.Fatalf("got %d attributes, want 16: %+v", n, listing.AttributesList)
+	}
+
+	byKey := make(map[string][]models.KeyValue)
+	for _, attribute := range listing.AttributesList {
+		byKey[attribute.Key] = append(byKey[attribute.Key], attribute)
+	}
+
+	// Repeated parameters keep every value in the list and are joined in the map
+	if equipment := byKey["Комплектация"]; len(equipment) != 2 {
+		t.Errorf("Комплектация rows = %+v, want 2", equipment)
+	}
+	if got, want := listing.Attributes["Комплектация"], "Климат-контроль, Подогрев сидений"; got != want {
+		t.Errorf("Attributes[Комплектация] = %q, want %q", got, want)
+	}
+
+	// Numbers are normalized, including those with non-breaking digit group separators
+	if mileage := byKey["Пробег"][0]; !mileage.IsNumeric || mileage.Number != 85000 || mileage.Unit != "км" {
+		t.Errorf("Пробег = %+v, want 85000 км", mileage)
+	}
+	if year := byKey["Год выпуска"][0]; !year.IsNumeric || year.Number != 2019 {
+		t.Errorf("Год выпуска = %+v, want 2019", year)
+	}
+	if vin := byKey["VIN или номер кузова"][0]; vin.IsNumeric || vin.Value != "XW7BF4FK**0*****7" {
+		t.Errorf("VIN = %+v", vin)
+	}
+
+	// A row without a colon is kept with an empty value
+	if book, ok := listing.Attributes["Сервисная книжка"]; !ok || book != "" {
+		t.Errorf("Attributes[Сервисная книжка] = %q, %v, want an empty value", book, ok)
+	}
+}
+
+func TestParseAttributesOldLayout(t *testing.T) {
+	doc := parseFragment(t, `<div class="item-params">
+		Тип дома: кирпичный
+		Этажей в доме: 9
+
+		Лифт
+	</div>`)
+
+	want := []models.KeyValue{
+		{Key: "Тип дома", Value: "кирпичный"},
+		{Key: "Этажей в доме", Value: "9", Number: 9, IsNumeric: true},
+		{Key: "Лифт"},
+	}
+	if got := parseAttributes(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAttributes = %+v, want %+v", got, want)
+	}
+}
+
+func TestListingCondition(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"item_iphone.html", models.ConditionUsed},
+		// "Новый, в плёнке" in the description alone doesn't count
+		{"item_samsung.html", models.ConditionUnknown},
+		{"item_flat.html", models.ConditionUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := fetchFixtureListing(t, tt.fixture, ParserOptions{}).Condition; got != tt.want {
+			t.Errorf("%s: Condition = %q, want %q", tt.fixture, got, tt.want)
+		}
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes []models.KeyValue
+		title      string
+		want       string
+	}{
+		{"new", []models.KeyValue{{Key: "Состояние", Value: "Новое"}}, "iPhone 15", models.ConditionNew},
+		{"new with tag", []models.KeyValue{{Key: "Состояние", Value: "Новое с биркой"}}, "Куртка", models.ConditionNew},
+		{"used", []models.KeyValue{{Key: "Состояние", Value: "Б/у"}}, "iPhone 15", models.ConditionUsed},
+		{"excellent", []models.KeyValue{{Key: "состояние", Value: "Отличное"}}, "Велосипед", models.ConditionUsed},
+		{"for parts", []models.KeyValue{{Key: "Состояние", Value: "На запчасти"}}, "Ноутбук", models.ConditionUsed},
+		{"attribute over title", []models.KeyValue{{Key: "Состояние", Value: "Б/у"}}, "Новый iPhone 15", models.ConditionUsed},
+		{"new title", nil, "Новый iPhone 15, 128 ГБ", models.ConditionNew},
+		{"used title", nil, "iPhone 15 б/у", models.ConditionUsed},
+		{"unrecognized attribute falls back to title", []models.KeyValue{{Key: "Состояние", Value: "Как есть"}}, "Новая куртка", models.ConditionNew},
+		{"word starting with нов", nil, "Новогодняя ёлка", models.ConditionUnknown},
+		{"unlabeled", []models.KeyValue{{Key: "Память", Value: "128 ГБ"}}, "iPhone 15", models.ConditionUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := parseCondition(tt.attributes, tt.title); got != tt.want {
+			t.Errorf("%s: parseCondition = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
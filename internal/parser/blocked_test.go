@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestGetListingsBlocked(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony": "blocked.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("GetListings error = %v, want ErrBlocked", err)
+	}
+	if len(listings) != 0 {
+		t.Errorf("got %d listings from a block page", len(listings))
+	}
+}
+
+func TestGetListingDetailsBlocked(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony/iphone_15_1111111111": "blocked.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	_, err := p.GetListingDetails(models.Listing{ID: "1111111111", URL: srv.URL + "/moskva/telefony/iphone_15_1111111111"})
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("GetListingDetails error = %v, want ErrBlocked", err)
+	}
+}
+
+func TestListingQuotingBlockPageIsNotBlocked(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/routery/router_5555555555": "item_quotes_block.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	listing, err := p.GetListingDetails(models.Listing{ID: "5555555555", URL: srv.URL + "/moskva/routery/router_5555555555"})
+	if err != nil {
+		t.Fatalf("GetListingDetails: %v", err)
+	}
+	if want := "Показывает «Доступ ограничен» для нежелательных сайтов."; listing.Description != want {
+		t.Errorf("Description = %q, want %q", listing.Description, want)
+	}
+}
+
+func TestIsBlockPage(t *testing.T) {
+	for _, fixture := range []string{"blocked.html"} {
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isBlockPage(body) {
+			t.Errorf("%s isn't recognized as a block page", fixture)
+		}
+	}
+
+	for _, fixture := range []string{"category.html", "item_iphone.html", "item_quotes_block.html"} {
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isBlockPage(body) {
+			t.Errorf("%s is taken for a block page", fixture)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// fixtureSize returns the size in bytes of a fixture in testdata
+func fixtureSize(t *testing.T, fixture string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("stat %s: %v", fixture, err)
+	}
+	return info.Size()
+}
+
+func TestMaxBodyBytesRejectsLargeResultsPage(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{
+		SkipDetails:  true,
+		MaxBodyBytes: fixtureSize(t, "category.html") - 1,
+	})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("GetListings error = %v, want ErrResponseTooLarge", err)
+	}
+	if len(listings) != 0 {
+		t.Errorf("got %d listings from a page that was too large", len(listings))
+	}
+}
+
+func TestMaxBodyBytesAllowsPageOfExactSize(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{
+		SkipDetails:  true,
+		MaxBodyBytes: fixtureSize(t, "category.html"),
+	})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+}
+
+func TestMaxBodyBytesRejectsLargeListingPage(t *testing.T) {
+	const path = "/moskva/listing_7777777777"
+	srv := newFixtureServer(t, map[string]string{path: "item_flat.html"})
+	p := newFixtureParser(t, srv, ParserOptions{MaxBodyBytes: 1024})
+
+	_, err := p.GetListingDetails(models.Listing{ID: "7777777777", URL: srv.URL + path})
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("GetListingDetails error = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+func TestMaxBodyBytesOption(t *testing.T) {
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	if got := p.opts.MaxBodyBytes; got != DefaultParserOptions().MaxBodyBytes || got <= 0 {
+		t.Errorf("default MaxBodyBytes = %d, want the finite default", got)
+	}
+
+	if _, err := NewParser(ParserOptions{MaxBodyBytes: -1}); err == nil {
+		t.Error("NewParser accepted a negative MaxBodyBytes")
+	}
+}
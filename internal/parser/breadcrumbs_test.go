@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListingCategoryFromBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		fixture        string
+		wantPath       []string
+		wantCategoryID string
+	}{
+		{"item_flat.html", []string{"Недвижимость", "Квартиры", "Продам", "Вторичка"}, "vtorichka"},
+		{"item_car.html", []string{"Транспорт", "Автомобили", "Toyota"}, "toyota"},
+		{"item_iphone.html", []string{"Электроника", "Телефоны"}, "telefony"},
+		// Pages without breadcrumbs leave both empty
+		{"item_samsung.html", nil, ""},
+	}
+
+	for _, tt := range tests {
+		listing := fetchFixtureListing(t, tt.fixture, ParserOptions{})
+		if !reflect.DeepEqual(listing.CategoryPath, tt.wantPath) || listing.CategoryID != tt.wantCategoryID {
+			t.Errorf("%s: CategoryPath = %q, CategoryID = %q, want %q and %q",
+				tt.fixture, listing.CategoryPath, listing.CategoryID, tt.wantPath, tt.wantCategoryID)
+		}
+	}
+}
+
+func TestParseBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		name           string
+		page           string
+		wantPath       []string
+		wantCategoryID string
+	}{
+		{
+			name: "microdata with absolute links",
+			page: `<ol itemtype="https://schema.org/BreadcrumbList">
+				<li><a href="https://www.avito.ru/">Главная</a></li>
+				<li><a href="https://www.avito.ru/sankt-peterburg">Санкт-Петербург</a></li>
+				<li><a href="https://www.avito.ru/sankt-peterburg/hobbi_i_otdyh">Хобби и отдых</a></li>
+				<li><a href="https://www.avito.ru/sankt-peterburg/velosipedy">Велосипеды</a></li>
+			</ol>`,
+			wantPath:       []string{"Хобби и отдых", "Велосипеды"},
+			wantCategoryID: "velosipedy",
+		},
+		{
+			name: "filters in the slug and repeated links",
+			page: `<div data-marker="breadcrumbs">
+				<a href="/moskva">Москва</a>
+				<a href="/moskva/avtomobili">Автомобили</a>
+				<a href="/moskva/avtomobili">Автомобили</a>
+				<a href="/moskva/avtomobili/s_probegom-ASgBAgICAUSGFMjmAQ?cd=1">С пробегом</a>
+			</div>`,
+			wantPath:       []string{"Автомобили", "С пробегом"},
+			wantCategoryID: "s_probegom",
+		},
+		{
+			name: "links outside the trail",
+			page: `<div class="breadcrumbs"><a href="/moskva/telefony">Телефоны</a><span>iPhone 15</span></div>
+				<a href="/moskva/noutbuki">Ноутбуки</a>`,
+			wantPath:       []string{"Телефоны"},
+			wantCategoryID: "telefony",
+		},
+		{
+			name: "no breadcrumbs",
+			page: `<h1>iPhone 15</h1>`,
+		},
+	}
+
+	for _, tt := range tests {
+		path, categoryID := parseBreadcrumbs(parseFragment(t, tt.page))
+		if !reflect.DeepEqual(path, tt.wantPath) || categoryID != tt.wantCategoryID {
+			t.Errorf("%s: parseBreadcrumbs = %q, %q, want %q, %q", tt.name, path, categoryID, tt.wantPath, tt.wantCategoryID)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// requestBudgetKey is the context key under which a traversal's requestBudget is stored
+type requestBudgetKey struct{}
+
+// requestBudget counts the requests left to a traversal. It is shared through the
+// context by every nested call, e.g. the subcategories visited from a catalog page.
+type requestBudget struct {
+	remaining atomic.Int64
+}
+
+// withRequestBudget returns ctx carrying a budget of MaxRequests requests, unless
+// ctx already carries one from an enclosing call or the budget is disabled
+func (p *Parser) withRequestBudget(ctx context.Context) context.Context {
+	if p.opts.MaxRequests <= 0 || requestBudgetFrom(ctx) != nil {
+		return ctx
+	}
+
+	budget := &requestBudget{}
+	budget.remaining.Store(int64(p.opts.MaxRequests))
+	return context.WithValue(ctx, requestBudgetKey{}, budget)
+}
+
+// requestBudgetFrom returns the budget carried by ctx, or nil if there is none
+func requestBudgetFrom(ctx context.Context) *requestBudget {
+	budget, _ := ctx.Value(requestBudgetKey{}).(*requestBudget)
+	return budget
+}
+
+// exhausted reports whether the budget has no requests left. A nil budget never runs out.
+func (b *requestBudget) exhausted() bool {
+	return b != nil && b.remaining.Load() <= 0
+}
+
+// take uses up one request and reports whether the budget allowed it
+func (b *requestBudget) take() bool {
+	return b == nil || b.remaining.Add(-1) >= 0
+}
+
+// checkRequestBudget returns ErrRequestBudgetExhausted once the traversal in ctx
+// has made all the requests it is allowed, so rawURL won't be fetched
+func checkRequestBudget(ctx context.Context, rawURL string) error {
+	if requestBudgetFrom(ctx).exhausted() {
+		return fmt.Errorf("%w: %s", ErrRequestBudgetExhausted, rawURL)
+	}
+	return nil
+}
+
+// limitRequests aborts the collector's requests once the budget in ctx runs out.
+// Responses served from the cache don't count against it.
+func (p *Parser) limitRequests(ctx context.Context, c *colly.Collector) {
+	budget := requestBudgetFrom(ctx)
+	if budget == nil {
+		return
+	}
+
+	c.OnRequest(func(r *colly.Request) {
+		if p.isCached(r.URL.String()) {
+			return
+		}
+		if !budget.take() {
+			log.Printf("Request budget of %d exhausted, skipping %s", p.opts.MaxRequests, r.URL)
+			r.Abort()
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+// sectionRoutes serve a catalog page linking to three subcategories, each showing
+// the two phone cards
+var sectionRoutes = map[string]string{
+	"/catalog/elektronika":                    "catalog_sections.html",
+	"/moskva/telefony":                        "category.html",
+	"/moskva/noutbuki":                        "category.html",
+	"/moskva/planshety":                       "category.html",
+	"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+	"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+}
+
+func TestRequestBudgetAcrossNestedCalls(t *testing.T) {
+	srv := newFixtureServer(t, sectionRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{MaxRequests: 4})
+
+	// The catalog page, then the first subcategory and its listing, then the second
+	// subcategory use up the budget before the second listing and the third subcategory
+	listings, err := p.GetListings(srv.URL+"/catalog/elektronika", 10)
+	if err != nil && !errors.Is(err, ErrRequestBudgetExhausted) {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) == 0 {
+		t.Error("the listings collected before the budget ran out were dropped")
+	}
+
+	if hits := srv.TotalHits(); hits != 4 {
+		t.Errorf("server got %d requests, want 4", hits)
+	}
+	if hits := srv.Hits("/moskva/noutbuki"); hits != 1 {
+		t.Errorf("second subcategory was requested %d times, want 1", hits)
+	}
+	if hits := srv.Hits("/moskva/planshety"); hits != 0 {
+		t.Errorf("third subcategory was requested %d times after the budget ran out", hits)
+	}
+}
+
+func TestRequestBudgetPerCall(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{MaxRequests: 2, Concurrency: 1})
+
+	// Each call gets a budget of its own
+	for i := 0; i < 2; i++ {
+		listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+		if err != nil {
+			t.Fatalf("GetListings: %v", err)
+		}
+		if len(listings) != 2 || listings[0].SellerName == "" || listings[1].SellerName != "" {
+			t.Errorf("call %d: want the first listing with details and the second without, got %+v", i+1, listings)
+		}
+	}
+	if hits := srv.TotalHits(); hits != 4 {
+		t.Errorf("server got %d requests, want 4", hits)
+	}
+}
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cacheHeader marks responses served from the Cache
+const cacheHeader = "X-Avitolog-Cache"
+
+// Cache stores fetched pages keyed by their full URL. Each entry is an opaque byte
+// slice holding the response headers and body, to be stored as is.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the entry stored under key, or false if it is missing or expired
+	Get(key string) ([]byte, bool)
+	// Set stores entry under key for ttl
+	Set(key string, entry []byte, ttl time.Duration)
+}
+
+// uncachedHeaders are response headers not replayed from the Cache: the body is stored
+// decoded and whole, and cookies must not be set again on every hit
+var uncachedHeaders = []string{"Content-Encoding", "Content-Length", "Transfer-Encoding", "Set-Cookie"}
+
+// MemoryCache is an in-memory LRU Cache with per-entry expiry
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front is most recently used
+}
+
+// memoryCacheEntry is a cached body with its expiry time
+type memoryCacheEntry struct {
+	key       string
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates a MemoryCache holding at most capacity entries.
+// A capacity of zero or less means no limit.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get implements Cache
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.body, true
+}
+
+// Set implements Cache
+func (c *MemoryCache) Set(key string, body []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.body, entry.expiresAt = body, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, body: body, expiresAt: expiresAt})
+
+	// Evict the least recently used entries once over capacity
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// cachingTransport serves GET requests from a Cache and stores successful responses in it
+type cachingTransport struct {
+	cache   Cache
+	ttl     time.Duration
+	maxBody int64
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	if entry, ok := t.cache.Get(key); ok {
+		// Entries that don't decode, e.g. ones stored by an older version, are refetched
+		if resp, err := decodeCacheEntry(entry, req); err == nil {
+			return resp, nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	// Read one byte past the limit, leaving oversized bodies for the collector to reject
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBody+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Anti-bot pages must not be replayed once the block is lifted
+	if !isBlockPage(body) && int64(len(body)) <= t.maxBody {
+		if entry, err := encodeCacheEntry(resp.Header, body); err == nil {
+			t.cache.Set(key, entry, t.ttl)
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// encodeCacheEntry stores a successful response as its HTTP/1.1 wire form so that its
+// headers, Content-Type in particular, are replayed with the body. colly only runs
+// HTML callbacks on responses whose Content-Type says they are HTML.
+func encodeCacheEntry(header http.Header, body []byte) ([]byte, error) {
+	header = header.Clone()
+	for _, name := range uncachedHeaders {
+		header.Del(name)
+	}
+
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+
+	var entry bytes.Buffer
+	if err := resp.Write(&entry); err != nil {
+		return nil, err
+	}
+	return entry.Bytes(), nil
+}
+
+// decodeCacheEntry turns an entry made by encodeCacheEntry back into a response to
+// req, marked with cacheHeader
+func decodeCacheEntry(entry []byte, req *http.Request) (*http.Response, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry)), req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header.Set(cacheHeader, "hit")
+	return resp, nil
+}
+
+// isCached reports whether rawURL can be served from the Parser's cache
+func (p *Parser) isCached(rawURL string) bool {
+	if p.opts.Cache == nil {
+		return false
+	}
+
+	_, ok := p.opts.Cache.Get(rawURL)
+	return ok
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestCacheServesRepeatedFetches(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{Cache: NewMemoryCache(10)})
+
+	route := "/moskva/telefony/iphone_15_1111111111"
+	listing := models.Listing{ID: "1111111111", URL: srv.URL + route}
+
+	first, err := p.GetListingDetails(listing)
+	if err != nil {
+		t.Fatalf("first GetListingDetails: %v", err)
+	}
+	second, err := p.GetListingDetails(listing)
+	if err != nil {
+		t.Fatalf("second GetListingDetails: %v", err)
+	}
+
+	if hits := srv.Hits(route); hits != 1 {
+		t.Errorf("listing page was requested %d times, want 1", hits)
+	}
+	if second.Description == "" || second.Location == "" || !second.IsActive {
+		t.Errorf("cached page wasn't parsed: %+v", second)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached fetch returned\n%+v\nwant\n%+v", second, first)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{Cache: NewMemoryCache(10), CacheTTL: time.Nanosecond})
+
+	route := "/moskva/telefony/iphone_15_1111111111"
+	listing := models.Listing{ID: "1111111111", URL: srv.URL + route}
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.GetListingDetails(listing); err != nil {
+			t.Fatalf("GetListingDetails: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if hits := srv.Hits(route); hits != 2 {
+		t.Errorf("listing page was requested %d times, want 2 once the entry expired", hits)
+	}
+}
+
+func TestCacheEntryRoundTrip(t *testing.T) {
+	header := http.Header{
+		"Content-Type":     {"text/html; charset=utf-8"},
+		"Content-Encoding": {"gzip"},
+		"Set-Cookie":       {"session=1"},
+	}
+	entry, err := encodeCacheEntry(header, []byte("<html></html>"))
+	if err != nil {
+		t.Fatalf("encodeCacheEntry: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://www.avito.ru/moskva", nil)
+	resp, err := decodeCacheEntry(entry, req)
+	if err != nil {
+		t.Fatalf("decodeCacheEntry: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want it replayed", got)
+	}
+	if got := resp.Header.Get(cacheHeader); got != "hit" {
+		t.Errorf("%s = %q, want hit", cacheHeader, got)
+	}
+	for _, name := range []string{"Content-Encoding", "Set-Cookie"} {
+		if got := resp.Header.Get(name); got != "" {
+			t.Errorf("%s = %q, want it dropped", name, got)
+		}
+	}
+
+	// Bare bodies, as stored before headers were kept, aren't valid entries
+	if _, err := decodeCacheEntry([]byte("<html></html>"), req); err == nil {
+		t.Error("decodeCacheEntry accepted a bare body")
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", []byte("1"), time.Minute)
+	cache.Set("b", []byte("2"), time.Minute)
+	cache.Get("a")
+	cache.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("least recently used entry b wasn't evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("entry %s was evicted", key)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+var (
+	// Regex to extract the engine volume from a modification like "1.6 AT (123 л.с.)"
+	modificationVolumeRegex = regexp.MustCompile(`^(\d+[.,]\d+)`)
+	// Regex to extract the engine power from values like "123 л.с." or "1.6 AT (123 л.с.)"
+	enginePowerRegex = regexp.MustCompile(`(\d[\d\s]*)\s*л\.\s*с\.?`)
+)
+
+// carCategoryPaths are URL path fragments of the passenger car categories
+var carCategoryPaths = []string{"/avtomobili"}
+
+// isCarListing reports whether a listing is in the passenger car category, going by
+// its breadcrumbs or, failing those, its URL or the category it was found through
+func isCarListing(listing models.Listing) bool {
+	for _, name := range listing.CategoryPath {
+		if strings.EqualFold(name, "Автомобили") {
+			return true
+		}
+	}
+	if len(listing.CategoryPath) > 0 {
+		return false
+	}
+
+	return pathMatches(listing.URL, carCategoryPaths) || pathMatches(listing.CategoryURL, carCategoryPaths)
+}
+
+// parseCarDetails collects the car parameters of a listing from its attributes. It
+// returns nil for listings outside the car category and for those without any of
+// the parameters.
+func parseCarDetails(listing models.Listing) *models.CarDetails {
+	if !isCarListing(listing) {
+		return nil
+	}
+
+	var car models.CarDetails
+	found := false
+	for _, attribute := range listing.AttributesList {
+		if setCarDetail(&car, attribute) {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	return &car
+}
+
+// setCarDetail stores a parameter of a car listing in car, reporting whether it
+// is one of the car parameters
+func setCarDetail(car *models.CarDetails, attribute models.KeyValue) bool {
+	value := attribute.Value
+	switch strings.ToLower(attribute.Key) {
+	case "марка":
+		car.Make = value
+	case "модель":
+		car.Model = value
+	case "год выпуска":
+		car.Year = parseCount(value)
+	case "пробег":
+		car.Mileage = parseCount(value)
+	case "объём двигателя", "объем двигателя":
+		if number, ok := parseNumber(strings.TrimSpace(strings.TrimSuffix(value, "л"))); ok {
+			car.EngineVolume = number
+		}
+	case "мощность двигателя", "мощность":
+		if matches := enginePowerRegex.FindStringSubmatch(value); matches != nil {
+			car.EnginePower = parseCount(matches[1])
+		} else {
+			car.EnginePower = parseCount(value)
+		}
+	case "модификация":
+		// Fills in the volume and power when they aren't listed on their own
+		if matches := modificationVolumeRegex.FindStringSubmatch(value); matches != nil && car.EngineVolume == 0 {
+			car.EngineVolume, _ = parseNumber(matches[1])
+		}
+		if matches := enginePowerRegex.FindStringSubmatch(value); matches != nil && car.EnginePower == 0 {
+			car.EnginePower = parseCount(matches[1])
+		}
+	case "тип двигателя":
+		car.EngineType = value
+	case "коробка передач":
+		car.Transmission = value
+	case "привод":
+		car.DriveType = value
+	case "тип кузова":
+		car.BodyType = value
+	case "цвет":
+		car.Color = value
+	case "руль":
+		car.Steering = value
+	case "владельцев по птс", "владельцев":
+		car.Owners = parseCount(value)
+	case "vin или номер кузова", "vin", "номер кузова":
+		car.VIN, car.HasVIN = value, value != ""
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestCarDetailsFromListingPage(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_car.html", ParserOptions{})
+
+	want := &models.CarDetails{
+		Make:         "Toyota",
+		Model:        "Camry",
+		Year:         2019,
+		Mileage:      85000,
+		EngineVolume: 2.5,
+		EnginePower:  181,
+		EngineType:   "Бензин",
+		Transmission: "Автомат",
+		DriveType:    "Передний",
+		BodyType:     "Седан",
+		Color:        "Белый",
+		Steering:     "Левый",
+		Owners:       1,
+		VIN:          "XW7BF4FK**0*****7",
+		HasVIN:       true,
+	}
+	if !reflect.DeepEqual(listing.Car, want) {
+		t.Errorf("Car = %+v, want %+v", listing.Car, want)
+	}
+}
+
+func TestNonCarListingsHaveNoCarDetails(t *testing.T) {
+	for _, fixture := range []string{"item_flat.html", "item_iphone.html"} {
+		if listing := fetchFixtureListing(t, fixture, ParserOptions{}); listing.Car != nil {
+			t.Errorf("%s: Car = %+v, want nil", fixture, listing.Car)
+		}
+	}
+}
+
+func TestParseCarDetails(t *testing.T) {
+	param := func(key, value string) models.KeyValue {
+		return models.KeyValue{Key: key, Value: value}
+	}
+
+	tests := []struct {
+		name    string
+		listing models.Listing
+		want    *models.CarDetails
+	}{
+		{
+			name: "separate engine rows win over the modification",
+			listing: models.Listing{
+				CategoryPath: []string{"Транспорт", "Автомобили"},
+				AttributesList: []models.KeyValue{
+					param("Модификация", "1.6 MT (102 л.с.)"),
+					param("Объём двигателя", "1,8 л"),
+					param("Мощность двигателя", "140 л.с."),
+					param("Владельцев по ПТС", "4+"),
+				},
+			},
+			want: &models.CarDetails{EngineVolume: 1.8, EnginePower: 140, Owners: 4},
+		},
+		{
+			name: "car category from the URL",
+			listing: models.Listing{
+				URL:            "https://www.avito.ru/moskva/avtomobili/lada_granta_1111111111",
+				AttributesList: []models.KeyValue{param("Марка", "LADA"), param("Пробег", "120 000 км")},
+			},
+			want: &models.CarDetails{Make: "LADA", Mileage: 120000},
+		},
+		{
+			name: "car category from the category page",
+			listing: models.Listing{
+				CategoryURL:    "https://www.avito.ru/moskva/avtomobili?q=kia",
+				AttributesList: []models.KeyValue{param("Год выпуска", "2021")},
+			},
+			want: &models.CarDetails{Year: 2021},
+		},
+		{
+			name: "motorcycle",
+			listing: models.Listing{
+				CategoryPath:   []string{"Транспорт", "Мотоциклы и мототехника"},
+				URL:            "https://www.avito.ru/moskva/avtomobili_i_moto/yamaha_1111111111",
+				AttributesList: []models.KeyValue{param("Марка", "Yamaha"), param("Год выпуска", "2015")},
+			},
+		},
+		{
+			name: "car without car parameters",
+			listing: models.Listing{
+				CategoryPath:   []string{"Транспорт", "Автомобили"},
+				AttributesList: []models.KeyValue{param("Комплектация", "Люкс")},
+			},
+		},
+		{
+			name: "empty VIN",
+			listing: models.Listing{
+				CategoryPath:   []string{"Автомобили"},
+				AttributesList: []models.KeyValue{param("VIN или номер кузова", ""), param("Руль", "Правый")},
+			},
+			want: &models.CarDetails{Steering: "Правый"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCarDetails(tt.listing); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCarDetails = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,8 +7,25 @@ import (
 	"github.com/itcaat/avitolog/internal/models"
 )
 
-const (
-	baseURL = "https://www.avito.ru"
+// defaultBaseURL is the site root used when ParserOptions.BaseURL is empty
+const defaultBaseURL = "https://www.avito.ru"
+
+var (
+	// defaultAllowedDomains are the hosts collectors may visit unless AllowedDomains
+	// or a BaseURL on another host say otherwise
+	defaultAllowedDomains = []string{"www.avito.ru", "avito.ru"}
+
+	// trackingParams are query parameters Avito and ad networks add to links to record
+	// where they were clicked; utm_* parameters are removed as well
+	trackingParams = map[string]bool{
+		"context":   true,
+		"slocation": true,
+		"from":      true,
+		"src":       true,
+		"gclid":     true,
+		"yclid":     true,
+		"fbclid":    true,
+	}
 )
 
 // GetCategories returns a predefined list of main categories and their subcategories from Avito.ru
@@ -148,8 +165,60 @@ func GetCategories() ([]models.Category, error) {
 	}, nil
 }
 
-// normalizeURL ensures the URL is absolute
+// FlattenCategories walks the category tree depth-first and returns its nodes as a flat slice.
+// When leavesOnly is true, only categories without subcategories are returned.
+func FlattenCategories(categories []models.Category, leavesOnly bool) []models.Category {
+	var flat []models.Category
+
+	for _, category := range categories {
+		if !leavesOnly || len(category.Subcategories) == 0 {
+			flat = append(flat, models.Category{Name: category.Name, URL: category.URL})
+		}
+
+		flat = append(flat, FlattenCategories(category.Subcategories, leavesOnly)...)
+	}
+
+	return flat
+}
+
+// normalizeURL makes the URL absolute and canonical so the same page always maps
+// to the same string: the host is lowercased, repeated slashes in the path are
+// collapsed and the fragment and tracking parameters (see trackingParams) are removed.
+// Relative links are resolved against Avito; use normalizeLink for links found on
+// pages of a Parser with another BaseURL.
 func normalizeURL(href string) string {
+	return normalizeLink(defaultBaseURL, href)
+}
+
+// normalizeLink works like normalizeURL, resolving relative links against base
+func normalizeLink(base, href string) string {
+	absolute := absoluteURL(base, href)
+
+	parsedURL, err := models.CanonicalURL(absolute)
+	if err != nil || parsedURL.Host == "" {
+		return absolute
+	}
+
+	if parsedURL.RawQuery != "" {
+		query := parsedURL.Query()
+		stripped := false
+		for key := range query {
+			if isTrackingParam(key) {
+				query.Del(key)
+				stripped = true
+			}
+		}
+		// Other queries are left as they are so signed image URLs stay valid
+		if stripped {
+			parsedURL.RawQuery = query.Encode()
+		}
+	}
+
+	return parsedURL.String()
+}
+
+// absoluteURL resolves links relative to the site root base
+func absoluteURL(base, href string) string {
 	if strings.HasPrefix(href, "http") {
 		return href
 	}
@@ -159,19 +228,25 @@ func normalizeURL(href string) string {
 	}
 
 	if strings.HasPrefix(href, "/") {
-		return baseURL + href
+		return base + href
 	}
 
 	// Try to parse the URL to handle other cases
 	parsedURL, err := url.Parse(href)
 	if err != nil {
-		return baseURL + "/" + href
+		return base + "/" + href
 	}
 
 	// If parsed successfully but is relative
 	if !parsedURL.IsAbs() {
-		return baseURL + "/" + href
+		return base + "/" + href
 	}
 
 	return href
 }
+
+// isTrackingParam reports whether a query parameter only records where a link was
+// clicked rather than selecting what the page shows
+func isTrackingParam(key string) bool {
+	return trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_")
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// categoryNode is a category as it appears in Avito's embedded rubricator state
+type categoryNode struct {
+	Name string         `json:"name"`
+	URL  string         `json:"url"`
+	Subs []categoryNode `json:"subs"`
+}
+
+// GetCategoriesLive scrapes the current category tree from Avito using the default parser
+func GetCategoriesLive() ([]models.Category, error) {
+	return defaultParser.GetCategoriesLive()
+}
+
+// GetCategoriesLive scrapes the current category tree from Avito.
+// It returns ErrCategoriesNotFound if the page structure isn't recognized.
+func (p *Parser) GetCategoriesLive() ([]models.Category, error) {
+	return p.GetCategoriesLiveContext(context.Background())
+}
+
+// GetCategoriesLiveContext scrapes the current category tree from Avito,
+// aborting when the context is cancelled or its deadline expires
+func (p *Parser) GetCategoriesLiveContext(ctx context.Context) ([]models.Category, error) {
+	var body []byte
+
+	c := p.newCollector(ctx)
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Println("Visiting", r.URL)
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		body = r.Body
+	})
+
+	p.trackRequestStats(ctx, c)
+
+	pageURL := p.regionalURL(p.opts.BaseURL + "/" + allRegions)
+	if err := p.visit(ctx, c, pageURL); err != nil {
+		return nil, fmt.Errorf("error visiting category page: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return parseCategoryTree(string(body), p.opts.BaseURL)
+}
+
+// parseCategoryTree builds the category tree from the rubricator state embedded in an
+// Avito page, resolving category links against base
+func parseCategoryTree(htmlContent, base string) ([]models.Category, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("%w: error parsing HTML: %w", ErrParseFailed, err)
+	}
+
+	var categories []models.Category
+	doc.Find("script[data-mfe-state]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data interface{}
+		if err := json.Unmarshal([]byte(html.UnescapeString(s.Text())), &data); err != nil {
+			return true
+		}
+
+		rawTree := findKey(data, "categoryTreeTop")
+		if rawTree == nil {
+			return true
+		}
+
+		encoded, err := json.Marshal(rawTree)
+		if err != nil {
+			return true
+		}
+
+		var nodes []categoryNode
+		if err := json.Unmarshal(encoded, &nodes); err != nil {
+			return true
+		}
+
+		categories = toCategories(nodes, base)
+		return len(categories) == 0
+	})
+
+	if len(categories) == 0 {
+		return nil, ErrCategoriesNotFound
+	}
+
+	return categories, nil
+}
+
+// findKey walks decoded JSON and returns the first value stored under key
+func findKey(node interface{}, key string) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if found, ok := value[key]; ok {
+			return found
+		}
+		for _, child := range value {
+			if found := findKey(child, key); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			if found := findKey(child, key); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+// toCategories converts rubricator nodes into categories, dropping tracking parameters from their URLs
+func toCategories(nodes []categoryNode, base string) []models.Category {
+	var categories []models.Category
+
+	for _, node := range nodes {
+		name := strings.TrimSpace(node.Name)
+		if name == "" || node.URL == "" {
+			continue
+		}
+
+		categoryURL := normalizeLink(base, node.URL)
+		if parsedURL, err := url.Parse(categoryURL); err == nil {
+			parsedURL.RawQuery = ""
+			categoryURL = parsedURL.String()
+		}
+
+		categories = append(categories, models.Category{
+			Name:          name,
+			URL:           categoryURL,
+			Subcategories: toCategories(node.Subs, base),
+		})
+	}
+
+	return categories
+}
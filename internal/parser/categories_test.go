@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestFlattenCategoriesDefaultTree(t *testing.T) {
+	tree, err := GetCategories()
+	if err != nil {
+		t.Fatalf("GetCategories: %v", err)
+	}
+
+	var want, wantLeaves []models.Category
+	for _, top := range tree {
+		want = append(want, models.Category{Name: top.Name, URL: top.URL})
+		for _, sub := range top.Subcategories {
+			if len(sub.Subcategories) != 0 {
+				t.Fatalf("%s has subcategories, the default tree has two levels", sub.Name)
+			}
+			want = append(want, sub)
+			wantLeaves = append(wantLeaves, sub)
+		}
+	}
+
+	all := FlattenCategories(tree, false)
+	if len(all) != len(want) {
+		t.Fatalf("got %d categories, want %d", len(all), len(want))
+	}
+	for i := range want {
+		if all[i].Name != want[i].Name || all[i].URL != want[i].URL || len(all[i].Subcategories) != 0 {
+			t.Errorf("category %d = %+v, want %+v", i, all[i], want[i])
+		}
+	}
+
+	leaves := FlattenCategories(tree, true)
+	if len(leaves) != len(wantLeaves) {
+		t.Fatalf("got %d leaves, want %d", len(leaves), len(wantLeaves))
+	}
+	for i := range wantLeaves {
+		if leaves[i].Name != wantLeaves[i].Name || leaves[i].URL != wantLeaves[i].URL {
+			t.Errorf("leaf %d = %+v, want %+v", i, leaves[i], wantLeaves[i])
+		}
+	}
+
+	if first := leaves[0]; first.Name != "Автомобили" || first.URL != "https://www.avito.ru/all/avtomobili" {
+		t.Errorf("first leaf = %+v, want Автомобили", first)
+	}
+}
+
+func TestFlattenCategoriesEmpty(t *testing.T) {
+	if flat := FlattenCategories(nil, true); len(flat) != 0 {
+		t.Errorf("FlattenCategories(nil) = %v, want none", flat)
+	}
+}
+
+func TestGetCategoriesLive(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/all":    "rubricator.html",
+		"/moskva": "rubricator.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	categories, err := p.GetCategoriesLive()
+	if err != nil {
+		t.Fatalf("GetCategoriesLive: %v", err)
+	}
+
+	want := []models.Category{
+		{
+			Name: "Транспорт",
+			URL:  srv.URL + "/all/transport",
+			Subcategories: []models.Category{
+				{Name: "Автомобили", URL: srv.URL + "/all/avtomobili"},
+				{Name: "Водный транспорт", URL: srv.URL + "/all/vodnyy_transport"},
+			},
+		},
+		{
+			Name: "Электроника",
+			URL:  srv.URL + "/all/bytovaya_elektronika",
+			Subcategories: []models.Category{
+				{Name: "Телефоны", URL: srv.URL + "/all/telefony"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(categories, want) {
+		t.Errorf("GetCategoriesLive returned\n%+v\nwant\n%+v", categories, want)
+	}
+
+	regional := newFixtureParser(t, srv, ParserOptions{Region: "moskva"})
+	if _, err := regional.GetCategoriesLive(); err != nil {
+		t.Fatalf("GetCategoriesLive in a region: %v", err)
+	}
+	if hits := srv.Hits("/moskva"); hits != 1 {
+		t.Errorf("regional page was requested %d times, want 1", hits)
+	}
+}
+
+func TestGetCategoriesLiveWithoutState(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/all": "category.html"})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	if _, err := p.GetCategoriesLive(); !errors.Is(err, ErrCategoriesNotFound) {
+		t.Errorf("GetCategoriesLive on a page without the rubricator = %v, want ErrCategoriesNotFound", err)
+	}
+}
+
+func TestNormalizeURLSameItem(t *testing.T) {
+	want := "https://www.avito.ru/moskva/telefony/iphone_15_1111111111"
+	for _, href := range []string{
+		"/moskva/telefony/iphone_15_1111111111",
+		"/moskva/telefony/iphone_15_1111111111?context=H4sIAAAAAAAA_0q0MrSqLrYytVJKKS0qSsxLVrKuBQQAAP__",
+		"https://WWW.Avito.ru//moskva/telefony//iphone_15_1111111111?slocation=637640&utm_source=share",
+		"//www.avito.ru/moskva/telefony/iphone_15_1111111111?from=catalog#photos",
+	} {
+		if got := normalizeURL(href); got != want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", href, got, want)
+		}
+	}
+}
+
+func TestNormalizeURLKeepsIdentifyingParams(t *testing.T) {
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"/all?q=iphone&context=abc", "https://www.avito.ru/all?q=iphone"},
+		{"/moskva/telefony?p=2&s=104&UTM_Campaign=x", "https://www.avito.ru/moskva/telefony?p=2&s=104"},
+		// Queries without tracking parameters are kept byte for byte, e.g. signed image URLs
+		{"https://img.avito.st/image/1/abc.jpg?sig=b%2Bc&t=1", "https://img.avito.st/image/1/abc.jpg?sig=b%2Bc&t=1"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeURL(tt.href); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.href, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLinkUsesBase(t *testing.T) {
+	got := normalizeLink("http://127.0.0.1:8080", "/moskva/telefony/iphone_15_1111111111?context=abc")
+	if want := "http://127.0.0.1:8080/moskva/telefony/iphone_15_1111111111"; got != want {
+		t.Errorf("normalizeLink = %q, want %q", got, want)
+	}
+}
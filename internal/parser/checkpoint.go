@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// Checkpoint records the listings whose details have been fetched so an interrupted
+// scrape can resume without fetching them again. Every listing is appended to the
+// checkpoint file as one JSON line as soon as it is fetched, so a crash loses at most
+// the listing being fetched at the time.
+type Checkpoint struct {
+	mu       sync.Mutex
+	file     *os.File
+	listings map[string]models.Listing
+}
+
+// LoadCheckpoint opens the checkpoint file at path, creating it if needed, and loads the
+// listings recorded by earlier runs. A truncated last line left by a crash is ignored.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint: %w", err)
+	}
+
+	checkpoint := &Checkpoint{
+		file:     file,
+		listings: make(map[string]models.Listing),
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var listing models.Listing
+		if err := json.Unmarshal(scanner.Bytes(), &listing); err != nil {
+			log.Printf("Skipping unreadable checkpoint entry: %v", err)
+			continue
+		}
+		if listing.URL != "" {
+			checkpoint.listings[normalizeURL(listing.URL)] = listing
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	// Terminate a truncated last line so new entries start on a line of their own
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := file.Write([]byte{'\n'}); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("error repairing checkpoint: %w", err)
+			}
+		}
+	}
+
+	log.Printf("Loaded %d listings from checkpoint %s", len(checkpoint.listings), path)
+	return checkpoint, nil
+}
+
+// Lookup returns the recorded listing for a listing URL
+func (c *Checkpoint) Lookup(listingURL string) (models.Listing, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listing, ok := c.listings[normalizeURL(listingURL)]
+	return listing, ok
+}
+
+// Len returns the number of recorded listings
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.listings)
+}
+
+// Record appends a fetched listing to the checkpoint file and syncs it to disk
+func (c *Checkpoint) Record(listing models.Listing) error {
+	if listing.URL == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(listing)
+	if err != nil {
+		return fmt.Errorf("error encoding listing %s: %w", listing.ID, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing checkpoint: %w", err)
+	}
+
+	c.listings[normalizeURL(listing.URL)] = listing
+	return nil
+}
+
+// Close closes the checkpoint file
+func (c *Checkpoint) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.file.Close()
+}
+
+// checkpointed returns the listing recorded for listingURL by an earlier run, if any
+func (p *Parser) checkpointed(listingURL string) (models.Listing, bool) {
+	if p.opts.Checkpoint == nil {
+		return models.Listing{}, false
+	}
+
+	listing, ok := p.opts.Checkpoint.Lookup(listingURL)
+	if ok {
+		log.Printf("Skipping %s, already in checkpoint", listingURL)
+	}
+	return listing, ok
+}
+
+// recordCheckpoint adds a fetched listing to the checkpoint, if one is configured
+func (p *Parser) recordCheckpoint(listing models.Listing) {
+	if p.opts.Checkpoint == nil {
+		return
+	}
+
+	if err := p.opts.Checkpoint.Record(listing); err != nil {
+		log.Printf("Error recording checkpoint: %v", err)
+	}
+}
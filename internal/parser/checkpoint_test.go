@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// loadTestCheckpoint opens the checkpoint at path, closing it with the test
+func loadTestCheckpoint(t *testing.T, path string) *Checkpoint {
+	t.Helper()
+
+	checkpoint, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	t.Cleanup(func() { checkpoint.Close() })
+	return checkpoint
+}
+
+func TestCheckpointResumesInterruptedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	srv := newFixtureServer(t, phoneRoutes)
+
+	// The first run is cut short after the category page and the first listing page
+	first := newFixtureParser(t, srv, ParserOptions{
+		Checkpoint:  loadTestCheckpoint(t, path),
+		MaxRequests: 2,
+		Concurrency: 1,
+	})
+	if _, err := first.GetListings(srv.URL+"/moskva/telefony", 10); err != nil {
+		t.Fatalf("interrupted GetListings: %v", err)
+	}
+	if hits := srv.Hits("/moskva/telefony/samsung_s24_2222222222"); hits != 0 {
+		t.Fatalf("second listing was fetched %d times in the interrupted run", hits)
+	}
+
+	// The second run reloads the checkpoint from disk
+	resumed := newFixtureParser(t, srv, ParserOptions{Checkpoint: loadTestCheckpoint(t, path)})
+	listings, err := resumed.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("resumed GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !slices.Equal(got, want) {
+		t.Errorf("listings = %v, want %v", got, want)
+	}
+	if listings[0].SellerName != "Иван" {
+		t.Errorf("checkpointed listing lost its details: %+v", listings[0])
+	}
+	if hits := srv.Hits("/moskva/telefony/iphone_15_1111111111"); hits != 1 {
+		t.Errorf("checkpointed listing was fetched %d times, want 1", hits)
+	}
+	if hits := srv.Hits("/moskva/telefony/samsung_s24_2222222222"); hits != 1 {
+		t.Errorf("remaining listing was fetched %d times, want 1", hits)
+	}
+}
+
+func TestLoadCheckpointSkipsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	content := `{"id":"1","url":"https://www.avito.ru/moskva/telefony/iphone_1"}` + "\n" + `{"id":"2","url":"https://www.av`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := loadTestCheckpoint(t, path)
+	if checkpoint.Len() != 1 {
+		t.Fatalf("loaded %d listings, want 1", checkpoint.Len())
+	}
+	if _, ok := checkpoint.Lookup("https://www.avito.ru/moskva/telefony/iphone_1?context=abc"); !ok {
+		t.Error("Lookup doesn't find a recorded listing by an equivalent URL")
+	}
+
+	if err := checkpoint.Record(models.Listing{ID: "3", URL: "https://www.avito.ru/moskva/telefony/pixel_3"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	checkpoint.Close()
+
+	// The new entry starts on a line of its own
+	reloaded := loadTestCheckpoint(t, path)
+	if reloaded.Len() != 2 {
+		t.Errorf("reloaded %d listings, want 2", reloaded.Len())
+	}
+}
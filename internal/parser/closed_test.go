@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestGetListingDetailsClosedListing(t *testing.T) {
+	const path = "/moskva/telefony/iphone_15_1111111111"
+	srv := newFixtureServer(t, map[string]string{path: "item_closed.html"})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	// The listing as stored from an earlier scrape
+	stored := models.Listing{
+		ID:       "1111111111",
+		URL:      srv.URL + path,
+		Price:    models.Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+		IsActive: true,
+	}
+
+	listing, err := p.GetListingDetails(stored)
+	if err != nil {
+		t.Fatalf("GetListingDetails: %v", err)
+	}
+
+	if listing.IsActive {
+		t.Error("closed listing reported as active")
+	}
+	if listing.Title != "iPhone 15" {
+		t.Errorf("Title = %q, want iPhone 15", listing.Title)
+	}
+	// Nothing is taken from the stale page
+	if listing.Price != stored.Price {
+		t.Errorf("Price = %+v, want the stored %+v", listing.Price, stored.Price)
+	}
+	if len(listing.ImageURLs) != 0 || listing.Description != "" || listing.Location != "" {
+		t.Errorf("details were extracted from a closed listing: %+v", listing)
+	}
+}
+
+func TestIsClosedListing(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want bool
+	}{
+		{"marker", `<div data-marker="item-closed"></div>`, true},
+		{"removed notice", `<h2>Объявление снято с публикации</h2>`, true},
+		{"sold notice", `<div class="item-view-warning">Товар продан</div>`, true},
+		{"expired notice", `<div data-marker="item-view/warning">Срок размещения этого объявления истёк</div>`, true},
+		{"description", `<div data-marker="item-description"><h3>Товар продан не будет до пятницы</h3></div>`, false},
+		{"active", `<h1>iPhone 15</h1><span data-marker="item-price">65 000 ₽</span>`, false},
+	}
+
+	for _, tt := range tests {
+		if got := isClosedListing(parseFragment(t, tt.page)); got != tt.want {
+			t.Errorf("%s: isClosedListing = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetListingDetailsActiveListing(t *testing.T) {
+	if listing := fetchFixtureListing(t, "item_iphone.html", ParserOptions{}); !listing.IsActive {
+		t.Error("active listing reported as closed")
+	}
+}
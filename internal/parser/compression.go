@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding lists the content encodings advertised to Avito
+const acceptEncoding = "gzip, br"
+
+// decodingTransport advertises gzip and brotli support on every request and decodes
+// compressed responses, so collectors and the cache only ever see decoded bodies.
+// Setting Accept-Encoding turns off net/http's transparent gzip handling, which
+// wouldn't cover brotli anyway.
+type decodingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *decodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := decodeBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// decodeBody replaces a gzip or brotli encoded response body with a decoding reader
+// and drops the headers describing the encoded body
+func decodeBody(resp *http.Response) error {
+	var decoded io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(resp.Body)
+		switch {
+		case errors.Is(err, io.EOF):
+			// Responses such as 304s have an empty body despite the header
+			decoded = http.NoBody
+		case err != nil:
+			return fmt.Errorf("error decoding gzip response from %s: %w", resp.Request.URL, err)
+		default:
+			decoded = reader
+		}
+	case "br":
+		decoded = brotli.NewReader(resp.Body)
+	default:
+		return nil
+	}
+
+	resp.Body = &decodedBody{Reader: decoded, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody reads a decoded response body and closes the underlying one
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+// Close implements io.Closer
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
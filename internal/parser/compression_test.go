@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compressingServer serves the phone fixtures gzipped, except for the Samsung
+// listing page which it sends brotli encoded. It records the Accept-Encoding of
+// every request.
+func compressingServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var accepted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		accepted = append(accepted, r.Header.Get("Accept-Encoding"))
+		mu.Unlock()
+
+		fixture, ok := phoneRoutes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		page, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var body bytes.Buffer
+		var encoder io.WriteCloser
+		if strings.Contains(r.URL.Path, "samsung") {
+			w.Header().Set("Content-Encoding", "br")
+			encoder = brotli.NewWriter(&body)
+		} else {
+			w.Header().Set("Content-Encoding", "gzip")
+			encoder = gzip.NewWriter(&body)
+		}
+		_, _ = encoder.Write(page)
+		_ = encoder.Close()
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(body.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), accepted...)
+	}
+}
+
+func TestGetListingsDecodesCompressedPages(t *testing.T) {
+	srv, accepted := compressingServer(t)
+	p := newTestParser(t, srv.URL, ParserOptions{})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got listings %v, want %v", got, want)
+	}
+	if listings[0].Description == "" || listings[1].Description == "" {
+		t.Errorf("details of compressed listing pages weren't parsed: %+v", listings)
+	}
+
+	encodings := accepted()
+	if len(encodings) != 3 {
+		t.Fatalf("server got %d requests, want 3", len(encodings))
+	}
+	for _, encoding := range encodings {
+		if encoding != acceptEncoding {
+			t.Errorf("request sent with Accept-Encoding %q, want %q", encoding, acceptEncoding)
+		}
+	}
+}
+
+func TestDecodingTransport(t *testing.T) {
+	srv, _ := compressingServer(t)
+	page, err := os.ReadFile(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &decodingTransport{base: http.DefaultTransport}}
+	resp, err := client.Get(srv.URL + "/moskva/telefony")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+
+	// The body and its length are those of the decoded page, which is what gets logged
+	if !bytes.Equal(body, page) {
+		t.Errorf("decoded body has %d bytes, want the %d of the fixture", len(body), len(page))
+	}
+	if resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != -1 || !resp.Uncompressed {
+		t.Errorf("response still describes the encoded body: %v, length %d", resp.Header, resp.ContentLength)
+	}
+}
+
+func TestDecodeBodyRejectsCorruptGzip(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1/moskva/telefony", nil)
+	resp := &http.Response{
+		Header:  http.Header{"Content-Encoding": {"gzip"}},
+		Body:    io.NopCloser(strings.NewReader("<html>not gzip</html>")),
+		Request: req,
+	}
+	if err := decodeBody(resp); err == nil {
+		t.Error("decodeBody accepted a body that isn't gzip")
+	}
+
+	// An empty body with the header, as on a 304, reads as empty
+	resp = &http.Response{
+		Header:  http.Header{"Content-Encoding": {"gzip"}},
+		Body:    io.NopCloser(strings.NewReader("")),
+		Request: req,
+	}
+	if err := decodeBody(resp); err != nil {
+		t.Fatalf("decodeBody of an empty body: %v", err)
+	}
+	if body, _ := io.ReadAll(resp.Body); len(body) != 0 {
+		t.Errorf("empty body decoded to %q", body)
+	}
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCookiesSentWithEveryRequest(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{Cookies: []*http.Cookie{
+		{Name: "buyer_location_id", Value: "637640"},
+		{Name: "tracking", Value: "1", Domain: "www.avito.ru"},
+	}})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 10); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	headers := srv.Headers()
+	if len(headers) != 3 {
+		t.Fatalf("server got %d requests, want the category and 2 listing pages", len(headers))
+	}
+	for i, header := range headers {
+		// Cookies for Avito's domain aren't sent to the test server
+		if got := header.Get("Cookie"); got != "buyer_location_id=637640" {
+			t.Errorf("request %d sent Cookie %q, want the region cookie only", i, got)
+		}
+	}
+}
+
+func TestNoCookiesByDefault(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 10); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	for i, header := range srv.Headers() {
+		if got := header.Get("Cookie"); got != "" {
+			t.Errorf("request %d sent Cookie %q without the Cookies option", i, got)
+		}
+	}
+}
+
+func TestCookiesKeepSession(t *testing.T) {
+	page := `<div data-marker="catalog-serp"><div data-marker="item" data-item-id="1111111111">
+		<a href="/moskva/telefony/iphone_15_1111111111"><h3 itemprop="name">iPhone 15</h3></a></div></div>`
+
+	var mu sync.Mutex
+	var cookies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		mu.Unlock()
+
+		// Avito starts a session on the first request
+		if _, err := r.Cookie("sessid"); err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "sessid", Value: "abc", Path: "/"})
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := newTestParser(t, srv.URL, ParserOptions{
+		Cookies:     []*http.Cookie{{Name: "buyer_location_id", Value: "637640"}},
+		SkipDetails: true,
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+			t.Fatalf("GetListings: %v", err)
+		}
+	}
+
+	if len(cookies) != 2 {
+		t.Fatalf("server got %d requests, want 2", len(cookies))
+	}
+	if !strings.Contains(cookies[1], "sessid=abc") || !strings.Contains(cookies[1], "buyer_location_id=637640") {
+		t.Errorf("second request sent Cookie %q, want the session and region cookies", cookies[1])
+	}
+}
+
+func TestNewParserRejectsInvalidCookies(t *testing.T) {
+	if _, err := NewParser(ParserOptions{Cookies: []*http.Cookie{{Name: "bad name", Value: "1"}}}); err == nil {
+		t.Error("NewParser accepted a cookie with an invalid name")
+	}
+}
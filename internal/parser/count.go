@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+var (
+	// Regex to match result counts like "Найдено 12 345 объявлений", "1 объявление" or "2,5 тыс. объявлений"
+	resultsCountRegex = regexp.MustCompile(`(?i)(\d[\d\s\x{00a0}\x{202f}]*(?:[.,]\d+)?)\s*(тыс|млн)?\.?\s*объявлени[еяй]`)
+	// Regex to extract the result count from the JSON state of a category page
+	totalCountRegex = regexp.MustCompile(`"(?:totalCount|mainCount)"\s*:\s*(\d+)`)
+	// Regex to detect the notice shown when a category or search has no results
+	nothingFoundRegex = regexp.MustCompile(`(?i)ничего\s+не\s+найдено`)
+)
+
+// CountListings returns the number of results in a category using the default parser
+func CountListings(categoryURL string) (int, error) {
+	return defaultParser.CountListings(categoryURL)
+}
+
+// CountListingsContext returns the number of results in a category using the default parser,
+// aborting when the context is cancelled or its deadline expires
+func CountListingsContext(ctx context.Context, categoryURL string) (int, error) {
+	return defaultParser.CountListingsContext(ctx, categoryURL)
+}
+
+// CountListings returns the number of results Avito reports for a category or search,
+// such as "Найдено 12 345 объявлений", fetching only the first results page. It lets
+// callers decide whether a full scrape is worthwhile. Counts Avito rounds, like
+// "2,5 тыс. объявлений", are returned as shown. The error wraps ErrParseFailed when
+// the page shows no count and ErrCategoryNotFound when Avito redirects the URL away.
+func (p *Parser) CountListings(categoryURL string) (int, error) {
+	return p.CountListingsContext(context.Background(), categoryURL)
+}
+
+// CountListingsContext works like CountListings, aborting when the context is cancelled
+// or its deadline expires
+func (p *Parser) CountListingsContext(ctx context.Context, categoryURL string) (int, error) {
+	if categoryURL == "" {
+		return 0, fmt.Errorf("category: %w", ErrEmptyURL)
+	}
+	pageURL := p.withSellerTypeParam(p.regionalURL(categoryURL))
+
+	ctx = p.withRequestBudget(ctx)
+	count, found, redirected := 0, false, false
+
+	c := p.newCollector(ctx)
+	c.Limit(p.limitRule())
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Println("Visiting", r.URL)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Println("Error:", err)
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		if redirected = redirectedAway(pageURL, r.Request.URL); redirected {
+			return
+		}
+		count, found = parseResultsCount(r.Body)
+	})
+
+	p.trackRequestStats(ctx, c)
+
+	if err := p.visit(ctx, c, pageURL); err != nil {
+		return 0, fmt.Errorf("error visiting category page: %w", err)
+	}
+
+	c.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if redirected {
+		return 0, fmt.Errorf("%w: %s redirects elsewhere", ErrCategoryNotFound, pageURL)
+	}
+	if !found {
+		return 0, fmt.Errorf("%w: no results count at %s", ErrParseFailed, pageURL)
+	}
+
+	return count, nil
+}
+
+// parseResultsCount extracts the number of results from a category page: from the count
+// next to the page title, a "Найдено N объявлений" line, or the page's JSON state
+func parseResultsCount(body []byte) (int, bool) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return 0, false
+	}
+
+	// The count next to the title is usually a bare number like "12 345"
+	if text := cleanText(doc.Find("*[data-marker='page-title/count']").First().Text()); text != "" {
+		if count, ok := parseResultsCountText(text + " объявлений"); ok {
+			return count, true
+		}
+	}
+
+	title := cleanText(doc.Find("*[data-marker='page-title'], h1").First().Text())
+	if count, ok := parseResultsCountText(title); ok {
+		return count, true
+	}
+
+	// "Найдено" lines outside the title, ignoring counts such as a seller's listings
+	count, found := 0, false
+	doc.Find("span, div, p").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if s.Children().Length() > 0 {
+			return true
+		}
+		text := cleanText(s.Text())
+		if strings.HasPrefix(strings.ToLower(text), "найдено") {
+			count, found = parseResultsCountText(text)
+		}
+		return !found
+	})
+	if found {
+		return count, true
+	}
+
+	if matches := totalCountRegex.FindSubmatch(body); matches != nil {
+		if count, err := strconv.Atoi(string(matches[1])); err == nil {
+			return count, true
+		}
+	}
+
+	if nothingFoundRegex.MatchString(title) || nothingFoundRegex.MatchString(doc.Find("*[data-marker='catalog-serp']").Text()) {
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// parseResultsCountText parses a count like "Найдено 12 345 объявлений" or "2,5 тыс. объявлений"
+func parseResultsCountText(text string) (int, bool) {
+	matches := resultsCountRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, false
+	}
+
+	switch strings.ToLower(matches[2]) {
+	case "тыс":
+		value, ok := parseNumber(matches[1])
+		return int(value*1_000 + 0.5), ok
+	case "млн":
+		value, ok := parseNumber(matches[1])
+		return int(value*1_000_000 + 0.5), ok
+	}
+
+	return parseCount(matches[1]), true
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCountListings(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony":  "category_count.html",
+		"/moskva/planshety": "category.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	count, err := p.CountListings(srv.URL + "/moskva/telefony")
+	if err != nil {
+		t.Fatalf("CountListings: %v", err)
+	}
+	if count != 12345 {
+		t.Errorf("CountListings = %d, want 12345", count)
+	}
+	if hits := srv.TotalHits(); hits != 1 {
+		t.Errorf("server got %d requests, want only the first results page", hits)
+	}
+
+	if _, err := p.CountListings(srv.URL + "/moskva/planshety"); !errors.Is(err, ErrParseFailed) {
+		t.Errorf("CountListings of a page without a count: error = %v, want ErrParseFailed", err)
+	}
+	if _, err := p.CountListings(""); !errors.Is(err, ErrEmptyURL) {
+		t.Errorf("CountListings(\"\") error = %v, want ErrEmptyURL", err)
+	}
+}
+
+func TestParseResultsCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      string
+		want      int
+		wantFound bool
+	}{
+		{"title count", `<h1 data-marker="page-title">Телефоны <span data-marker="page-title/count">1 234</span></h1>`, 1234, true},
+		{"title", `<h1>Найдено 12 345 объявлений</h1>`, 12345, true},
+		{"one", `<h1>Найдено 1 объявление</h1>`, 1, true},
+		{"few", `<h1>Найдено 3 объявления</h1>`, 3, true},
+		{"non-breaking spaces", `<h1>Найдено 1&nbsp;234&nbsp;567 объявлений</h1>`, 1234567, true},
+		{"thousands", `<h1>Телефоны</h1><span>Найдено 2,5 тыс. объявлений</span>`, 2500, true},
+		{"millions", `<h1>Найдено 1,2 млн объявлений</h1>`, 1200000, true},
+		{"found line", `<h1>Телефоны</h1><div><span>Найдено 48 объявлений</span></div>`, 48, true},
+		{"seller count ignored", `<h1>Телефоны</h1><span>Ещё 42 объявления продавца</span><script>{"totalCount": 980}</script>`, 980, true},
+		{"JSON state", `<h1>Телефоны</h1><script>window.state = {"mainCount":77}</script>`, 77, true},
+		{"nothing found", `<h1>Ничего не найдено</h1>`, 0, true},
+		{"no count", `<h1>Телефоны</h1><span>Ещё 42 объявления продавца</span>`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := parseResultsCount([]byte(tt.page))
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("parseResultsCount = %d, %v, want %d, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateMonths(t *testing.T) {
+	now := time.Date(2024, time.December, 31, 18, 0, 0, 0, moscowLocation)
+
+	months := []struct {
+		genitive, nominative, short string
+		month                       time.Month
+	}{
+		{"января", "январь", "янв", time.January},
+		{"февраля", "февраль", "фев", time.February},
+		{"марта", "март", "мар", time.March},
+		{"апреля", "апрель", "апр", time.April},
+		{"мая", "май", "мая", time.May},
+		{"июня", "июнь", "июн", time.June},
+		{"июля", "июль", "июл", time.July},
+		{"августа", "август", "авг", time.August},
+		{"сентября", "сентябрь", "сен", time.September},
+		{"октября", "октябрь", "окт", time.October},
+		{"ноября", "ноябрь", "ноя", time.November},
+		{"декабря", "декабрь", "дек.", time.December},
+	}
+
+	for _, m := range months {
+		want := time.Date(2024, m.month, 15, 0, 0, 0, 0, moscowLocation)
+		for _, name := range []string{m.genitive, m.nominative, m.short} {
+			dateStr := "15 " + name
+			got, err := parseDateAt(dateStr, now, false)
+			if err != nil {
+				t.Errorf("parseDateAt(%q): %v", dateStr, err)
+				continue
+			}
+			if !got.Equal(want) {
+				t.Errorf("parseDateAt(%q) = %v, want %v", dateStr, got, want)
+			}
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 18, 0, 0, 0, moscowLocation)
+
+	tests := []struct {
+		dateStr string
+		want    time.Time
+	}{
+		{"5 марта 10:30", time.Date(2024, time.March, 5, 10, 30, 0, 0, moscowLocation)},
+		{"5 марта в 10:30", time.Date(2024, time.March, 5, 10, 30, 0, 0, moscowLocation)},
+		{"5 мар 2023", time.Date(2023, time.March, 5, 0, 0, 0, 0, moscowLocation)},
+		// Dates later in the year than now are from last year
+		{"15 декабря", time.Date(2023, time.December, 15, 0, 0, 0, 0, moscowLocation)},
+		{"05.03.2024", time.Date(2024, time.March, 5, 0, 0, 0, 0, moscowLocation)},
+		{"05.03.24 09:05", time.Date(2024, time.March, 5, 9, 5, 0, 0, moscowLocation)},
+		{"Сегодня в 12:15", time.Date(2024, time.March, 10, 12, 15, 0, 0, moscowLocation)},
+		{"вчера 23:59", time.Date(2024, time.March, 9, 23, 59, 0, 0, moscowLocation)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDateAt(tt.dateStr, now, false)
+		if err != nil {
+			t.Errorf("parseDateAt(%q): %v", tt.dateStr, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDateAt(%q) = %v, want %v", tt.dateStr, got, tt.want)
+		}
+	}
+}
+
+func TestParseDateFailures(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 18, 0, 0, 0, moscowLocation)
+
+	for _, dateStr := range []string{"", "недавно", "5 смарта", "31 февраля", "32.01.2024", "5 марта 25:00"} {
+		got, err := parseDateAt(dateStr, now, false)
+		if err == nil {
+			t.Errorf("parseDateAt(%q) = %v, want an error", dateStr, got)
+		}
+		if !got.IsZero() {
+			t.Errorf("parseDateAt(%q) = %v, want the zero time", dateStr, got)
+		}
+	}
+}
+
+func TestParseRelativeDates(t *testing.T) {
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	tests := []struct {
+		dateStr string
+		ago     time.Duration
+	}{
+		{"минуту назад", time.Minute},
+		{"1 минуту назад", time.Minute},
+		{"3 минуты назад", 3 * time.Minute},
+		{"5 минут назад", 5 * time.Minute},
+		{"час назад", time.Hour},
+		{"2 часа назад", 2 * time.Hour},
+		{"11 часов назад", 11 * time.Hour},
+		{"1 день назад", 24 * time.Hour},
+		{"2 дня назад", 2 * 24 * time.Hour},
+		{"5 дней назад", 5 * 24 * time.Hour},
+		{"неделю назад", 7 * 24 * time.Hour},
+		{"2 недели назад", 14 * 24 * time.Hour},
+		{"3 недель назад", 21 * 24 * time.Hour},
+	}
+
+	const tolerance = 5 * time.Second
+	for _, tt := range tests {
+		got, err := p.parseDate(tt.dateStr)
+		if err != nil {
+			t.Errorf("parseDate(%q): %v", tt.dateStr, err)
+			continue
+		}
+		want := time.Now().Add(-tt.ago)
+		if diff := want.Sub(got); diff < -tolerance || diff > tolerance {
+			t.Errorf("parseDate(%q) = %v, want about %v", tt.dateStr, got, want)
+		}
+	}
+}
+
+// setLocalZone makes location the machine's local zone for the rest of the test
+func setLocalZone(t *testing.T, location *time.Location) {
+	t.Helper()
+
+	local := time.Local
+	time.Local = location
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestParseDateOutsideMoscow(t *testing.T) {
+	// Twelve hours behind Moscow, so the two are often on different days
+	setLocalZone(t, time.FixedZone("UTC-9", -9*60*60))
+
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	got, err := p.parseDate("5 марта 2024 в 10:30")
+	if err != nil {
+		t.Fatalf("parseDate: %v", err)
+	}
+	if want := time.Date(2024, time.March, 5, 7, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("parseDate = %v, want %v", got, want)
+	}
+	if got.Location() != moscowLocation {
+		t.Errorf("parseDate returned a time in %v, want Moscow time", got.Location())
+	}
+
+	// Relative days are Moscow days, whatever the local date is
+	moscowNow := time.Now().In(moscowLocation)
+	for dateStr, daysAgo := range map[string]int{"сегодня в 00:05": 0, "вчера в 23:55": 1} {
+		got, err := p.parseDate(dateStr)
+		if err != nil {
+			t.Fatalf("parseDate(%q): %v", dateStr, err)
+		}
+		want := moscowNow.AddDate(0, 0, -daysAgo)
+		if y, m, d := got.In(moscowLocation).Date(); y != want.Year() || m != want.Month() || d != want.Day() {
+			t.Errorf("parseDate(%q) = %v, want a time on %v in Moscow", dateStr, got, want.Format(time.DateOnly))
+		}
+	}
+
+	got, err = p.parseDate("2 часа назад")
+	if err != nil {
+		t.Fatalf("parseDate: %v", err)
+	}
+	if diff := time.Since(got) - 2*time.Hour; diff < -5*time.Second || diff > 5*time.Second {
+		t.Errorf("parseDate(2 часа назад) = %v, %v off", got, diff)
+	}
+}
+
+func TestParseDateInConfiguredLocation(t *testing.T) {
+	setLocalZone(t, time.UTC)
+	vladivostok := time.FixedZone("VLAT", 10*60*60)
+
+	p, err := NewParser(ParserOptions{Location: vladivostok})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	got, err := p.parseDate("05.03.2024 09:00")
+	if err != nil {
+		t.Fatalf("parseDate: %v", err)
+	}
+	if want := time.Date(2024, time.March, 4, 23, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("parseDate = %v, want %v", got, want)
+	}
+}
+
+func TestListingDatesOutsideMoscow(t *testing.T) {
+	setLocalZone(t, time.FixedZone("UTC-9", -9*60*60))
+
+	// The car listing page shows "3 апреля 2024 в 11:20"
+	listing := fetchFixtureListing(t, "item_car.html", ParserOptions{})
+	if want := time.Date(2024, time.April, 3, 8, 20, 0, 0, time.UTC); !listing.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %v, want %v", listing.PublishedAt, want)
+	}
+}
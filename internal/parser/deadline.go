@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"context"
+	"errors"
+)
+
+// scrapeDeadlineKey marks a context whose traversal is already bounded by MaxDuration
+type scrapeDeadlineKey struct{}
+
+// withMaxDuration bounds the traversal in ctx to MaxDuration, unless the bound is
+// disabled or an enclosing call, e.g. the catalog page visiting a subcategory, already
+// set one. Once it elapses ctx is done with ErrDeadlineReached as its cause.
+func (p *Parser) withMaxDuration(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.opts.MaxDuration <= 0 || ctx.Value(scrapeDeadlineKey{}) != nil {
+		return ctx, func() {}
+	}
+
+	ctx = context.WithValue(ctx, scrapeDeadlineKey{}, true)
+	return context.WithTimeoutCause(ctx, p.opts.MaxDuration, ErrDeadlineReached)
+}
+
+// deadlineReached reports whether the traversal in ctx stopped because MaxDuration elapsed
+func deadlineReached(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrDeadlineReached)
+}
+
+// deadlineError replaces err with ErrDeadlineReached when the traversal in ctx failed
+// because MaxDuration elapsed, whatever request happened to be cut short
+func deadlineError(ctx context.Context, err error) error {
+	if err != nil && deadlineReached(ctx) {
+		return ErrDeadlineReached
+	}
+	return err
+}
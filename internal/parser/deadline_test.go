@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// slowServer serves fixtures like fixtureServer, taking delay to answer the slow route
+func slowServer(t *testing.T, routes map[string]string, slow string, delay time.Duration) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RequestURI() == slow {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		fixture, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMaxDurationStopsSlowPagination(t *testing.T) {
+	routes := map[string]string{
+		"/moskva/telefony":     "category.html",
+		"/moskva/telefony?p=2": "category_page3.html",
+	}
+	srv := slowServer(t, routes, "/moskva/telefony?p=2", 5*time.Second)
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true, MaxPages: 3, MaxDuration: 200 * time.Millisecond})
+
+	start := time.Now()
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrDeadlineReached) {
+		t.Errorf("GetListings error = %v, want ErrDeadlineReached", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !slices.Equal(got, want) {
+		t.Errorf("listings = %v, want the first page's %v", got, want)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("GetListings took %v, want it to stop soon after MaxDuration", elapsed)
+	}
+}
+
+func TestMaxDurationStopsSlowDetails(t *testing.T) {
+	srv := slowServer(t, phoneRoutes, "/moskva/telefony/samsung_s24_2222222222", 5*time.Second)
+	p := newTestParser(t, srv.URL, ParserOptions{MaxDuration: 200 * time.Millisecond})
+
+	start := time.Now()
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrDeadlineReached) {
+		t.Errorf("GetListings error = %v, want ErrDeadlineReached", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("GetListings took %v, want it to stop soon after MaxDuration", elapsed)
+	}
+
+	// The iPhone's details came in before the deadline
+	if len(listings) == 0 || listings[0].ID != "1111111111" || listings[0].Description == "" {
+		t.Errorf("listings = %+v, want the iPhone with its details first", listings)
+	}
+}
+
+func TestMaxDurationNotReached(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{MaxDuration: time.Minute})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestCatalogPageDeduplicatesListings(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/catalog/telefony": "catalog.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/catalog/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !slices.Equal(got, want) {
+		t.Errorf("listings = %v, want %v", got, want)
+	}
+}
+
+func TestDedupeListings(t *testing.T) {
+	listings := []models.Listing{
+		{ID: "1", URL: "https://www.avito.ru/moskva/telefony/iphone_1", Title: "first"},
+		{ID: "2", URL: "https://www.avito.ru/moskva/telefony/samsung_2"},
+		{ID: "1", URL: "https://www.avito.ru/item/iphone_1", Title: "second"},
+		{URL: "https://www.avito.ru/moskva/telefony/nokia?context=abc"},
+		{URL: "https://WWW.avito.ru/moskva/telefony/nokia#photos"},
+	}
+
+	unique := dedupeListings(listings)
+	if len(unique) != 3 {
+		t.Fatalf("got %d listings, want 3: %+v", len(unique), unique)
+	}
+	// The first occurrence is kept
+	if unique[0].Title != "first" {
+		t.Errorf("kept %q, want the first occurrence", unique[0].Title)
+	}
+	if unique[2].URL != listings[3].URL {
+		t.Errorf("third listing = %s, want %s", unique[2].URL, listings[3].URL)
+	}
+}
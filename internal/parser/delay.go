@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// DelayStrategy decides how long the Parser pauses between requests to a domain
+// and before retrying a rate limited request
+type DelayStrategy interface {
+	// Delay returns the pause after a request when attempt is 0, and the backoff
+	// before the attempt-th retry of a request that got a 429 otherwise
+	Delay(ctx context.Context, attempt int) time.Duration
+}
+
+// DelayFunc adapts a function to the DelayStrategy interface
+type DelayFunc func(ctx context.Context, attempt int) time.Duration
+
+// Delay calls f(ctx, attempt)
+func (f DelayFunc) Delay(ctx context.Context, attempt int) time.Duration {
+	return f(ctx, attempt)
+}
+
+// ConstantDelay returns a DelayStrategy that always waits d, including before retries
+func ConstantDelay(d time.Duration) DelayStrategy {
+	return DelayFunc(func(context.Context, int) time.Duration { return d })
+}
+
+// ExponentialDelay returns a DelayStrategy that waits base between requests and
+// doubles the wait with every retry up to maxDelay, with jitter
+func ExponentialDelay(base, maxDelay time.Duration) DelayStrategy {
+	return DelayFunc(func(_ context.Context, attempt int) time.Duration {
+		if attempt == 0 {
+			return base
+		}
+		return backoffDelay(attempt, base, maxDelay)
+	})
+}
+
+// defaultDelayStrategy spreads requests between minDelay and maxDelay apart and backs
+// off exponentially from retryBase up to retryMax before retries. The Limiter already
+// spaces requests minDelay apart, so the pause after a request is only the random
+// part on top of it.
+type defaultDelayStrategy struct {
+	minDelay, maxDelay  time.Duration
+	retryBase, retryMax time.Duration
+}
+
+// newDefaultDelayStrategy returns the strategy built from the delay options
+func newDefaultDelayStrategy(opts ParserOptions) DelayStrategy {
+	return &defaultDelayStrategy{
+		minDelay:  opts.MinDelay,
+		maxDelay:  opts.MaxDelay,
+		retryBase: opts.RetryBaseDelay,
+		retryMax:  opts.RetryMaxDelay,
+	}
+}
+
+// Delay implements DelayStrategy
+func (s *defaultDelayStrategy) Delay(_ context.Context, attempt int) time.Duration {
+	if attempt > 0 {
+		return backoffDelay(attempt, s.retryBase, s.retryMax)
+	}
+	if s.maxDelay <= s.minDelay {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.maxDelay-s.minDelay) + 1))
+}
+
+// delayRequests pauses after every request of c for the delay the DelayStrategy gives,
+// holding up the collector like a colly LimitRule delay. Responses served from the
+// cache aren't followed by a pause.
+func (p *Parser) delayRequests(ctx context.Context, c *colly.Collector) {
+	pause := func(r *colly.Response) {
+		if r.Headers != nil && r.Headers.Get(cacheHeader) != "" {
+			return
+		}
+		if delay := p.delays.Delay(ctx, 0); delay > 0 {
+			log.Printf("Waiting %v after request to %s", delay, r.Request.URL.Host)
+			_ = sleepContext(ctx, delay)
+		}
+	}
+
+	c.OnScraped(pause)
+	c.OnError(func(r *colly.Response, _ error) {
+		pause(r)
+	})
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedDelays is a DelayStrategy handing out pauses from a list in order and
+// recording the attempts it was asked about
+type scriptedDelays struct {
+	mu       sync.Mutex
+	pauses   []time.Duration
+	attempts []int
+}
+
+func (s *scriptedDelays) Delay(_ context.Context, attempt int) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts = append(s.attempts, attempt)
+	if len(s.pauses) == 0 {
+		return 0
+	}
+	pause := s.pauses[0]
+	s.pauses = s.pauses[1:]
+	return pause
+}
+
+// timingServer serves fixtures by request URI and records when each request arrived
+func timingServer(t *testing.T, routes map[string]string) (*httptest.Server, func() []time.Time) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var arrivals []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+
+		fixture, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), arrivals...)
+	}
+}
+
+func TestCustomDelayStrategyAppliedInOrder(t *testing.T) {
+	srv, arrivals := timingServer(t, map[string]string{
+		"/moskva/telefony":     "category.html",
+		"/moskva/telefony?p=2": "category_page3.html",
+		"/moskva/telefony?p=3": "category_sellers.html",
+	})
+	delays := &scriptedDelays{pauses: []time.Duration{150 * time.Millisecond, 50 * time.Millisecond}}
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true, MaxPages: 3, DelayStrategy: delays})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	times := arrivals()
+	if len(times) != 3 {
+		t.Fatalf("server got %d requests, want 3 pages", len(times))
+	}
+	// Each page waits out the pause that followed the previous one
+	if gap := times[1].Sub(times[0]); gap < 150*time.Millisecond {
+		t.Errorf("second page came %v after the first, want at least the first pause of 150ms", gap)
+	}
+	if gap := times[2].Sub(times[1]); gap < 50*time.Millisecond || gap >= 150*time.Millisecond {
+		t.Errorf("third page came %v after the second, want the second pause of 50ms", gap)
+	}
+	if want := []int{0, 0, 0}; !reflect.DeepEqual(delays.attempts, want) {
+		t.Errorf("strategy was asked for attempts %v, want %v", delays.attempts, want)
+	}
+}
+
+func TestCustomDelayStrategyBacksOffRetries(t *testing.T) {
+	srv, hits := rateLimitingServer(t, "")
+	delays := &scriptedDelays{pauses: []time.Duration{0, 100 * time.Millisecond}}
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true, MaxRetries: 1, DelayStrategy: delays})
+
+	start := time.Now()
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if hits.Load() != 2 {
+		t.Errorf("server got %d requests, want the 429 and the retry", hits.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("retry came after %v, want the strategy's 100ms backoff", elapsed)
+	}
+	// The pause after the 429, the backoff before the retry, the pause after the retry
+	if want := []int{0, 1, 0}; !reflect.DeepEqual(delays.attempts, want) {
+		t.Errorf("strategy was asked for attempts %v, want %v", delays.attempts, want)
+	}
+}
+
+func TestBuiltInDelayStrategies(t *testing.T) {
+	ctx := context.Background()
+
+	constant := ConstantDelay(time.Second)
+	for _, attempt := range []int{0, 1, 5} {
+		if d := constant.Delay(ctx, attempt); d != time.Second {
+			t.Errorf("ConstantDelay.Delay(%d) = %v, want 1s", attempt, d)
+		}
+	}
+
+	exponential := ExponentialDelay(time.Second, 10*time.Second)
+	if d := exponential.Delay(ctx, 0); d != time.Second {
+		t.Errorf("ExponentialDelay.Delay(0) = %v, want the base delay", d)
+	}
+	if d := exponential.Delay(ctx, 3); d < 2*time.Second || d > 4*time.Second {
+		t.Errorf("ExponentialDelay.Delay(3) = %v, want between 2s and 4s", d)
+	}
+	if d := exponential.Delay(ctx, 20); d > 10*time.Second {
+		t.Errorf("ExponentialDelay.Delay(20) = %v, want at most 10s", d)
+	}
+}
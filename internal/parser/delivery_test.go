@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"testing"
+)
+
+// deliveryRoutes serve a category page where only the Samsung card has the Avito
+// Delivery badge, along with the two listing pages
+var deliveryRoutes = map[string]string{
+	"/moskva/telefony":                        "category_delivery.html",
+	"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+	"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+}
+
+func TestDeliveryBadgeOnCards(t *testing.T) {
+	srv := newFixtureServer(t, deliveryRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	want := map[string]bool{"1111111111": false, "2222222222": true}
+	if len(listings) != len(want) {
+		t.Fatalf("got %d listings, want %d", len(listings), len(want))
+	}
+	for _, listing := range listings {
+		if listing.DeliveryAvailable != want[listing.ID] {
+			t.Errorf("listing %s: DeliveryAvailable = %v, want %v", listing.ID, listing.DeliveryAvailable, want[listing.ID])
+		}
+	}
+}
+
+func TestDeliveryButtonOnListingPages(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    bool
+	}{
+		{"item_samsung.html", true},
+		{"item_iphone.html", false},
+	}
+
+	for _, tt := range tests {
+		if got := fetchFixtureListing(t, tt.fixture, ParserOptions{}).DeliveryAvailable; got != tt.want {
+			t.Errorf("%s: DeliveryAvailable = %v, want %v", tt.fixture, got, tt.want)
+		}
+	}
+}
+
+func TestHasDeliveryBadge(t *testing.T) {
+	tests := []struct {
+		card string
+		want bool
+	}{
+		{`<div><span>Авито Доставка</span></div>`, true},
+		{`<div><span>С доставкой</span></div>`, true},
+		{`<div><i data-marker="delivery/icon"></i></div>`, true},
+		{`<div><div class="styles-deliveryBadge-x1y2"></div></div>`, true},
+		// Descriptions mentioning delivery aren't the badge
+		{`<div><p>Доставка по городу за ваш счёт</p></div>`, false},
+		{`<div><span>54 000 ₽</span></div>`, false},
+	}
+
+	for _, tt := range tests {
+		if got := hasDeliveryBadge(parseFragment(t, tt.card)); got != tt.want {
+			t.Errorf("hasDeliveryBadge(%s) = %v, want %v", tt.card, got, tt.want)
+		}
+	}
+}
+
+func TestHasDeliveryButton(t *testing.T) {
+	tests := []struct {
+		page string
+		want bool
+	}{
+		{`<button>Купить с доставкой</button>`, true},
+		{`<a role="button" href="/order">Заказать с доставкой</a>`, true},
+		{`<div data-marker="item-view/delivery"></div>`, true},
+		{`<div data-marker="item-description">Доставка Авито не работает, только самовывоз</div>`, false},
+		{`<button>Написать продавцу</button>`, false},
+	}
+
+	for _, tt := range tests {
+		if got := hasDeliveryButton(parseFragment(t, tt.page)); got != tt.want {
+			t.Errorf("hasDeliveryButton(%s) = %v, want %v", tt.page, got, tt.want)
+		}
+	}
+}
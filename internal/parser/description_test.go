@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestListingMultiParagraphDescription(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_description.html", ParserOptions{})
+
+	want := "Продаю велосипед, катался один сезон.\n\n" +
+		"Что входит:\n\n" +
+		"насос;\nзамок;\nфонарь.\n\n" +
+		"Самовывоз с 10 до 20.\nТорг уместен.\n\nЗвоните!"
+	if listing.Description != want {
+		t.Errorf("Description = %q, want %q", listing.Description, want)
+	}
+
+	// The raw HTML keeps the markup, trimmed of the whitespace around it
+	html := listing.DescriptionHTML
+	if !strings.HasPrefix(html, "<p>") || !strings.HasSuffix(html, "</script>") {
+		t.Errorf("DescriptionHTML isn't the trimmed inner HTML: %q", html)
+	}
+	for _, markup := range []string{"<ul>", "<li>насос;</li>", "<br/>Торг уместен."} {
+		if !strings.Contains(html, markup) {
+			t.Errorf("DescriptionHTML = %q, want it to contain %q", html, markup)
+		}
+	}
+}
+
+func TestHTMLText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"paragraphs", "<p>Один</p><p>Два</p>", "Один\n\nДва"},
+		{"line breaks", "Один<br>Два<br/><br/>Три", "Один\nДва\n\nТри"},
+		{"leading and trailing breaks", "<br><p>Один</p><br>", "Один"},
+		{"list", "<ul><li>Один</li><li>Два</li></ul>", "Один\nДва"},
+		{"inline markup", "<p><b>Один</b> и <i>два</i></p>", "Один и два"},
+		{"source line breaks", "<p>Один\n  два</p>", "Один два"},
+		{"script and style", "<style>p{}</style><p>Один</p><script>x()</script>", "Один"},
+		{"empty paragraphs", "<p>Один</p><p> </p><p></p><p>Два</p>", "Один\n\nДва"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseFragment(t, "<div id=\"d\">"+tt.html+"</div>")
+			if got := htmlText(doc.Find("#d")); got != tt.want {
+				t.Errorf("htmlText(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"no limit", "Продаю велосипед", 0, "Продаю велосипед"},
+		{"shorter", "Продаю", 10, "Продаю"},
+		{"exactly at the limit", "Продаю", 6, "Продаю"},
+		{"cyrillic cut between runes", "Продаю велосипед", 4, "Прод…"},
+		{"trailing space trimmed", "Продаю велосипед", 7, "Продаю…"},
+		{"mixed scripts", "iPhone 15 Про", 12, "iPhone 15 Пр…"},
+		{"empty", "", 5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateText(tt.text, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateText(%q, %d) = %q, which isn't valid UTF-8", tt.text, tt.limit, got)
+			}
+		})
+	}
+}
+
+func TestMaxDescriptionLength(t *testing.T) {
+	full := fetchFixtureListing(t, "item_description.html", ParserOptions{})
+	listing := fetchFixtureListing(t, "item_description.html", ParserOptions{MaxDescriptionLength: 16})
+
+	if want := "Продаю велосипед…"; listing.Description != want {
+		t.Errorf("Description = %q, want %q", listing.Description, want)
+	}
+	if listing.DescriptionHTML != full.DescriptionHTML {
+		t.Errorf("DescriptionHTML = %q, want it kept whole as %q", listing.DescriptionHTML, full.DescriptionHTML)
+	}
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// fetchFixtureListing fetches the listing page served from fixture with a Parser
+// created with opts
+func fetchFixtureListing(t *testing.T, fixture string, opts ParserOptions) models.Listing {
+	t.Helper()
+
+	const path = "/moskva/listing_7777777777"
+	srv := newFixtureServer(t, map[string]string{path: fixture})
+	p := newFixtureParser(t, srv, opts)
+
+	listing, err := p.GetListingDetails(models.Listing{ID: "7777777777", URL: srv.URL + path})
+	if err != nil {
+		t.Fatalf("GetListingDetails(%s): %v", fixture, err)
+	}
+	return listing
+}
+
+// parseFragment parses an HTML fragment into a document
+func parseFragment(t *testing.T, page string) *goquery.Selection {
+	t.Helper()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("parsing page: %v", err)
+	}
+	return doc.Selection
+}
+
+func TestListingCoordinatesFromJSON(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_flat.html", ParserOptions{})
+
+	if !listing.HasCoordinates || listing.Latitude != 55.759412 || listing.Longitude != 37.645893 {
+		t.Errorf("coordinates = %v, %v (%v), want 55.759412, 37.645893",
+			listing.Latitude, listing.Longitude, listing.HasCoordinates)
+	}
+
+	// The iPhone's page has no map
+	listing = fetchFixtureListing(t, "item_iphone.html", ParserOptions{})
+	if listing.HasCoordinates || listing.Latitude != 0 || listing.Longitude != 0 {
+		t.Errorf("coordinates of a page without a map = %v, %v (%v)",
+			listing.Latitude, listing.Longitude, listing.HasCoordinates)
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		lat, lon float64
+		ok       bool
+	}{
+		{
+			name: "map widget",
+			page: `<div data-map-lat="59.9386" data-map-lon="30.3141"></div>`,
+			lat:  59.9386, lon: 30.3141, ok: true,
+		},
+		{
+			name: "structured data",
+			page: `<script type="application/ld+json">{"geo":{"@type":"GeoCoordinates","latitude":"56.8389","longitude":"60.6057"}}</script>`,
+			lat:  56.8389, lon: 60.6057, ok: true,
+		},
+		{
+			name: "HTML-escaped state",
+			page: `<script>window.__state__ = "{&quot;coords&quot;:{&quot;lat&quot;:55.0084,&quot;lng&quot;:82.9357}}"</script>`,
+			lat:  55.0084, lon: 82.9357, ok: true,
+		},
+		{
+			name: "zero coordinates",
+			page: `<div data-map-lat="0" data-map-lon="0"></div>`,
+		},
+		{
+			name: "out of range",
+			page: `<script>{"coords":{"lat":155.1,"lng":37.6}}</script>`,
+		},
+		{
+			name: "no map",
+			page: `<h1>Диван</h1>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon, ok := parseCoordinates(parseFragment(t, tt.page))
+			if lat != tt.lat || lon != tt.lon || ok != tt.ok {
+				t.Errorf("parseCoordinates = %v, %v, %v, want %v, %v, %v", lat, lon, ok, tt.lat, tt.lon, tt.ok)
+			}
+		})
+	}
+}
+
+func TestListingCounters(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_flat.html", ParserOptions{})
+	if listing.Views != 12345 || listing.Favorites != 1204 {
+		t.Errorf("Views = %d, Favorites = %d, want 12345 and 1204", listing.Views, listing.Favorites)
+	}
+
+	// Pages without the counters leave them at zero
+	listing = fetchFixtureListing(t, "item_iphone.html", ParserOptions{})
+	if listing.Views != 0 || listing.Favorites != 0 {
+		t.Errorf("Views = %d, Favorites = %d without counters, want zeros", listing.Views, listing.Favorites)
+	}
+}
+
+func TestParseCounters(t *testing.T) {
+	tests := []struct {
+		page                     string
+		wantViews, wantFavorites int
+	}{
+		{`<div data-marker="item-view/total-views">1 просмотр</div>`, 1, 0},
+		{`<div data-marker="item-view/total-views">3 просмотра</div>`, 3, 0},
+		{`<div data-marker="item-views">1 234 567 просмотров</div>`, 1234567, 0},
+		// Older pages put both counters in one metadata line
+		{`<div class="title-info-metadata">5 000 просмотров, 12 человек в избранном</div>`, 5000, 12},
+		{`<div class="title-info-views">48 просмотров</div><div class="title-info-metadata">Добавили в избранное 7</div>`, 48, 7},
+		{`<div data-marker="item-view/favorites">В избранном у 2 пользователей</div>`, 0, 2},
+		{`<div data-marker="item-view/total-views">Просмотров пока нет</div>`, 0, 0},
+	}
+
+	for _, tt := range tests {
+		views, favorites := parseCounters(parseFragment(t, tt.page))
+		if views != tt.wantViews || favorites != tt.wantFavorites {
+			t.Errorf("parseCounters(%s) = %d, %d, want %d, %d", tt.page, views, favorites, tt.wantViews, tt.wantFavorites)
+		}
+	}
+}
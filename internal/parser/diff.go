@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// Diff is the difference between two scrapes of the same category or search
+type Diff struct {
+	// Added holds listings present only in the new scrape, in its order
+	Added []models.Listing `json:"added"`
+	// Removed holds listings present only in the old scrape, in its order
+	Removed []models.Listing `json:"removed"`
+	// Changed holds listings present in both scrapes whose fields differ
+	Changed []ListingChange `json:"changed"`
+}
+
+// ListingChange describes how a listing changed between two scrapes
+type ListingChange struct {
+	Old models.Listing `json:"old"`
+	New models.Listing `json:"new"`
+	// Fields lists the JSON names of the changed fields, e.g. "price" or "title"
+	Fields []string `json:"fields"`
+}
+
+// Has reports whether field, given by its JSON name, changed
+func (c ListingChange) Has(field string) bool {
+	for _, changed := range c.Fields {
+		if changed == field {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceChanged reports whether the listing's price changed
+func (c ListingChange) PriceChanged() bool {
+	return c.Has("price")
+}
+
+// IsEmpty reports whether the scrapes had no differences
+func (d Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffListings compares two scrape results, matching listings by ID
+// (or by URL for listings without one)
+func DiffListings(old, new []models.Listing) Diff {
+	var diff Diff
+
+	oldByKey := make(map[string]models.Listing, len(old))
+	for _, listing := range old {
+		oldByKey[listingKey(listing)] = listing
+	}
+
+	newKeys := make(map[string]bool, len(new))
+	for _, listing := range new {
+		key := listingKey(listing)
+		newKeys[key] = true
+
+		previous, ok := oldByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, listing)
+			continue
+		}
+
+		if fields := changedFields(previous, listing); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, ListingChange{Old: previous, New: listing, Fields: fields})
+		}
+	}
+
+	for _, listing := range old {
+		if !newKeys[listingKey(listing)] {
+			diff.Removed = append(diff.Removed, listing)
+		}
+	}
+
+	return diff
+}
+
+// changedFields returns the JSON names of the fields that differ between two listings
+func changedFields(old, new models.Listing) []string {
+	var fields []string
+
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(new)
+	t := oldValue.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if !fieldEqual(oldValue.Field(i), newValue.Field(i)) {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// fieldEqual compares two values of a listing field. Times are equal when they are the
+// same instant, and nil slices and maps equal empty ones, so that listings loaded from
+// JSON compare equal to freshly scraped ones.
+func fieldEqual(a, b reflect.Value) bool {
+	if at, ok := a.Interface().(time.Time); ok {
+		return at.Equal(b.Interface().(time.Time))
+	}
+
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestDiffListings(t *testing.T) {
+	published := time.Date(2024, time.April, 3, 11, 20, 0, 0, time.UTC)
+	old := []models.Listing{
+		{ID: "1111111111", Title: "iPhone 15", Price: models.Price{Value: 65000, Currency: "RUB"}, PublishedAt: published},
+		{ID: "2222222222", Title: "Samsung Galaxy S24", Price: models.Price{Value: 54000, Currency: "RUB"}},
+		{ID: "3333333333", Title: "Pixel 8", Price: models.Price{Value: 40000, Currency: "RUB"}},
+		{URL: "https://www.avito.ru/moskva/telefony/nokia", Title: "Nokia 3310"},
+	}
+	new := []models.Listing{
+		// Same instant in another zone, and an empty rather than nil slice
+		{ID: "1111111111", Title: "iPhone 15", Price: models.Price{Value: 65000, Currency: "RUB"}, PublishedAt: published.In(time.FixedZone("MSK", 3*60*60)), ImageURLs: []string{}},
+		{ID: "2222222222", Title: "Samsung Galaxy S24 Ultra", Price: models.Price{Value: 49000, Currency: "RUB"}},
+		{ID: "4444444444", Title: "Xiaomi 14", Price: models.Price{Value: 45000, Currency: "RUB"}},
+		{URL: "https://www.avito.ru/moskva/telefony/nokia", Title: "Nokia 3310"},
+	}
+
+	diff := DiffListings(old, new)
+
+	if got := listingIDs(diff.Added); !reflect.DeepEqual(got, []string{"4444444444"}) {
+		t.Errorf("Added = %v, want [4444444444]", got)
+	}
+	if got := listingIDs(diff.Removed); !reflect.DeepEqual(got, []string{"3333333333"}) {
+		t.Errorf("Removed = %v, want [3333333333]", got)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("Changed = %+v, want only 2222222222", diff.Changed)
+	}
+
+	change := diff.Changed[0]
+	if change.Old.ID != "2222222222" || change.New.Price.Value != 49000 {
+		t.Errorf("change = %+v, want 2222222222 from 54000 to 49000", change)
+	}
+	if !reflect.DeepEqual(change.Fields, []string{"title", "price"}) {
+		t.Errorf("changed fields = %v, want [title price]", change.Fields)
+	}
+	if !change.PriceChanged() || change.Has("description") {
+		t.Errorf("PriceChanged = %v, Has(description) = %v", change.PriceChanged(), change.Has("description"))
+	}
+	if diff.IsEmpty() {
+		t.Error("IsEmpty = true for scrapes with differences")
+	}
+}
+
+func TestDiffListingsRoundTrip(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_flat.html", ParserOptions{})
+
+	// A listing saved as JSON and loaded back is the same listing
+	data, err := json.Marshal([]models.Listing{listing})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var loaded []models.Listing
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	if diff := DiffListings(loaded, []models.Listing{listing}); !diff.IsEmpty() {
+		t.Errorf("DiffListings of a listing and its JSON copy = %+v, want no differences", diff)
+	}
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseDiscountEnd(t *testing.T) {
+	now := time.Date(2024, time.October, 20, 12, 0, 0, 0, moscowLocation)
+
+	tests := []struct {
+		name  string
+		timer string
+		want  time.Time
+	}{
+		{
+			name:  "countdown with days",
+			timer: `<div data-marker="discount-timer">2 дня 03:15:00</div>`,
+			want:  now.Add(51*time.Hour + 15*time.Minute),
+		},
+		{
+			name:  "countdown",
+			timer: `<div data-marker="discount-timer">Осталось 14:05</div>`,
+			want:  now.Add(14*time.Hour + 5*time.Minute),
+		},
+		{
+			name:  "absolute date with time",
+			timer: `<div data-marker="discount-timer">до 25 октября 23:59</div>`,
+			want:  time.Date(2024, time.October, 25, 23, 59, 0, 0, moscowLocation),
+		},
+		{
+			name:  "absolute date in the next year",
+			timer: `<div data-marker="discount-timer">до 5 января</div>`,
+			want:  time.Date(2025, time.January, 5, 0, 0, 0, 0, moscowLocation),
+		},
+		{
+			name:  "past absolute date",
+			timer: `<div data-marker="discount-timer">до 1 октября 2024 23:59</div>`,
+		},
+		{
+			name:  "machine-readable end",
+			timer: `<div data-marker="discount-timer" datetime="2024-10-21T10:00:00+03:00">завтра</div>`,
+			want:  time.Date(2024, time.October, 21, 10, 0, 0, 0, time.FixedZone("", 3*60*60)),
+		},
+		{
+			name:  "no end",
+			timer: `<div data-marker="discount-timer">Скидка</div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.timer))
+			if err != nil {
+				t.Fatalf("parsing timer: %v", err)
+			}
+
+			got := parseDiscountEnd(doc.Find("div").First(), now)
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDiscountEnd(%s) = %v, want %v", tt.timer, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package parser
+
+import "errors"
+
+// Errors returned by the parser. They are wrapped with details such as the URL,
+// so compare against them with errors.Is.
+var (
+	// ErrBlocked is returned when Avito serves its anti-bot challenge page instead of content
+	ErrBlocked = errors.New("blocked by Avito anti-bot protection")
+
+	// ErrRateLimited is returned when Avito keeps answering 429 after all retries
+	ErrRateLimited = errors.New("rate limited by Avito")
+
+	// ErrDisallowedByRobots is returned for URLs that robots.txt disallows when
+	// ParserOptions.RespectRobotsTxt is set
+	ErrDisallowedByRobots = errors.New("URL disallowed by robots.txt")
+
+	// ErrEmptyURL is returned when a category or listing URL is empty
+	ErrEmptyURL = errors.New("URL is empty")
+
+	// ErrNotItemURL is returned by GetListingByURL for URLs that aren't Avito listing pages
+	ErrNotItemURL = errors.New("not an Avito listing URL")
+
+	// ErrNoListingsFound is returned when a category or search page has no listings
+	ErrNoListingsFound = errors.New("no listings found")
+
+	// ErrCategoryNotFound is returned when Avito redirects a category or search URL it
+	// can't resolve, such as a stale one, to its home page or another shallower page
+	ErrCategoryNotFound = errors.New("category not found")
+
+	// ErrOffsetOutOfRange is returned when ParserOptions.StartPage is past the last
+	// results page of a category
+	ErrOffsetOutOfRange = errors.New("start page beyond the last results page")
+
+	// ErrParseFailed is returned when a page can't be parsed
+	ErrParseFailed = errors.New("parse failed")
+
+	// ErrResponseTooLarge is returned when a response body exceeds ParserOptions.MaxBodyBytes
+	ErrResponseTooLarge = errors.New("response body too large")
+
+	// ErrLayoutUnrecognized is returned by GetListingDetails when none of the known
+	// content blocks were found on the page, meaning the detail selectors need updating.
+	// It also matches ErrParseFailed.
+	ErrLayoutUnrecognized = errors.New("listing page layout not recognized")
+
+	// ErrRequestBudgetExhausted is returned when a traversal has made the
+	// ParserOptions.MaxRequests requests it is allowed
+	ErrRequestBudgetExhausted = errors.New("request budget exhausted")
+
+	// ErrDeadlineReached is returned alongside the listings collected so far when a
+	// traversal runs for ParserOptions.MaxDuration. It marks the results as partial
+	// rather than the scrape as failed.
+	ErrDeadlineReached = errors.New("scrape deadline reached")
+
+	// ErrCategoriesNotFound is returned by GetCategoriesLive when the page has no
+	// recognizable category tree. Callers can fall back to GetCategories.
+	ErrCategoriesNotFound = errors.New("category tree not found on page")
+)
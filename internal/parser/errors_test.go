@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestErrorsMatchSentinels(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony":         "category.html",
+		"/moskva/pusto":            "empty.html",
+		"/moskva/zablokirovano":    "blocked.html",
+		"/moskva/telefony/pustaya": "empty.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(limited.Close)
+	limitedParser := newTestParser(t, limited.URL, ParserOptions{})
+
+	tests := []struct {
+		name string
+		call func() error
+		want []error
+	}{
+		{
+			name: "empty category URL",
+			call: func() error { _, err := p.GetListings("", 10); return err },
+			want: []error{ErrEmptyURL},
+		},
+		{
+			name: "empty listing URL",
+			call: func() error { _, err := p.GetListingByURL(" "); return err },
+			want: []error{ErrEmptyURL},
+		},
+		{
+			name: "not a listing URL",
+			call: func() error { _, err := p.GetListingByURL(srv.URL + "/moskva/telefony"); return err },
+			want: []error{ErrNotItemURL},
+		},
+		{
+			name: "category without listings",
+			call: func() error { _, err := p.GetListings(srv.URL+"/moskva/pusto", 10); return err },
+			want: []error{ErrNoListingsFound},
+		},
+		{
+			name: "block page",
+			call: func() error { _, err := p.GetListings(srv.URL+"/moskva/zablokirovano", 10); return err },
+			want: []error{ErrBlocked},
+		},
+		{
+			name: "unrecognized listing page",
+			call: func() error {
+				_, err := p.GetListingDetails(models.Listing{URL: srv.URL + "/moskva/telefony/pustaya"})
+				return err
+			},
+			want: []error{ErrParseFailed, ErrLayoutUnrecognized},
+		},
+		{
+			name: "rate limited",
+			call: func() error { _, err := limitedParser.GetListings(limited.URL+"/moskva/telefony", 10); return err },
+			want: []error{ErrRateLimited},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil {
+				t.Fatal("no error")
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("error %q doesn't match %q", err, want)
+				}
+			}
+			if err.Error() == tt.want[0].Error() {
+				t.Errorf("error %q carries no details", err)
+			}
+		})
+	}
+}
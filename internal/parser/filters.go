@@ -0,0 +1,296 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// sellerTypeParamCategories lists URL path fragments of categories that accept the "user" parameter
+var sellerTypeParamCategories = []string{
+	"/transport",
+	"/avtomobili",
+	"/mototsikly_i_mototehnika",
+	"/gruzoviki_i_spetstehnika",
+	"/vodnyy_transport",
+	"/nedvizhimost",
+	"/kvartiry",
+	"/kommercheskaya_nedvizhimost",
+	"/doma_dachi_kottedzhi",
+	"/zemelnye_uchastki",
+	"/garazhi_i_mashinomesta",
+}
+
+// videoParamCategories lists URL path fragments of categories whose filters offer
+// "только с видео" through the "video" parameter: cars, motorcycles and residential
+// and commercial real estate
+var videoParamCategories = []string{
+	"/avtomobili",
+	"/mototsikly_i_mototehnika",
+	"/kvartiry",
+	"/komnaty",
+	"/doma_dachi_kottedzhi",
+	"/kommercheskaya_nedvizhimost",
+}
+
+// SortOrder is the order Avito returns results in, set through the "s" query parameter
+type SortOrder string
+
+// Sort orders supported by Avito. SortDefault keeps Avito's relevance ordering.
+const (
+	SortDefault     SortOrder = ""
+	SortByDateDesc  SortOrder = "104"
+	SortByPriceAsc  SortOrder = "1"
+	SortByPriceDesc SortOrder = "2"
+)
+
+// valid reports whether s is one of the known sort orders
+func (s SortOrder) valid() bool {
+	switch s {
+	case SortDefault, SortByDateDesc, SortByPriceAsc, SortByPriceDesc:
+		return true
+	}
+	return false
+}
+
+// FilterOptions narrows down the listings returned by GetListingsFiltered
+type FilterOptions struct {
+	// WithVideoOnly keeps only listings that have a video. It maps to Avito's "video"
+	// parameter in transport and real estate categories and falls back to checking
+	// Listing.HasVideo elsewhere. The fallback needs the listing pages, so it fails with
+	// SkipDetails, and it runs after limit listings have been fetched: fewer than limit
+	// can come back even when the category has more listings with a video.
+	WithVideoOnly bool
+
+	// WithDeliveryOnly keeps only listings that can be bought with Avito Delivery through
+	// Avito's "d" parameter, which every category accepts. Listings aren't checked
+	// client-side because cards parsed from embedded page data lack the badge.
+	WithDeliveryOnly bool
+
+	// MinPrice and MaxPrice restrict results to a price window in rubles through
+	// Avito's "pmin" and "pmax" parameters. Zero leaves the bound open.
+	MinPrice int
+	MaxPrice int
+
+	// Sort sets the order of the results; the zero value keeps Avito's default
+	Sort SortOrder
+}
+
+// GetListingsFiltered fetches listings from a category URL and applies the filter options using the default parser
+func GetListingsFiltered(categoryURL string, limit int, opts FilterOptions) ([]models.Listing, error) {
+	return defaultParser.GetListingsFiltered(categoryURL, limit, opts)
+}
+
+// GetListingsFiltered fetches listings from a category URL and applies the filter options
+func (p *Parser) GetListingsFiltered(categoryURL string, limit int, opts FilterOptions) ([]models.Listing, error) {
+	filteredURL, err := opts.apply(categoryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Avito's own video filter is trusted as is. Elsewhere the video is only known from
+	// the listing page, which SkipDetails leaves unfetched.
+	if videoFilteredByServer(filteredURL) {
+		opts.WithVideoOnly = false
+	} else if opts.WithVideoOnly && p.opts.SkipDetails {
+		return nil, fmt.Errorf("WithVideoOnly needs listing details outside categories with Avito's video filter: %s", categoryURL)
+	}
+
+	listings, err := p.GetListings(filteredURL, limit)
+	return opts.filter(listings), err
+}
+
+// videoFilteredByServer reports whether the category URL asks Avito for listings with a video only
+func videoFilteredByServer(categoryURL string) bool {
+	parsedURL, err := url.Parse(categoryURL)
+	return err == nil && parsedURL.Query().Get("video") == "1"
+}
+
+// validate checks that the filter options are consistent
+func (opts FilterOptions) validate() error {
+	return opts.urlOptions().validate()
+}
+
+// urlOptions returns the filter options that map directly to URL parameters
+func (opts FilterOptions) urlOptions() URLOptions {
+	return URLOptions{MinPrice: opts.MinPrice, MaxPrice: opts.MaxPrice, Sort: opts.Sort}
+}
+
+// apply adds the query parameters for the filter options to the category URL
+func (opts FilterOptions) apply(categoryURL string) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+
+	parsedURL, err := url.Parse(categoryURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid category URL: %w", err)
+	}
+
+	query := parsedURL.Query()
+	if opts.WithVideoOnly && pathMatches(parsedURL.Path, videoParamCategories) {
+		query.Set("video", "1")
+	}
+	if opts.WithDeliveryOnly {
+		query.Set("d", "1")
+	}
+	opts.urlOptions().setQuery(query)
+
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String(), nil
+}
+
+// filter drops listings that don't match the filter options
+func (opts FilterOptions) filter(listings []models.Listing) []models.Listing {
+	filtered := make([]models.Listing, 0, len(listings))
+	for _, listing := range listings {
+		if opts.WithVideoOnly && !listing.HasVideo {
+			continue
+		}
+		filtered = append(filtered, listing)
+	}
+
+	return filtered
+}
+
+// GetNewSince returns listings published after since using the default parser
+func GetNewSince(categoryURL string, since time.Time, limit int) ([]models.Listing, error) {
+	return defaultParser.GetNewSince(categoryURL, since, limit)
+}
+
+// GetNewSince returns listings from a category published after since, newest first.
+// The category is fetched sorted by date and pagination stops at the first listing
+// published at or before since, so only the newer listings have their details fetched.
+// Promoted listings, which Avito pins above the sorted results, don't stop it; the older
+// ones are left out. Listings whose publish date couldn't be parsed are included to be safe.
+func (p *Parser) GetNewSince(categoryURL string, since time.Time, limit int) ([]models.Listing, error) {
+	return p.GetNewSinceContext(context.Background(), categoryURL, since, limit)
+}
+
+// GetNewSinceContext is GetNewSince with a context. Like GetListings, it returns the
+// listings collected so far alongside any error.
+func (p *Parser) GetNewSinceContext(ctx context.Context, categoryURL string, since time.Time, limit int) ([]models.Listing, error) {
+	if categoryURL == "" {
+		return nil, fmt.Errorf("category: %w", ErrEmptyURL)
+	}
+
+	parsedURL, err := url.Parse(p.regionalURL(categoryURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid category URL: %w", err)
+	}
+
+	query := parsedURL.Query()
+	query.Set("s", string(SortByDateDesc))
+	parsedURL.RawQuery = query.Encode()
+
+	ctx = p.withRequestBudget(ctx)
+	ctx, cancel := p.withMaxDuration(ctx)
+	defer cancel()
+
+	older := func(listing models.Listing) bool {
+		return !listing.PublishedAt.IsZero() && !listing.PublishedAt.After(since)
+	}
+	reachedOlder := func(listing models.Listing) bool {
+		return !listing.IsPromoted && older(listing)
+	}
+	dropOlder := func(listings []models.Listing) []models.Listing {
+		newer := make([]models.Listing, 0, len(listings))
+		for _, listing := range listings {
+			if !older(listing) {
+				newer = append(newer, listing)
+			}
+		}
+		return newer
+	}
+
+	started := time.Now()
+	listings, _, err := p.collectListingPagesUntil(ctx, parsedURL.String(), limit, reachedOlder)
+	listings = dropOlder(listings)
+
+	// Cards without a date get one from their listing page
+	if err == nil && len(listings) > 0 && !p.opts.SkipDetails {
+		listings, err = p.enrichListings(ctx, listings)
+		listings = p.applyFilter(dropOlder(dedupeListings(listings)))
+	}
+
+	p.countScrape(started, len(listings))
+	return listings, deadlineError(ctx, err)
+}
+
+// pathMatches reports whether the URL path contains any of the given fragments
+func pathMatches(path string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(path, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withSellerTypeParam adds Avito's owner-type parameter to categories that support it
+func (p *Parser) withSellerTypeParam(categoryURL string) string {
+	var value string
+	switch p.opts.SellerTypeFilter {
+	case models.SellerTypePrivate:
+		value = "1"
+	case models.SellerTypeCompany:
+		value = "2"
+	default:
+		return categoryURL
+	}
+
+	parsedURL, err := url.Parse(categoryURL)
+	if err != nil {
+		return categoryURL
+	}
+
+	if !pathMatches(parsedURL.Path, sellerTypeParamCategories) {
+		return categoryURL
+	}
+
+	query := parsedURL.Query()
+	query.Set("user", value)
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
+
+// keepListing reports whether a listing passes the Filter option and, with
+// StrictValidation, is valid, with ExcludePromoted, isn't promoted and, with
+// SellerTypeFilter, isn't known to be from another type of seller
+func (p *Parser) keepListing(listing models.Listing) bool {
+	if p.opts.ExcludePromoted && listing.IsPromoted {
+		return false
+	}
+	if p.opts.SellerTypeFilter != "" && listing.SellerType != "" && listing.SellerType != p.opts.SellerTypeFilter {
+		return false
+	}
+	if p.opts.StrictValidation {
+		if err := listing.Validate(); err != nil {
+			log.Printf("Skipping %v", err)
+			return false
+		}
+	}
+	return p.opts.Filter == nil || p.opts.Filter(listing)
+}
+
+// applyFilter drops the listings rejected by keepListing
+func (p *Parser) applyFilter(listings []models.Listing) []models.Listing {
+	if p.opts.Filter == nil && !p.opts.StrictValidation && !p.opts.ExcludePromoted && p.opts.SellerTypeFilter == "" {
+		return listings
+	}
+
+	filtered := make([]models.Listing, 0, len(listings))
+	for _, listing := range listings {
+		if p.keepListing(listing) {
+			filtered = append(filtered, listing)
+		}
+	}
+
+	return filtered
+}
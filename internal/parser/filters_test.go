@@ -0,0 +1,340 @@
+package parser
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestGetNewSinceStopsAtFirstOlderListing(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony?s=104":                  "newest.html",
+		"/moskva/telefony?p=2&s=104":              "category.html",
+		"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+		"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{MaxPages: 10})
+
+	since := time.Date(2024, time.March, 5, 0, 0, 0, 0, moscowLocation)
+	listings, err := p.GetNewSince(srv.URL+"/moskva/telefony", since, 0)
+	if err != nil {
+		t.Fatalf("GetNewSince: %v", err)
+	}
+
+	// The old promoted listing is left out without stopping, the undated Samsung card
+	// gets its date from its listing page, and the Pixel is where collection stops
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got listings %v, want %v", got, want)
+	}
+
+	if hits := srv.Hits("/moskva/telefony?p=2&s=104"); hits != 0 {
+		t.Error("pagination went on past the first older listing")
+	}
+	for _, route := range []string{"/moskva/telefony/nokia_3310_9999999999", "/moskva/telefony/pixel_8_3333333333"} {
+		if hits := srv.Hits(route); hits != 0 {
+			t.Errorf("details of older listing %s were fetched", route)
+		}
+	}
+}
+
+func TestGetNewSinceWithoutNewListings(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony?s=104": "newest.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{MaxPages: 10})
+
+	since := time.Date(2024, time.April, 1, 0, 0, 0, 0, moscowLocation)
+	listings, err := p.GetNewSince(srv.URL+"/moskva/telefony", since, 0)
+	if err != nil {
+		t.Fatalf("GetNewSince: %v", err)
+	}
+
+	if len(listings) != 0 {
+		t.Errorf("got listings %v, want none", listingIDs(listings))
+	}
+	if hits := srv.TotalHits(); hits != 1 {
+		t.Errorf("server got %d requests, want only the first page", hits)
+	}
+}
+
+func TestSellerTypeFilterSkipsDetailsOfOtherSellers(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony":                        "category_sellers.html",
+		"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+		"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SellerTypeFilter: models.SellerTypePrivate})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	// The Samsung card doesn't show its seller, so only its listing page reveals a company
+	if got, want := listingIDs(listings), []string{"1111111111"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got listings %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/moskva/telefony/samsung_s24_2222222222"); hits != 1 {
+		t.Errorf("listing with an unknown seller was fetched %d times, want 1", hits)
+	}
+	if hits := srv.Hits("/moskva/telefony/pixel_8_3333333333"); hits != 0 {
+		t.Error("details were fetched for a card showing a company seller")
+	}
+}
+
+func TestSellerTypeFilterQueryParam(t *testing.T) {
+	tests := []struct {
+		filter, categoryURL, want string
+	}{
+		{models.SellerTypePrivate, "https://www.avito.ru/moskva/avtomobili", "https://www.avito.ru/moskva/avtomobili?user=1"},
+		{models.SellerTypeCompany, "https://www.avito.ru/moskva/kvartiry/prodam", "https://www.avito.ru/moskva/kvartiry/prodam?user=2"},
+		{models.SellerTypePrivate, "https://www.avito.ru/moskva/telefony", "https://www.avito.ru/moskva/telefony"},
+		{"", "https://www.avito.ru/moskva/avtomobili", "https://www.avito.ru/moskva/avtomobili"},
+	}
+
+	for _, tt := range tests {
+		p, err := NewParser(ParserOptions{SellerTypeFilter: tt.filter})
+		if err != nil {
+			t.Fatalf("NewParser: %v", err)
+		}
+		if got := p.withSellerTypeParam(tt.categoryURL); got != tt.want {
+			t.Errorf("withSellerTypeParam(%q) with filter %q = %q, want %q", tt.categoryURL, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestFilterOptionsVideoParam(t *testing.T) {
+	tests := []struct {
+		categoryURL string
+		wantVideo   bool
+	}{
+		{"https://www.avito.ru/moskva/avtomobili/toyota", true},
+		{"https://www.avito.ru/moskva/kvartiry/sdam", true},
+		{"https://www.avito.ru/moskva/doma_dachi_kottedzhi", true},
+		{"https://www.avito.ru/moskva/telefony", false},
+		{"https://www.avito.ru/moskva/garazhi_i_mashinomesta", false},
+	}
+
+	for _, tt := range tests {
+		got, err := FilterOptions{WithVideoOnly: true}.apply(tt.categoryURL)
+		if err != nil {
+			t.Fatalf("apply(%q): %v", tt.categoryURL, err)
+		}
+
+		parsedURL, err := url.Parse(got)
+		if err != nil {
+			t.Fatalf("apply(%q) returned an invalid URL %q: %v", tt.categoryURL, got, err)
+		}
+		if video := parsedURL.Query().Get("video") == "1"; video != tt.wantVideo {
+			t.Errorf("apply(%q) = %q, want video parameter %v", tt.categoryURL, got, tt.wantVideo)
+		}
+	}
+
+	// Without the option the parameter is never added
+	got, err := FilterOptions{}.apply("https://www.avito.ru/moskva/avtomobili")
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if strings.Contains(got, "video") {
+		t.Errorf("apply without WithVideoOnly = %q", got)
+	}
+}
+
+func TestFilterOptionsVideoFallback(t *testing.T) {
+	listings := []models.Listing{
+		{ID: "1", HasVideo: true},
+		{ID: "2"},
+	}
+
+	got := FilterOptions{WithVideoOnly: true}.filter(listings)
+	if ids := listingIDs(got); !reflect.DeepEqual(ids, []string{"1"}) {
+		t.Errorf("filter kept %v, want [1]", ids)
+	}
+}
+
+func TestGetListingsFilteredKeepsServerVideoResults(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/avtomobili?video=1": "category.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	// Avito already filtered the results, so the cards aren't checked for a video again
+	listings, err := p.GetListingsFiltered(srv.URL+"/moskva/avtomobili", 0, FilterOptions{WithVideoOnly: true})
+	if err != nil {
+		t.Fatalf("GetListingsFiltered: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+}
+
+func TestGetListingsFilteredVideoFallbackNeedsDetails(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListingsFiltered(srv.URL+"/moskva/telefony", 0, FilterOptions{WithVideoOnly: true})
+	if err == nil {
+		t.Fatalf("GetListingsFiltered = %v, want an error without listing details", listingIDs(listings))
+	}
+	if hits := srv.TotalHits(); hits != 0 {
+		t.Errorf("server got %d requests, want none", hits)
+	}
+}
+
+func TestFilterOptionsPriceParams(t *testing.T) {
+	tests := []struct {
+		opts FilterOptions
+		want string
+	}{
+		{FilterOptions{MinPrice: 10000, MaxPrice: 50000}, "https://www.avito.ru/moskva/telefony?pmax=50000&pmin=10000"},
+		{FilterOptions{MinPrice: 10000}, "https://www.avito.ru/moskva/telefony?pmin=10000"},
+		{FilterOptions{MaxPrice: 50000}, "https://www.avito.ru/moskva/telefony?pmax=50000"},
+		{FilterOptions{MinPrice: 20000, MaxPrice: 20000}, "https://www.avito.ru/moskva/telefony?pmax=20000&pmin=20000"},
+		{FilterOptions{}, "https://www.avito.ru/moskva/telefony"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.opts.apply("https://www.avito.ru/moskva/telefony")
+		if err != nil {
+			t.Errorf("apply with %+v: %v", tt.opts, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("apply with %+v = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+
+	for _, opts := range []FilterOptions{
+		{MinPrice: 50000, MaxPrice: 10000},
+		{MinPrice: -1},
+		{MaxPrice: -1},
+	} {
+		if got, err := opts.apply("https://www.avito.ru/moskva/telefony"); err == nil {
+			t.Errorf("apply with %+v = %q, want an error", opts, got)
+		}
+	}
+}
+
+func TestGetListingsFilteredByPrice(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony?pmax=60000&pmin=50000":  "category.html",
+		"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+		"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	listings, err := p.GetListingsFiltered(srv.URL+"/moskva/telefony", 10, FilterOptions{MinPrice: 50000, MaxPrice: 60000})
+	if err != nil {
+		t.Fatalf("GetListingsFiltered: %v", err)
+	}
+
+	// The listings are still enriched from their pages
+	if len(listings) != 2 || listings[0].Description == "" || listings[1].SellerName == "" {
+		t.Errorf("GetListingsFiltered returned %+v, want both listings with details", listings)
+	}
+}
+
+func TestSortOrderParam(t *testing.T) {
+	tests := []struct {
+		sort SortOrder
+		want string
+	}{
+		{SortDefault, ""},
+		{SortByDateDesc, "104"},
+		{SortByPriceAsc, "1"},
+		{SortByPriceDesc, "2"},
+	}
+
+	for _, tt := range tests {
+		got, err := FilterOptions{Sort: tt.sort}.apply("https://www.avito.ru/moskva/telefony?q=iphone")
+		if err != nil {
+			t.Fatalf("apply with sort %q: %v", tt.sort, err)
+		}
+
+		parsedURL, err := url.Parse(got)
+		if err != nil {
+			t.Fatalf("apply with sort %q returned an invalid URL %q: %v", tt.sort, got, err)
+		}
+		query := parsedURL.Query()
+		if query.Get("s") != tt.want || (tt.want == "" && query.Has("s")) {
+			t.Errorf("apply with sort %q = %q, want s=%q", tt.sort, got, tt.want)
+		}
+		if query.Get("q") != "iphone" {
+			t.Errorf("apply with sort %q dropped the search query: %q", tt.sort, got)
+		}
+	}
+
+	if got, err := (FilterOptions{Sort: "price"}).apply("https://www.avito.ru/moskva/telefony"); err == nil {
+		t.Errorf("apply with an unknown sort order = %q, want an error", got)
+	}
+}
+
+func TestFilterRejectsHalfTheListings(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{
+		// Keeps listings under 60 000 ₽, which rejects the iPhone
+		Filter: func(listing models.Listing) bool { return listing.Price.Value < 60000 },
+	})
+
+	// The limit counts listings the filter keeps, so the rejected first card doesn't use it up
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 1)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got listings %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/moskva/telefony/iphone_15_1111111111"); hits != 0 {
+		t.Error("details of a rejected listing were fetched")
+	}
+}
+
+func TestFilterAppliesToDetails(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{
+		// Cards don't name the seller type, so both pass on the category page and
+		// the Samsung from a company is only dropped once its details are in
+		Filter: func(listing models.Listing) bool { return listing.SellerType != models.SellerTypeCompany },
+	})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got listings %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/moskva/telefony/samsung_s24_2222222222"); hits != 1 {
+		t.Errorf("details of the Samsung were fetched %d times, want 1", hits)
+	}
+}
+
+func TestStrictValidationDropsInvalidListings(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/mebel": "category_invalid.html"})
+
+	lenient := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+	listings, err := lenient.GetListings(srv.URL+"/moskva/mebel", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 5 {
+		t.Errorf("got %d listings without StrictValidation, want all 5", len(listings))
+	}
+
+	// The table without a price and the chair with an unparsed one are dropped and
+	// don't count toward the limit
+	strict := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, StrictValidation: true})
+	listings, err = strict.GetListings(srv.URL+"/moskva/mebel", 2)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"3030303030", "4040404040"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// The Parser logs every request; keep test output readable
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fixtureServer replays the pages in testdata. Routes map a request path, with its
+// query if it has one, to a fixture file; other requests get a 404.
+type fixtureServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	routes  map[string]string
+	hits    map[string]int
+	headers []http.Header
+}
+
+// newFixtureServer starts a server replaying routes that is closed with the test
+func newFixtureServer(t *testing.T, routes map[string]string) *fixtureServer {
+	t.Helper()
+
+	s := &fixtureServer{routes: routes, hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	route := r.URL.RequestURI()
+
+	s.mu.Lock()
+	s.hits[route]++
+	s.headers = append(s.headers, r.Header.Clone())
+	fixture, ok := s.routes[route]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if strings.HasSuffix(fixture, ".json") {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	_, _ = w.Write(body)
+}
+
+// Hits returns how many times route was requested
+func (s *fixtureServer) Hits(route string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[route]
+}
+
+// TotalHits returns the number of requests the server got
+func (s *fixtureServer) TotalHits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for _, hits := range s.hits {
+		total += hits
+	}
+	return total
+}
+
+// Headers returns the headers of the requests the server got, in the order they came
+func (s *fixtureServer) Headers() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.headers)
+}
+
+// newFixtureParser creates a Parser pointed at srv that doesn't wait between requests
+// or retry them. Options set in opts take precedence.
+func newFixtureParser(t *testing.T, srv *fixtureServer, opts ParserOptions) *Parser {
+	t.Helper()
+	return newTestParser(t, srv.URL, opts)
+}
+
+// newTestParser works like newFixtureParser for a server at baseURL, such as one
+// scripting responses the fixtures can't express
+func newTestParser(t *testing.T, baseURL string, opts ParserOptions) *Parser {
+	t.Helper()
+
+	opts.BaseURL = baseURL
+	if opts.MinDelay == 0 {
+		opts.MinDelay = time.Nanosecond
+	}
+	if opts.DelayStrategy == nil {
+		opts.DelayStrategy = ConstantDelay(0)
+	}
+	if opts.MaxPages == 0 {
+		opts.MaxPages = 1
+	}
+
+	p, err := NewParser(opts)
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	return p
+}
@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// fullSizeImage is the size segment used for full resolution images on Avito's image hosts
+const fullSizeImage = "1280x960"
+
+var (
+	// Regex to match the size segment of legacy thumbnail URLs like "https://00.img.avito.st/208x156/1234.jpg"
+	thumbnailSizeRegex = regexp.MustCompile(`^(https?://[^/]*img\.avito\.st)/\d+x\d+/`)
+	// Regex to match photo badges like "12 фото" or "Ещё 5 фото"; the second form counts the photos not shown
+	photoBadgeRegex = regexp.MustCompile(`(?i)(ещё|еще|\+)?\s*(\d+)\s*фото`)
+	// Regex to match a gallery position counter like "1 / 12"
+	galleryPositionRegex = regexp.MustCompile(`^\d+ ?/ ?(\d+)$`)
+)
+
+// imageExtensions maps image content types to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"image/avif": ".avif",
+}
+
+// imageSource returns the best URL of an img element: the largest srcset candidate,
+// then src, then the lazy-loading data-src
+func imageSource(img *goquery.Selection) string {
+	for _, attr := range []string{"srcset", "data-srcset"} {
+		if srcset, ok := img.Attr(attr); ok {
+			if best := bestSrcsetCandidate(srcset); best != "" {
+				return best
+			}
+		}
+	}
+
+	for _, attr := range []string{"src", "data-src"} {
+		if src, ok := img.Attr(attr); ok && strings.TrimSpace(src) != "" && !strings.HasPrefix(src, "data:") {
+			return strings.TrimSpace(src)
+		}
+	}
+
+	return ""
+}
+
+// bestSrcsetCandidate returns the URL with the largest width ("640w") or density ("2x")
+// descriptor in a srcset attribute. Candidates without a descriptor count as 1x.
+func bestSrcsetCandidate(srcset string) string {
+	var best string
+	bestSize := -1.0
+
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+
+		size := 1.0
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			if value, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64); err == nil {
+				size = value
+			}
+		}
+
+		if size > bestSize {
+			best, bestSize = fields[0], size
+		}
+	}
+
+	return best
+}
+
+// normalizeImageURLs makes image URLs absolute, upgrades legacy thumbnails to full size
+// and removes duplicates, keeping the first occurrence
+func normalizeImageURLs(imageURLs []string) []string {
+	if len(imageURLs) == 0 {
+		return imageURLs
+	}
+
+	seen := make(map[string]bool, len(imageURLs))
+	normalized := make([]string, 0, len(imageURLs))
+	for _, imageURL := range imageURLs {
+		imageURL = thumbnailSizeRegex.ReplaceAllString(normalizeURL(imageURL), "${1}/"+fullSizeImage+"/")
+		if seen[imageURL] {
+			continue
+		}
+		seen[imageURL] = true
+		normalized = append(normalized, imageURL)
+	}
+
+	return normalized
+}
+
+// cardPhotoCount counts the photos of a listing card from its image slider and "N фото"
+// badge. Cards often show only a few slides, so the badge wins when it reports more.
+func cardPhotoCount(card *goquery.Selection) int {
+	count := card.Find("*[data-marker^='slider-image'], ul[class*='photo-slider'] li, div[class*='photo-slider-item']").Length()
+
+	card.Find("span, div, p").Each(func(_ int, s *goquery.Selection) {
+		if s.Children().Length() > 0 {
+			return
+		}
+		matches := photoBadgeRegex.FindStringSubmatch(cleanText(s.Text()))
+		if matches == nil {
+			return
+		}
+		badge, err := strconv.Atoi(matches[2])
+		if err != nil {
+			return
+		}
+		if matches[1] != "" {
+			badge += max(count, 1)
+		}
+		count = max(count, badge)
+	})
+
+	if count == 0 && imageSource(card.Find("img").First()) != "" {
+		count = 1
+	}
+	return count
+}
+
+// pagePhotoCount counts the photos in a listing page's gallery from its frames,
+// thumbnails and "1 / 12" position counter, whichever reports the most
+func pagePhotoCount(doc *goquery.Selection) int {
+	count := doc.Find("*[data-marker='image-frame/image-wrapper'], div.gallery-img-wrapper, div.photo-slider-image-wrapper").Length()
+	count = max(count, doc.Find("*[data-marker='image-preview/item'], li.gallery-list-item").Length())
+
+	doc.Find("*[data-marker*='gallery'] span, *[data-marker*='gallery'] div, div.gallery span, div.gallery div").Each(func(_ int, s *goquery.Selection) {
+		if s.Children().Length() > 0 {
+			return
+		}
+		if matches := galleryPositionRegex.FindStringSubmatch(cleanText(s.Text())); matches != nil {
+			if total, err := strconv.Atoi(matches[1]); err == nil {
+				count = max(count, total)
+			}
+		}
+	})
+
+	return count
+}
+
+// DownloadImages saves the images of a listing into dir using the default parser
+func DownloadImages(listing models.Listing, dir string) ([]string, error) {
+	return defaultParser.DownloadImages(listing, dir)
+}
+
+// DownloadImages saves the images of a listing into dir and returns their local paths.
+// Files are named after the listing ID and the image index, e.g. "7304268017_1.jpg",
+// and images already present in dir are not downloaded again. Failed downloads and
+// responses that aren't images are skipped; their errors are returned joined together.
+func (p *Parser) DownloadImages(listing models.Listing, dir string) ([]string, error) {
+	return p.DownloadImagesContext(context.Background(), listing, dir)
+}
+
+// DownloadImagesContext works like DownloadImages, aborting when the context is cancelled
+func (p *Parser) DownloadImagesContext(ctx context.Context, listing models.Listing, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating image directory: %w", err)
+	}
+
+	prefix := listing.ID
+	if prefix == "" {
+		prefix = "listing"
+	}
+
+	client := &http.Client{Transport: p.transport, Jar: p.jar, Timeout: p.opts.RequestTimeout}
+
+	var paths []string
+	var errs []error
+	for i, imageURL := range listing.ImageURLs {
+		name := fmt.Sprintf("%s_%d", prefix, i+1)
+
+		if existing, _ := filepath.Glob(filepath.Join(dir, name+".*")); len(existing) > 0 {
+			paths = append(paths, existing[0])
+			continue
+		}
+
+		if err := p.waitForRateLimit(ctx, imageURL); err != nil {
+			return paths, err
+		}
+
+		path, err := p.downloadImage(ctx, client, imageURL, filepath.Join(dir, name))
+		if err != nil {
+			if ctx.Err() != nil {
+				return paths, ctx.Err()
+			}
+			log.Printf("Error downloading image %s: %v", imageURL, err)
+			errs = append(errs, fmt.Errorf("image %s: %w", imageURL, err))
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, errors.Join(errs...)
+}
+
+// downloadImage fetches one image and writes it to basePath plus an extension matching its content type
+func (p *Parser) downloadImage(ctx context.Context, client *http.Client, imageURL, basePath string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", p.randomUserAgent())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("not an image: %q", contentType)
+	}
+
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		} else {
+			ext = ".img"
+		}
+	}
+
+	// Write to a temporary file first so an interrupted download isn't mistaken for a finished one
+	path := basePath + ext
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(basePath)+"-*.part")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestDownloadImages(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/photo.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write([]byte("jpeg bytes"))
+		case "/moved":
+			http.Redirect(w, r, "/photo.png", http.StatusFound)
+		case "/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png bytes"))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	limiter := &countingLimiter{}
+	p := newTestParser(t, srv.URL, ParserOptions{Limiter: limiter})
+	dir := t.TempDir()
+	listing := models.Listing{
+		ID:        "7304268017",
+		ImageURLs: []string{srv.URL + "/photo.jpg", srv.URL + "/moved", srv.URL + "/page", srv.URL + "/missing"},
+	}
+
+	paths, err := p.DownloadImages(listing, dir)
+	if err == nil {
+		t.Error("DownloadImages didn't report the page and the missing image")
+	}
+
+	want := []string{filepath.Join(dir, "7304268017_1.jpg"), filepath.Join(dir, "7304268017_2.png")}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	for path, content := range map[string]string{want[0]: "jpeg bytes", want[1]: "png bytes"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s holds %q, want %q", path, data, content)
+		}
+	}
+
+	// Nothing else, such as partial downloads or the rejected page, is left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("dir holds %d files, want 2", len(entries))
+	}
+
+	// Every image request waited on the limiter
+	if waits := limiter.Waits(); waits != 4 {
+		t.Errorf("limiter was waited on %d times, want 4", waits)
+	}
+
+	// Downloading again skips the images already saved
+	paths, _ = p.DownloadImages(listing, dir)
+	if !slices.Equal(paths, want) {
+		t.Errorf("paths on the second run = %v, want %v", paths, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if hits["/photo.jpg"] != 1 || hits["/photo.png"] != 1 {
+		t.Errorf("saved images were downloaded again: %v", hits)
+	}
+}
+
+func TestImageSource(t *testing.T) {
+	tests := []struct {
+		name, img, want string
+	}{
+		{
+			name: "width descriptors",
+			img:  `<img src="https://10.img.avito.st/image/1/small.jpg" srcset="https://10.img.avito.st/image/1/a.jpg 236w, https://10.img.avito.st/image/1/c.jpg 636w, https://10.img.avito.st/image/1/b.jpg 472w">`,
+			want: "https://10.img.avito.st/image/1/c.jpg",
+		},
+		{
+			name: "density descriptors",
+			img:  `<img srcset="https://10.img.avito.st/image/1/1x.jpg, https://10.img.avito.st/image/1/2x.jpg 2x, https://10.img.avito.st/image/1/1.5x.jpg 1.5x">`,
+			want: "https://10.img.avito.st/image/1/2x.jpg",
+		},
+		{
+			name: "lazy-loaded srcset",
+			img:  `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-srcset="https://10.img.avito.st/image/1/s.jpg 1x, https://10.img.avito.st/image/1/l.jpg 2x">`,
+			want: "https://10.img.avito.st/image/1/l.jpg",
+		},
+		{
+			name: "lazy-loaded src behind a placeholder",
+			img:  `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="https://10.img.avito.st/image/1/lazy.jpg">`,
+			want: "https://10.img.avito.st/image/1/lazy.jpg",
+		},
+		{
+			name: "placeholder only",
+			img:  `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageSource(parseFragment(t, tt.img).Find("img")); got != tt.want {
+				t.Errorf("imageSource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeImageURLs(t *testing.T) {
+	got := normalizeImageURLs([]string{
+		"https://00.img.avito.st/208x156/1234567890.jpg",
+		"//00.img.avito.st/640x480/1234567890.jpg",
+		"https://00.img.avito.st/140x105/2345678901.jpg",
+		"https://10.img.avito.st/image/1/1.abc.jpg?cqp=2.xyz",
+	})
+	want := []string{
+		"https://00.img.avito.st/1280x960/1234567890.jpg",
+		"https://00.img.avito.st/1280x960/2345678901.jpg",
+		"https://10.img.avito.st/image/1/1.abc.jpg?cqp=2.xyz",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("normalizeImageURLs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// heldListingServer serves the iPhone listing page, or a 500 with fail, once release
+// is closed, signalling arrived as each request comes in
+func heldListingServer(t *testing.T, fail bool) (srv *httptest.Server, hits *atomic.Int32, arrived chan struct{}, release chan struct{}) {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", "item_iphone.html"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	hits = &atomic.Int32{}
+	arrived = make(chan struct{}, 10)
+	release = make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		arrived <- struct{}{}
+		<-release
+
+		if fail {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits, arrived, release
+}
+
+func TestConcurrentDetailFetchesShareOneRequest(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		srv, hits, arrived, release := heldListingServer(t, fail)
+		p := newTestParser(t, srv.URL, ParserOptions{})
+
+		// The same listing reached from two categories, with a tracking parameter on one link
+		listings := []models.Listing{
+			{ID: "1111111111", URL: srv.URL + "/moskva/telefony/iphone_15_1111111111", CategoryURL: srv.URL + "/moskva/telefony"},
+			{ID: "1111111111", URL: srv.URL + "/moskva/telefony/iphone_15_1111111111?context=abc", CategoryURL: srv.URL + "/moskva/apple"},
+		}
+		results := make([]models.Listing, len(listings))
+		errs := make([]error, len(listings))
+
+		var wg sync.WaitGroup
+		fetch := func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = p.GetListingDetails(listings[i])
+		}
+
+		wg.Add(2)
+		go fetch(0)
+		<-arrived
+		go fetch(1)
+		// Give the second caller time to join the request in flight
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got := hits.Load(); got != 1 {
+			t.Errorf("fail=%v: server got %d requests, want 1", fail, got)
+		}
+		if fail {
+			if errs[0] == nil || errs[1] == nil || errs[0].Error() != errs[1].Error() {
+				t.Errorf("errors = %v and %v, want the same error for both callers", errs[0], errs[1])
+			}
+			continue
+		}
+
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("GetListingDetails %d: %v", i, err)
+			}
+		}
+		if results[0].SellerName == "" || results[0].SellerName != results[1].SellerName || results[0].Description != results[1].Description {
+			t.Errorf("callers got different details: %+v and %+v", results[0], results[1])
+		}
+		for i, result := range results {
+			if result.CategoryURL != listings[i].CategoryURL {
+				t.Errorf("caller %d got CategoryURL %q, want its own %q", i, result.CategoryURL, listings[i].CategoryURL)
+			}
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+var (
+	// Regex to extract the URL-encoded window.__initialData__ state
+	initialDataRegex = regexp.MustCompile(`window\.__initialData__\s*=\s*"([^"]*)"`)
+
+	// errNoInitialData is returned when the page has no embedded listing state
+	errNoInitialData = errors.New("no embedded listing data found")
+)
+
+// initialDataItem is a listing as it appears in Avito's embedded page state
+type initialDataItem struct {
+	ID          json.Number `json:"id"`
+	Type        string      `json:"type"`
+	URLPath     string      `json:"urlPath"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Location    struct {
+		Name string `json:"name"`
+	} `json:"location"`
+	Geo struct {
+		FormattedAddress string `json:"formattedAddress"`
+	} `json:"geo"`
+	SortTimeStamp int64 `json:"sortTimeStamp"`
+	PriceDetailed struct {
+		FullString string  `json:"fullString"`
+		Value      float64 `json:"value"`
+		HasValue   bool    `json:"hasValue"`
+	} `json:"priceDetailed"`
+	Coords struct {
+		Lat flexFloat `json:"lat"`
+		Lng flexFloat `json:"lng"`
+	} `json:"coords"`
+	Images      []map[string]string `json:"images"`
+	ImagesCount int                 `json:"imagesCount"`
+	HasVideo    bool                `json:"hasVideo"`
+	IsVIP       bool                `json:"isVip"`
+	IsPromoted  bool                `json:"isPromoted"`
+}
+
+// flexFloat decodes numbers that Avito sometimes encodes as strings
+type flexFloat float64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *flexFloat) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return err
+	}
+
+	*f = flexFloat(value)
+	return nil
+}
+
+// parseInitialData extracts listings from the JSON state Avito embeds in its pages.
+// It returns errNoInitialData when the page carries no such state.
+func (p *Parser) parseInitialData(htmlContent string) ([]models.Listing, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("%w: error parsing HTML: %w", ErrParseFailed, err)
+	}
+
+	return p.parseInitialDataDocument(doc)
+}
+
+// parseInitialDataDocument extracts listings from the JSON state embedded in an already parsed page
+func (p *Parser) parseInitialDataDocument(doc *goquery.Document) ([]models.Listing, error) {
+	var states []string
+
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		// Microfrontend state and Next.js data are stored as JSON in script tags
+		if _, ok := s.Attr("data-mfe-state"); ok || s.AttrOr("id", "") == "__NEXT_DATA__" {
+			states = append(states, html.UnescapeString(s.Text()))
+			return
+		}
+
+		// The legacy state is a URL-encoded JSON string assigned to a global
+		if matches := initialDataRegex.FindStringSubmatch(s.Text()); matches != nil {
+			if decoded, err := url.QueryUnescape(matches[1]); err == nil {
+				states = append(states, decoded)
+			}
+		}
+	})
+
+	for _, state := range states {
+		var data interface{}
+		if err := json.Unmarshal([]byte(state), &data); err != nil {
+			continue
+		}
+
+		listings, err := p.listingsFromState(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(listings) > 0 {
+			return listings, nil
+		}
+	}
+
+	return nil, errNoInitialData
+}
+
+// listingsFromState converts the first "items" array found in decoded JSON into listings.
+// It returns no listings when there is no such array.
+func (p *Parser) listingsFromState(data interface{}) ([]models.Listing, error) {
+	rawItems := findItemsArray(data)
+	if rawItems == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(rawItems)
+	if err != nil {
+		return nil, nil
+	}
+
+	var items []initialDataItem
+	if err := json.Unmarshal(encoded, &items); err != nil {
+		return nil, fmt.Errorf("%w: error decoding embedded listings: %w", ErrParseFailed, err)
+	}
+
+	var listings []models.Listing
+	for _, item := range items {
+		if item.Type != "" && item.Type != "item" {
+			continue // banners and other non-listing entries
+		}
+		if listing := p.toListing(item); listing.ID != "" {
+			listings = append(listings, listing)
+		}
+	}
+
+	return listings, nil
+}
+
+// findItemsArray walks decoded JSON looking for an "items" array of listing objects
+func findItemsArray(node interface{}) []interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if items, ok := value["items"].([]interface{}); ok && len(items) > 0 {
+			if first, ok := items[0].(map[string]interface{}); ok {
+				if _, hasURL := first["urlPath"]; hasURL {
+					return items
+				}
+			}
+		}
+		for _, child := range value {
+			if items := findItemsArray(child); items != nil {
+				return items
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			if items := findItemsArray(child); items != nil {
+				return items
+			}
+		}
+	}
+
+	return nil
+}
+
+// toListing maps an embedded item onto models.Listing
+func (p *Parser) toListing(item initialDataItem) models.Listing {
+	listing := models.Listing{
+		ID:          item.ID.String(),
+		Title:       cleanText(item.Title),
+		Description: cleanMultilineText(item.Description),
+		URL:         normalizeLink(p.opts.BaseURL, item.URLPath),
+		Location:    cleanText(item.Geo.FormattedAddress),
+		HasVideo:    item.HasVideo,
+		IsPromoted:  item.IsVIP || item.IsPromoted,
+		Latitude:    float64(item.Coords.Lat),
+		Longitude:   float64(item.Coords.Lng),
+		IsActive:    true,
+	}
+
+	if listing.Location == "" {
+		listing.Location = cleanText(item.Location.Name)
+	}
+
+	listing.HasCoordinates = listing.Latitude != 0 || listing.Longitude != 0
+
+	if item.PriceDetailed.FullString != "" {
+		listing.Price = p.parsePrice(item.PriceDetailed.FullString)
+	}
+	if listing.Price.Value == 0 && item.PriceDetailed.HasValue {
+		listing.Price.Value = item.PriceDetailed.Value
+		listing.Price.Currency = "RUB"
+	}
+
+	if item.SortTimeStamp > 0 {
+		listing.PublishedAt = time.UnixMilli(item.SortTimeStamp)
+	}
+
+	for _, image := range item.Images {
+		if imageURL := largestImage(image); imageURL != "" {
+			listing.ImageURLs = append(listing.ImageURLs, imageURL)
+		}
+	}
+	listing.ImageURLs = normalizeImageURLs(listing.ImageURLs)
+	listing.PhotoCount = max(item.ImagesCount, len(listing.ImageURLs))
+	listing.PricePerSquareMeter = pricePerSquareMeter(listing)
+
+	return listing
+}
+
+// largestImage picks the highest resolution URL from a map keyed by "WxH" sizes
+func largestImage(sizes map[string]string) string {
+	var best string
+	bestWidth := -1
+
+	for size, imageURL := range sizes {
+		width, _ := strconv.Atoi(strings.SplitN(size, "x", 2)[0])
+		if width > bestWidth {
+			best, bestWidth = imageURL, width
+		}
+	}
+
+	return best
+}
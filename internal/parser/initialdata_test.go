@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFixture returns the contents of a testdata file
+func readFixture(t *testing.T, fixture string) string {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	return string(body)
+}
+
+func TestParseInitialDataFromPageState(t *testing.T) {
+	p := newTestParser(t, "https://www.avito.ru", ParserOptions{})
+
+	listings, err := p.parseInitialData(readFixture(t, "category_state.html"))
+	if err != nil {
+		t.Fatalf("parseInitialData: %v", err)
+	}
+
+	// The banner is left out and the card markup is ignored in favour of the state
+	if got, want := listingIDs(listings), []string{"3333333333", "4444444444"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listing IDs = %v, want %v", got, want)
+	}
+
+	pixel := listings[0]
+	if pixel.Title != "Google Pixel 8" || pixel.URL != "https://www.avito.ru/moskva/telefony/pixel_8_3333333333" {
+		t.Errorf("listing = %+v, want the Pixel with an absolute URL", pixel)
+	}
+	if pixel.Price.Value != 45000 || pixel.Price.Currency != "RUB" {
+		t.Errorf("Price = %+v, want 45000 RUB", pixel.Price)
+	}
+	if pixel.Location != "Москва, Ленинский пр-т" {
+		t.Errorf("Location = %q, want the formatted address", pixel.Location)
+	}
+	if !pixel.PublishedAt.Equal(time.UnixMilli(1709633700000)) {
+		t.Errorf("PublishedAt = %v, want the sort timestamp", pixel.PublishedAt)
+	}
+	if !pixel.HasCoordinates || pixel.Latitude != 55.7 || pixel.Longitude != 37.6 {
+		t.Errorf("coordinates = %v, %v, want 55.7, 37.6 from a string and a number", pixel.Latitude, pixel.Longitude)
+	}
+	if len(pixel.ImageURLs) != 1 || !strings.HasSuffix(pixel.ImageURLs[0], "/large.jpg") || pixel.PhotoCount != 5 {
+		t.Errorf("images = %v with PhotoCount %d, want the largest size and a count of 5", pixel.ImageURLs, pixel.PhotoCount)
+	}
+	if !pixel.HasVideo || pixel.IsPromoted {
+		t.Errorf("listing has video %v and promoted %v, want a video and no promotion", pixel.HasVideo, pixel.IsPromoted)
+	}
+
+	// Without a price string the raw value is used; VIP listings are promoted
+	xiaomi := listings[1]
+	if xiaomi.Price.Value != 30000 || xiaomi.Location != "Москва" || !xiaomi.IsPromoted {
+		t.Errorf("listing = %+v, want a promoted listing at 30000 in Москва", xiaomi)
+	}
+}
+
+func TestParseInitialDataFromLegacyState(t *testing.T) {
+	p := newTestParser(t, "https://www.avito.ru", ParserOptions{})
+
+	state := `{"data":{"items":[{"id":5555555555,"urlPath":"/moskva/telefony/nothing_2_5555555555","title":"Nothing Phone 2"}]}}`
+	page := `<html><body><script>window.__initialData__ = "` + url.QueryEscape(state) + `";</script></body></html>`
+
+	listings, err := p.parseInitialData(page)
+	if err != nil {
+		t.Fatalf("parseInitialData: %v", err)
+	}
+	if len(listings) != 1 || listings[0].ID != "5555555555" || listings[0].Title != "Nothing Phone 2" {
+		t.Errorf("listings = %+v, want the Nothing Phone", listings)
+	}
+}
+
+func TestParseInitialDataWithoutState(t *testing.T) {
+	p := newTestParser(t, "https://www.avito.ru", ParserOptions{})
+
+	for _, fixture := range []string{"category.html", "category_state_malformed.html"} {
+		if _, err := p.parseInitialData(readFixture(t, fixture)); !errors.Is(err, errNoInitialData) {
+			t.Errorf("parseInitialData(%s) error = %v, want errNoInitialData", fixture, err)
+		}
+	}
+}
+
+func TestParseItemsFallsBackToCards(t *testing.T) {
+	p := newTestParser(t, "https://www.avito.ru", ParserOptions{})
+
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		{"category_state.html", []string{"3333333333", "4444444444"}},
+		{"category_state_malformed.html", []string{"1111111111", "2222222222"}},
+		{"category.html", []string{"1111111111", "2222222222"}},
+	}
+
+	for _, tt := range tests {
+		listings, err := p.ParseItemsFromHTML(readFixture(t, tt.fixture))
+		if err != nil {
+			t.Errorf("ParseItemsFromHTML(%s): %v", tt.fixture, err)
+			continue
+		}
+		if got := listingIDs(listings); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseItemsFromHTML(%s) listing IDs = %v, want %v", tt.fixture, got, tt.want)
+		}
+	}
+}
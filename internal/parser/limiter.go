@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Limiter spaces out the requests made by one or more Parsers
+type Limiter interface {
+	// Wait blocks until the next request may be sent. It returns ctx.Err()
+	// if the context is done before then.
+	Wait(ctx context.Context) error
+}
+
+// NewLimiter returns a Limiter that spaces requests at least interval apart across
+// all goroutines and Parsers using it
+func NewLimiter(interval time.Duration) Limiter {
+	return &rateLimiter{interval: interval}
+}
+
+// rateLimiter spaces requests at least interval apart across goroutines
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+// Wait blocks until the next request slot. Slots are reserved under the lock,
+// so concurrent callers are spaced out instead of all waking up at once.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	next := l.last.Add(l.interval)
+	if next.Before(now) {
+		next = now
+	}
+	l.last = next
+	l.mu.Unlock()
+
+	return waitUntil(ctx, next)
+}
+
+// waitUntil blocks until the reserved slot at next, returning ctx.Err() if the
+// context is done before then
+func waitUntil(ctx context.Context, next time.Time) error {
+	sleepTime := time.Until(next)
+	if sleepTime <= 0 {
+		return nil
+	}
+
+	log.Printf("Rate limiting: Waiting %v before next request", sleepTime)
+	return sleepContext(ctx, sleepTime)
+}
+
+// LimiterFeedback is implemented by Limiters that adapt their pace to how Avito
+// responds. The Parser reports every response it gets from Avito to its Limiter
+// when the Limiter implements it.
+type LimiterFeedback interface {
+	// Success is called for a response that was neither rate limited nor blocked
+	Success()
+	// Throttled is called for a 429 response or Avito's anti-bot page
+	Throttled()
+}
+
+// adaptiveSuccessStreak is the number of successful responses in a row after which
+// an AdaptiveLimiter shortens its interval by one step
+const adaptiveSuccessStreak = 5
+
+// AdaptiveLimiter is a Limiter whose interval between requests follows Avito's
+// responses: each 429 or anti-bot page lengthens it by step, up to ceiling, and
+// every streak of successful responses shortens it by step, down to floor. It
+// starts at floor. Like the limiter returned by NewLimiter it can be shared by
+// several Parsers.
+type AdaptiveLimiter struct {
+	floor, ceiling, step time.Duration
+
+	mu       sync.Mutex
+	interval time.Duration
+	streak   int
+	last     time.Time
+}
+
+// NewAdaptiveLimiter returns an AdaptiveLimiter moving between floor and ceiling
+// in increments of step. A ceiling below floor is raised to floor.
+func NewAdaptiveLimiter(floor, ceiling, step time.Duration) *AdaptiveLimiter {
+	return &AdaptiveLimiter{
+		floor:    floor,
+		ceiling:  max(ceiling, floor),
+		step:     step,
+		interval: floor,
+	}
+}
+
+// Wait blocks until the next request slot at the current interval
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	next := l.last.Add(l.interval)
+	if next.Before(now) {
+		next = now
+	}
+	l.last = next
+	l.mu.Unlock()
+
+	return waitUntil(ctx, next)
+}
+
+// Interval returns the current interval between requests
+func (l *AdaptiveLimiter) Interval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.interval
+}
+
+// Success implements LimiterFeedback, shortening the interval by step after
+// a streak of successful responses
+func (l *AdaptiveLimiter) Success() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.streak++
+	if l.streak < adaptiveSuccessStreak {
+		return
+	}
+	l.streak = 0
+	if l.interval > l.floor {
+		l.interval = max(l.interval-l.step, l.floor)
+		log.Printf("Rate limiting: interval lowered to %v", l.interval)
+	}
+}
+
+// Throttled implements LimiterFeedback, lengthening the interval by step
+func (l *AdaptiveLimiter) Throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.streak = 0
+	if l.interval < l.ceiling {
+		l.interval = min(l.interval+l.step, l.ceiling)
+		log.Printf("Rate limiting: interval raised to %v", l.interval)
+	}
+}
+
+// waitForLimiter holds every request of c, retries included, until its turn on the
+// Parser's Limiter. It's the one place requests wait on the Limiter, so callers
+// don't wait before visiting a page.
+func (p *Parser) waitForLimiter(ctx context.Context, c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		if err := p.waitForRateLimit(ctx, r.URL.String()); err != nil {
+			r.Abort()
+		}
+	})
+}
+
+// reportToLimiter tells a Limiter implementing LimiterFeedback how Avito answered
+// the requests of c. Responses served from the cache aren't reported.
+func (p *Parser) reportToLimiter(c *colly.Collector) {
+	feedback, ok := p.limiter.(LimiterFeedback)
+	if !ok {
+		return
+	}
+
+	report := func(r *colly.Response) {
+		if r.Headers != nil && r.Headers.Get(cacheHeader) != "" {
+			return
+		}
+		switch {
+		case r.StatusCode == http.StatusTooManyRequests || isBlockPage(r.Body):
+			feedback.Throttled()
+		case r.StatusCode >= 200 && r.StatusCode < 400:
+			feedback.Success()
+		}
+	}
+
+	c.OnResponse(report)
+	c.OnError(func(r *colly.Response, _ error) {
+		report(r)
+	})
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterSpacesConcurrentWaits(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	limiter := NewLimiter(interval)
+
+	var mu sync.Mutex
+	var times []time.Time
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Errorf("Wait: %v", err)
+				return
+			}
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// The n-th request can't go before n intervals have passed. Times are taken after
+	// Wait returns, so a descheduled goroutine only makes its request look later and
+	// the gaps between neighbours aren't reliable.
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i, requested := range times {
+		// Timers may fire a little early, never by much
+		if elapsed, want := requested.Sub(start), time.Duration(i)*interval; elapsed < want-5*time.Millisecond {
+			t.Errorf("request %d went %v after the first Wait, want at least %v", i, elapsed, want)
+		}
+	}
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	limiter := NewLimiter(time.Hour)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait on a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestConcurrentGetListings(t *testing.T) {
+	const calls = 4
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{MinDelay: 5 * time.Millisecond})
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+			if err != nil {
+				t.Errorf("GetListings: %v", err)
+				return
+			}
+			if len(listings) != 2 {
+				t.Errorf("got %d listings, want 2", len(listings))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits := srv.Hits("/moskva/telefony"); hits != calls {
+		t.Errorf("category page was requested %d times, want %d", hits, calls)
+	}
+}
+
+// countingLimiter is a Limiter that doesn't wait but counts the waits
+type countingLimiter struct {
+	mu    sync.Mutex
+	waits int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waits++
+	return ctx.Err()
+}
+
+func (l *countingLimiter) Waits() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waits
+}
+
+func TestEveryRequestWaitsOnceOnLimiter(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	limiter := &countingLimiter{}
+	p := newFixtureParser(t, srv, ParserOptions{Limiter: limiter, Concurrency: 2})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !slices.Equal(got, want) {
+		t.Errorf("listings = %v, want %v in page order", got, want)
+	}
+
+	// The category page and both listing pages
+	if hits := srv.TotalHits(); hits != 3 {
+		t.Errorf("server got %d requests, want 3", hits)
+	}
+	if waits := limiter.Waits(); waits != srv.TotalHits() {
+		t.Errorf("limiter was waited on %d times for %d requests", waits, srv.TotalHits())
+	}
+}
+
+func TestDefaultDelayStaysWithinMaxDelay(t *testing.T) {
+	opts := DefaultParserOptions()
+	opts.MinDelay, opts.MaxDelay = 3*time.Second, 5*time.Second
+	delays := newDefaultDelayStrategy(opts)
+
+	// The Limiter spaces requests MinDelay apart, so the pause after a request is
+	// what's left up to MaxDelay
+	for i := 0; i < 100; i++ {
+		if d := delays.Delay(context.Background(), 0); d < 0 || d > 2*time.Second {
+			t.Fatalf("Delay = %v, want between 0 and %v", d, 2*time.Second)
+		}
+	}
+
+	opts.MaxDelay = opts.MinDelay
+	if d := newDefaultDelayStrategy(opts).Delay(context.Background(), 0); d != 0 {
+		t.Errorf("Delay with MaxDelay equal to MinDelay = %v, want 0", d)
+	}
+}
+
+// recordingLimiter wraps a Limiter, recording when each wait ends
+type recordingLimiter struct {
+	Limiter
+
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (l *recordingLimiter) Wait(ctx context.Context) error {
+	err := l.Limiter.Wait(ctx)
+	l.mu.Lock()
+	l.times = append(l.times, time.Now())
+	l.mu.Unlock()
+	return err
+}
+
+func TestLimiterSharedBetweenParsers(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	limiter := &recordingLimiter{Limiter: NewLimiter(interval)}
+
+	// Two parsers scraping different sites at the same time, as for two categories
+	phones := newFixtureServer(t, phoneRoutes)
+	laptops := newFixtureServer(t, map[string]string{"/moskva/noutbuki": "category.html"})
+	parsers := []*Parser{
+		newFixtureParser(t, phones, ParserOptions{Limiter: limiter}),
+		newFixtureParser(t, laptops, ParserOptions{Limiter: limiter, SkipDetails: true}),
+	}
+	categoryURLs := []string{phones.URL + "/moskva/telefony", laptops.URL + "/moskva/noutbuki"}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i, p := range parsers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.GetListings(categoryURLs[i], 0); err != nil {
+				t.Errorf("GetListings(%s): %v", categoryURLs[i], err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	requests := phones.TotalHits() + laptops.TotalHits()
+	if requests != 4 || len(limiter.times) != requests {
+		t.Fatalf("limiter was waited on %d times for %d requests, want 4 of each", len(limiter.times), requests)
+	}
+
+	// Requests of both parsers are spaced out together, so the n-th can't go
+	// before n intervals have passed
+	sort.Slice(limiter.times, func(i, j int) bool { return limiter.times[i].Before(limiter.times[j]) })
+	for i, requested := range limiter.times {
+		if elapsed, want := requested.Sub(start), time.Duration(i)*interval; elapsed < want-5*time.Millisecond {
+			t.Errorf("request %d went %v after the scrapes started, want at least %v", i, elapsed, want)
+		}
+	}
+}
@@ -1,12 +1,21 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	"log"
+	"math"
+	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
@@ -14,131 +23,460 @@ import (
 )
 
 var (
-	// Regex to extract item ID from URL or data attributes
-	itemIDRegex = regexp.MustCompile(`_(\d+)$|/(\d+)$`)
-	// Regex to extract price value
-	priceRegex = regexp.MustCompile(`[\d\s,.]+`)
+	// Regex to extract item ID from a URL path like "/moskva/telefony/iphone_15_4567891234" or "/item/4567891234"
+	itemIDRegex = regexp.MustCompile(`[_/](\d+)$`)
+	// Regex to extract price value, with digit groups separated by spaces, non-breaking spaces, commas or dots
+	priceRegex = regexp.MustCompile(`\d[\d\s\x{00a0}\x{202f},.]*`)
+	// Regex to detect price ranges like "1 000 – 2 000 ₽" or "от 1 000 до 2 000 ₽"
+	priceRangeRegex = regexp.MustCompile(`(\d[\d\s\x{00a0}\x{202f},.]*?)[\s\x{00a0}\x{202f}]*(?:[–—-]|до)[\s\x{00a0}\x{202f}]*(\d[\d\s\x{00a0}\x{202f},.]*)`)
 	// Regex to detect if the URL is a catalog page
 	catalogRegex = regexp.MustCompile(`/catalog/`)
+	// Regex to match countdown timers like "2 дня 03:15:00" or "14:05"
+	countdownRegex = regexp.MustCompile(`(?:(\d+)\s*д\S*\s+)?(\d{1,2}):(\d{2})(?::(\d{2}))?`)
+	// Regex to extract the seller's listing count, e.g. "Ещё 12 объявлений продавца" or "1 234 объявления"
+	sellerItemsRegex = regexp.MustCompile(`(\d[\d\s\x{00a0}\x{202f}]*)[\s\x{00a0}\x{202f}]*объявлени`)
+	// Regexes to extract counters like "1 234 просмотра" or "В избранном у 12 пользователей"
+	viewsRegex     = regexp.MustCompile(`(\d[\d\s\x{00a0}\x{202f}]*)\s*просмотр`)
+	favoritesRegex = regexp.MustCompile(`(?i)(?:в избранном у|добавили в избранное)\s*(\d[\d\s\x{00a0}\x{202f}]*)|(\d[\d\s\x{00a0}\x{202f}]*)\s*(?:человек\S*\s+)?в избранном`)
+	// Regex to match dates like "5 марта", "15 дек. 2023" or "1 января 2024 г."
+	textDateRegex = regexp.MustCompile(`(\d{1,2})\s+([а-яё]+\.?)(?:\s+(\d{4}))?`)
+	// Regex to match numeric dates like "05.03.2024" or "05.03.24"
+	numericDateRegex = regexp.MustCompile(`(\d{1,2})\.(\d{1,2})\.(\d{4}|\d{2})\b`)
+	// Regex to match relative dates like "5 минут назад", "час назад" or "2 недели назад"
+	relativeDateRegex = regexp.MustCompile(`(?:(\d+)\s+)?(минут|час|дн|день|недел)[а-яё]*\s+назад`)
+	// Regex to match numeric parameter values like "2", "54,5 м²" or "120 000 км"
+	attributeNumberRegex = regexp.MustCompile(`^(-?\d[\d ]*(?:[.,]\d+)?)(?: ?([^\d]{1,10}))?$`)
+	// Regex to extract the area from real estate titles like "2-к. квартира, 45,5 м², 5/9 эт."
+	titleAreaRegex = regexp.MustCompile(`(\d+(?:[.,]\d+)?)[\s\x{00a0}]*(?:м²|м2|кв\.?\s*м)`)
+	// Regex to find a condition stated in a title, e.g. "iPhone 13 новый" or "Диван б/у"
+	titleConditionRegex = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(нов(?:ый|ая|ое|ые)|б/у)(?:$|[^\p{L}])`)
+	// Regex to detect the Avito Delivery badge on listing cards, e.g. "Авито Доставка" or "С доставкой"
+	deliveryBadgeRegex = regexp.MustCompile(`(?i)авито\s*доставк|доставка\s+авито|^\s*с\s+доставкой`)
+	// Regexes to detect promoted listing cards by their class names or the badges Avito puts on them
+	promotedClassRegex = regexp.MustCompile(`(?i)(?:^|[\s_-])(?:vip|premium|highlight(?:ed)?|promoted)(?:$|[\s_-])`)
+	promotedBadgeRegex = regexp.MustCompile(`(?i)^(?:vip|премиум|реклама|продвигается|выделено)$`)
+	// Regex to detect the buy-with-delivery button on listing pages
+	deliveryButtonRegex = regexp.MustCompile(`(?i)купить\s+с\s+доставкой|заказать\s+с\s+доставкой`)
+	// Regex to detect the notice shown on pages of sold, closed or removed listings
+	closedListingRegex = regexp.MustCompile(`(?i)снят[оа]?\s+с\s+публикации|товар\s+продан|объявление\s+(?:удалено|закрыто|не\s+активно)|срок\s+размещения.{0,30}истёк|срок\s+размещения.{0,30}истек`)
+	// Regex to match a category slug at the start of a URL path segment, before any encoded filters
+	categorySlugRegex = regexp.MustCompile(`^[a-z0-9_]+`)
+	// Regex to match a time of day like "10:30"
+	timeOfDayRegex = regexp.MustCompile(`(\d{1,2}):(\d{2})`)
+
+	// Elements other than <p> that start a new line when rendered
+	blockElements = map[string]bool{
+		"div": true, "li": true, "ul": true, "ol": true, "blockquote": true, "tr": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	}
 
-	// Rate limiting
-	minRequestInterval = 3 * time.Second
-	lastRequestTime    = time.Now().Add(-minRequestInterval)
-	maxRetries         = 3
+	// Month lookup by the first three letters, which are unique across all forms
+	russianMonths = map[string]time.Month{
+		"янв": time.January,
+		"фев": time.February,
+		"мар": time.March,
+		"апр": time.April,
+		"мая": time.May,
+		"май": time.May,
+		"июн": time.June,
+		"июл": time.July,
+		"авг": time.August,
+		"сен": time.September,
+		"окт": time.October,
+		"ноя": time.November,
+		"дек": time.December,
+	}
+
+	// Regexes to extract coordinates from the JSON state or structured data of a listing page
+	coordsRegex = regexp.MustCompile(`"coords"\s*:\s*\{[^{}]*?"lat"\s*:\s*"?(-?\d+(?:\.\d+)?)"?\s*,\s*"lng"\s*:\s*"?(-?\d+(?:\.\d+)?)`)
+	geoRegex    = regexp.MustCompile(`"latitude"\s*:\s*"?(-?\d+(?:\.\d+)?)"?\s*,\s*"longitude"\s*:\s*"?(-?\d+(?:\.\d+)?)`)
+
+	// Selectors for the content blocks GetListingDetails knows how to parse
+	detailSelectors = []string{
+		"div[data-marker='item-description']",
+		"div.item-description",
+		"div.gallery-img-wrapper img",
+		"div.photo-slider-image-wrapper img",
+		"div[data-marker='item-address']",
+		"div.item-address",
+		"span.price-value",
+		"div.item-price",
+		"*[data-marker='item-price']",
+		"div[data-marker='item-date']",
+		"div.item-date",
+		"div.item-params",
+		"ul.item-params-list li",
+	}
 )
 
-// waitForRateLimit ensures we don't send requests too quickly
-func waitForRateLimit() {
-	elapsed := time.Since(lastRequestTime)
-	if elapsed < minRequestInterval {
-		sleepTime := minRequestInterval - elapsed
-		log.Printf("Rate limiting: Waiting %v before next request", sleepTime)
-		time.Sleep(sleepTime)
+// Renderer fetches fully rendered HTML for pages whose content is built client-side,
+// for example by driving a headless browser
+type Renderer interface {
+	RenderHTML(url string) (string, error)
+}
+
+// PriceParser parses a category-specific price format.
+// It returns false when the text is not in a format it handles.
+type PriceParser func(priceText string) (models.Price, bool)
+
+// DefaultPriceParsers returns the built-in price parsers tried before the default
+// price parser, currently ParseStartingPrice
+func DefaultPriceParsers() []PriceParser {
+	return []PriceParser{ParseStartingPrice}
+}
+
+// waitForRateLimit waits for the turn of a request to rawURL on the Parser's Limiter.
+// URLs that will be served from the cache don't wait. It returns ctx.Err() if the
+// context is done before the wait is over.
+func (p *Parser) waitForRateLimit(ctx context.Context, rawURL string) error {
+	if p.isCached(rawURL) {
+		return nil
+	}
+	return p.limiter.Wait(ctx)
+}
+
+// sleepContext pauses for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	lastRequestTime = time.Now()
 }
 
-// GetListings fetches listings from a given category URL
+// GetListings fetches listings from a given category URL using the default parser
 func GetListings(categoryURL string, limit int) ([]models.Listing, error) {
+	return defaultParser.GetListings(categoryURL, limit)
+}
+
+// GetListingsContext fetches listings from a given category URL using the default parser,
+// aborting when the context is cancelled or its deadline expires
+func GetListingsContext(ctx context.Context, categoryURL string, limit int) ([]models.Listing, error) {
+	return defaultParser.GetListingsContext(ctx, categoryURL, limit)
+}
+
+// GetListings fetches listings from a given category URL.
+//
+// If scraping fails part way, for example because the context is cancelled or the
+// request budget runs out, the listings collected before the failure are returned
+// together with the error. A non-nil error therefore doesn't mean the slice is empty;
+// callers that can use partial results should check both. A results page after the
+// first that can't be fetched only ends pagination.
+func (p *Parser) GetListings(categoryURL string, limit int) ([]models.Listing, error) {
+	return p.GetListingsContext(context.Background(), categoryURL, limit)
+}
+
+// GetListingsContext fetches listings from a given category URL,
+// aborting when the context is cancelled or its deadline expires.
+// Like GetListings, it returns the listings collected so far alongside any error.
+func (p *Parser) GetListingsContext(ctx context.Context, categoryURL string, limit int) ([]models.Listing, error) {
+	if categoryURL == "" {
+		return nil, fmt.Errorf("category: %w", ErrEmptyURL)
+	}
+	categoryURL = p.regionalURL(categoryURL)
+	ctx = p.withRequestBudget(ctx)
+	ctx, cancel := p.withMaxDuration(ctx)
+	defer cancel()
+
+	started := time.Now()
+	listings, err := p.getListings(ctx, categoryURL, limit)
+	p.countScrape(started, len(listings))
+	return listings, deadlineError(ctx, err)
+}
+
+// getListings does the work of GetListingsContext within the traversal's limits
+func (p *Parser) getListings(ctx context.Context, categoryURL string, limit int) ([]models.Listing, error) {
 	// Check if this is a catalog URL and handle it differently if needed
 	if catalogRegex.MatchString(categoryURL) {
-		return handleCatalogPage(categoryURL, limit)
+		return p.handleCatalogPage(ctx, categoryURL, limit)
+	}
+	if shopRegex.MatchString(categoryURL) {
+		return p.handleShopPage(ctx, categoryURL, limit)
 	}
 
+	listings, err := p.collectListings(ctx, categoryURL, limit)
+	if err != nil {
+		return listings, err
+	}
+
+	// If we found any listings, try to fetch more details for each
+	if len(listings) > 0 && !p.opts.SkipDetails {
+		enrichedListings, err := p.enrichListings(ctx, listings)
+		return p.applyFilter(dedupeListings(enrichedListings)), err
+	}
+
+	return listings, nil
+}
+
+// collectListings gathers listing cards from a category, following pagination
+// until limit listings are found, without visiting the listing pages
+func (p *Parser) collectListings(ctx context.Context, categoryURL string, limit int) ([]models.Listing, error) {
+	listings, _, err := p.collectListingPages(ctx, categoryURL, limit)
+	return listings, err
+}
+
+// collectListingPages works like collectListings and also returns the URLs of the
+// results pages it fetched
+func (p *Parser) collectListingPages(ctx context.Context, categoryURL string, limit int) ([]models.Listing, []string, error) {
+	return p.collectListingPagesUntil(ctx, categoryURL, limit, nil)
+}
+
+// collectListingPagesUntil works like collectListingPages and also stops at the first
+// listing for which stop returns true, leaving it and the rest of its page out. Stopping
+// this way before any listing was collected isn't an error.
+func (p *Parser) collectListingPagesUntil(ctx context.Context, categoryURL string, limit int, stop func(models.Listing) bool) ([]models.Listing, []string, error) {
 	var listings []models.Listing
+	var pageURLs []string
+	seen := make(map[string]bool)
+	emptyPages := 0
+	stopped := false
+
+	// Pages that load more items through the "показать ещё" endpoint are followed
+	// batch by batch; the rest are paginated
+	startPage := max(p.opts.StartPage, 1)
+	pageURL, batch := p.withSellerTypeParam(categoryURL), false
+	if startPage > 1 {
+		pageURL = pageURLFor(pageURL, startPage)
+	}
+	for page := startPage; ; page++ {
+		pageURLs = append(pageURLs, pageURL)
+
+		var pageListings []models.Listing
+		var next pageLink
+		var err error
+		if batch {
+			next.batch = true
+			pageListings, next.url, err = p.scrapeListingsBatch(ctx, pageURL, categoryURL)
+		} else {
+			pageListings, next, err = p.scrapeListingsPage(ctx, pageURL, categoryURL)
+		}
+		if err != nil {
+			if page == startPage || ctx.Err() != nil {
+				return listings, pageURLs, err
+			}
+			log.Printf("Error fetching page %d, stopping pagination: %v", page, err)
+			break
+		}
 
-	c := colly.NewCollector(
-		colly.AllowedDomains("www.avito.ru", "avito.ru"),
-		colly.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		colly.MaxDepth(1),
-	)
+		// Merge the page into the results, skipping listings seen on earlier pages
+		// and those rejected by the Filter option
+		added := 0
+		for _, listing := range pageListings {
+			if limit > 0 && len(listings) >= limit {
+				break
+			}
+			if stop != nil && stop(listing) {
+				stopped = true
+				break
+			}
+
+			key := listingKey(listing)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			added++
+
+			if p.keepListing(listing) {
+				listings = append(listings, listing)
+			}
+		}
+
+		if stopped {
+			log.Printf("Reached the stop condition on page %d, stopping pagination", page)
+			break
+		}
+		if len(pageListings) == 0 && page == startPage && startPage > 1 {
+			return nil, pageURLs, fmt.Errorf("%w: page %d of %s has no listings", ErrOffsetOutOfRange, startPage, categoryURL)
+		}
+		if len(pageListings) == 0 || (limit > 0 && len(listings) >= limit) {
+			break
+		}
+
+		// Avito sometimes repeats the last page; stop once pages stop contributing
+		if added == 0 {
+			emptyPages++
+			if emptyPages >= p.opts.MaxEmptyPages {
+				log.Printf("No new listings on the last %d page(s), stopping pagination", emptyPages)
+				break
+			}
+		} else {
+			emptyPages = 0
+		}
 
-	// Set up retry mechanism
-	c.SetRequestTimeout(30 * time.Second)
+		if page-startPage+1 >= p.opts.MaxPages {
+			log.Printf("Reached the maximum of %d pages", p.opts.MaxPages)
+			break
+		}
+
+		// The batch endpoint takes the same "p" parameter as results pages
+		if next.url == "" {
+			next.url = pageURLFor(pageURL, page+1)
+		}
+		pageURL, batch = next.url, next.batch
+	}
+
+	// Fall back to a fully rendered page when the static HTML yielded nothing
+	if len(listings) == 0 && !stopped && p.opts.Renderer != nil {
+		var err error
+		listings, err = p.renderListings(categoryURL, limit)
+		if err != nil {
+			return nil, pageURLs, err
+		}
+	}
+
+	if len(listings) == 0 && !stopped {
+		return nil, pageURLs, fmt.Errorf("%w at %s", ErrNoListingsFound, categoryURL)
+	}
+
+	return listings, pageURLs, nil
+}
+
+// enrichListings fetches the details of listings found on a category page using up to
+// Concurrency workers. Requests still share the Parser's rate limiter, and the results
+// keep the original order. Listings whose details can't be fetched are kept as they are,
+// as are those not reached before the context is done; ctx.Err() is returned with them.
+func (p *Parser) enrichListings(ctx context.Context, listings []models.Listing) ([]models.Listing, error) {
+	enriched := append([]models.Listing(nil), listings...)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.opts.Concurrency, len(listings)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				log.Printf("Fetching details for listing %d of %d", i+1, len(listings))
+
+				result, err := p.enrichListing(ctx, listings[i])
+				if err != nil && ctx.Err() == nil && !errors.Is(err, ErrRequestBudgetExhausted) {
+					log.Printf("Error fetching details for listing %s: %v", listings[i].ID, err)
+				}
+				enriched[i] = result
+			}
+		}()
+	}
+
+feed:
+	for i := range listings {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return enriched, ctx.Err()
+}
+
+// enrichListing fetches the details of a listing found on a category page.
+// On error the listing is returned unchanged together with the error.
+func (p *Parser) enrichListing(ctx context.Context, listing models.Listing) (models.Listing, error) {
+	// Only fetch details if we have a URL and they were asked for
+	if listing.URL == "" || p.opts.SkipDetails {
+		return listing, nil
+	}
+
+	// Listings fetched by an interrupted earlier run are taken from the checkpoint
+	if done, ok := p.checkpointed(listing.URL); ok {
+		return done, nil
+	}
+
+	if err := checkRequestBudget(ctx, listing.URL); err != nil {
+		return listing, err
+	}
+
+	enriched, err := p.GetListingDetailsContext(ctx, listing)
+	if err != nil {
+		return listing, err
+	}
+
+	p.recordCheckpoint(enriched)
+	return enriched, nil
+}
+
+// scrapeListingsPage collects listing cards from a single category page.
+// It also returns where the next results are: the "показать ещё" endpoint when the
+// page has one, otherwise the next page when the page links to one.
+func (p *Parser) scrapeListingsPage(ctx context.Context, pageURL, categoryURL string) ([]models.Listing, pageLink, error) {
+	var listings []models.Listing
+	var nextURL, moreURL string
+	var redirected, pageGone bool
+	requestedURL, _ := url.Parse(pageURL)
+
+	c := p.newCollector(ctx)
 
 	// Randomize delay between requests
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
-		RandomDelay: 5 * time.Second,
-		Delay:       3 * time.Second,
-	})
+	c.Limit(p.limitRule())
 
 	// Add debugging callbacks
 	c.OnRequest(func(r *colly.Request) {
 		log.Println("Visiting", r.URL)
-		// Respect rate limiting
-		waitForRateLimit()
 	})
 
 	c.OnError(func(r *colly.Response, err error) {
 		log.Println("Error:", err)
-		if r.StatusCode == 429 {
-			log.Println("Rate limited, waiting longer before retry")
-			time.Sleep(10 * time.Second)
-
-			// Try to retry with a different user agent
-			retries := 0
-			for retries < maxRetries {
-				retries++
-				log.Printf("Retry %d of %d...", retries, maxRetries)
-				time.Sleep(5 * time.Second * time.Duration(retries))
+	})
 
-				// Alternate user agents
-				userAgents := []string{
-					"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-					"Mozilla/5.0 (iPhone; CPU iPhone OS 13_2_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0.3 Mobile/15E148 Safari/604.1",
-					"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/92.0.4515.107 Safari/537.36",
-				}
+	c.OnResponse(func(r *colly.Response) {
+		log.Printf("Received response from listings page, size: %d bytes\n", len(r.Body))
 
-				r.Request.Headers.Set("User-Agent", userAgents[retries%len(userAgents)])
+		// The links on the page Avito redirected to aren't listings of the category
+		if redirectedAway(pageURL, r.Request.URL) {
+			log.Println("Redirected to", r.Request.URL)
+			redirected = true
+			return
+		}
+		// Avito sends requests for pages past the last one back to the first page
+		if pageNumber(r.Request.URL) != pageNumber(requestedURL) {
+			log.Println("Redirected to", r.Request.URL)
+			pageGone = true
+			return
+		}
 
-				// Retry the request
-				err = c.Request("GET", r.Request.URL.String(), nil, r.Request.Ctx, nil)
-				if err == nil {
-					return
-				}
+		if link := loadMoreURL(r.Body); link != "" && moreURL == "" {
+			moreURL = r.Request.AbsoluteURL(link)
+		}
 
-				log.Printf("Retry %d failed: %v", retries, err)
-			}
+		// Prefer the JSON state embedded in the page over CSS selectors
+		embedded, err := p.parseInitialData(string(r.Body))
+		if err != nil {
+			return
 		}
-	})
 
-	c.OnResponse(func(r *colly.Response) {
-		log.Printf("Received response from listings page, size: %d bytes\n", len(r.Body))
+		log.Printf("Found %d listings in embedded page data\n", len(embedded))
+		for _, listing := range embedded {
+			listing.CategoryURL = categoryURL
+			p.applyExtractionLimits(&listing)
+			listings = append(listings, listing)
+		}
 	})
 
 	// Parse listings from search results
 	c.OnHTML("div[data-marker='catalog-serp']", func(e *colly.HTMLElement) {
-		log.Println("Found listings container")
-
-		// Look for item cards with different possible selectors
-		itemSelectors := []string{
-			"div[data-marker='item']",
-			"div[data-marker='item-card']",
-			"div.item",
-			"div.item-card",
-			"div.iva-item-root",
+		if len(listings) > 0 {
+			return // Skip if the embedded data already provided the listings
 		}
 
-		for _, selector := range itemSelectors {
-			count := 0
-			e.ForEach(selector, func(_ int, item *colly.HTMLElement) {
-				if limit > 0 && count >= limit {
-					return
-				}
+		log.Println("Found listings container")
+		listings = p.parseCards(e, categoryURL)
+	})
 
-				listing := parseListing(item)
-				if listing.ID != "" && listing.Title != "" {
-					listing.CategoryURL = categoryURL
-					listings = append(listings, listing)
-					count++
-				}
-			})
+	// Find the link to the next page
+	c.OnHTML("a[data-marker='pagination-button/nextPage'][href]", func(e *colly.HTMLElement) {
+		nextURL = e.Request.AbsoluteURL(e.Attr("href"))
+	})
 
-			if count > 0 {
-				log.Printf("Found %d listings using selector: %s\n", count, selector)
-				break
-			}
+	// Find the "показать ещё" button of pages that load more items as JSON
+	c.OnHTML(loadMoreSelector, func(e *colly.HTMLElement) {
+		if moreURL == "" {
+			moreURL = loadMoreButtonURL(e)
 		}
 	})
 
@@ -148,15 +486,16 @@ func GetListings(categoryURL string, limit int) ([]models.Listing, error) {
 			return // Skip if we already found listings
 		}
 
+		// Pages whose results container changed may still have cards the selectors match
+		if listings = p.parseCards(e, categoryURL); len(listings) > 0 {
+			return
+		}
+
 		// Try to find any element that might be a listing
 		log.Println("Trying alternative method to find listings")
 
 		count := 0
 		e.DOM.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
-			if limit > 0 && count >= limit {
-				return
-			}
-
 			href, exists := s.Attr("href")
 			if !exists {
 				return
@@ -165,32 +504,26 @@ func GetListings(categoryURL string, limit int) ([]models.Listing, error) {
 			// Check if this link points to an item page
 			if strings.Contains(href, "/item/") {
 				// This might be a listing
-				title := strings.TrimSpace(s.Text())
+				title := cleanText(s.Text())
 				if title == "" {
 					// Try to find title in child elements
-					title = strings.TrimSpace(s.Find("h3, h4, h2, div.title, div.snippet-title").First().Text())
+					title = cleanText(s.Find("h3, h4, h2, div.title, div.snippet-title").First().Text())
 				}
 
 				if title != "" {
 					listing := models.Listing{
-						Title: title,
-						URL:   normalizeURL(href),
+						Title:    title,
+						URL:      normalizeLink(p.opts.BaseURL, href),
+						IsActive: true,
 					}
 
 					// Try to extract ID from URL
-					matches := itemIDRegex.FindStringSubmatch(href)
-					if len(matches) > 1 {
-						if matches[1] != "" {
-							listing.ID = matches[1]
-						} else if matches[2] != "" {
-							listing.ID = matches[2]
-						}
-					}
+					listing.ID = extractItemID(href)
 
 					// Look for price near this element
-					priceText := strings.TrimSpace(s.Find("span.price, div.price, *[data-marker='item-price']").First().Text())
+					priceText := cleanText(s.Find("span.price, div.price, *[data-marker='item-price']").First().Text())
 					if priceText != "" {
-						listing.Price = parsePrice(priceText)
+						listing.Price = p.parsePrice(priceText)
 					}
 
 					listing.CategoryURL = categoryURL
@@ -203,104 +536,138 @@ func GetListings(categoryURL string, limit int) ([]models.Listing, error) {
 		log.Printf("Found %d listings using alternative method\n", count)
 	})
 
-	// Wait for rate limiting before starting
-	waitForRateLimit()
+	p.trackRequestStats(ctx, c)
 
-	err := c.Visit(categoryURL)
+	err := p.visit(ctx, c, pageURL)
 	if err != nil {
-		return nil, fmt.Errorf("error visiting category page: %w", err)
+		return nil, pageLink{}, fmt.Errorf("error visiting category page: %w", err)
 	}
 
 	c.Wait()
 
-	// If we found any listings, try to fetch more details for each
-	if len(listings) > 0 {
-		enrichedListings := make([]models.Listing, 0, len(listings))
-		for i, listing := range listings {
-			// Only fetch details if we have a URL
-			if listing.URL != "" {
-				log.Printf("Fetching details for listing %d of %d", i+1, len(listings))
+	if err := ctx.Err(); err != nil {
+		return nil, pageLink{}, err
+	}
+	if redirected {
+		return nil, pageLink{}, fmt.Errorf("%w: %s redirects elsewhere", ErrCategoryNotFound, pageURL)
+	}
+	if pageGone {
+		return nil, pageLink{}, fmt.Errorf("%w: %s redirects to another page", ErrOffsetOutOfRange, pageURL)
+	}
 
-				// Respect rate limiting for each detail request
-				waitForRateLimit()
+	if moreURL != "" {
+		return listings, pageLink{url: moreURL, batch: true}, nil
+	}
+	return listings, pageLink{url: nextURL}, nil
+}
 
-				// Fetch detailed information for this listing
-				enriched, err := GetListingDetails(listing)
-				if err != nil {
-					log.Printf("Error fetching details for listing %s: %v", listing.ID, err)
-					enrichedListings = append(enrichedListings, listing)
-				} else {
-					enrichedListings = append(enrichedListings, enriched)
-				}
-			} else {
-				enrichedListings = append(enrichedListings, listing)
+// parseCards parses the listing cards within e using the first of the Items selectors
+// that matches any card with an ID and a title
+func (p *Parser) parseCards(e *colly.HTMLElement, categoryURL string) []models.Listing {
+	var listings []models.Listing
+	for _, selector := range p.opts.Selectors.Items {
+		e.ForEach(selector, func(_ int, item *colly.HTMLElement) {
+			listing := p.parseListing(item)
+			p.applyExtractionLimits(&listing)
+			if listing.ID != "" && listing.Title != "" {
+				listing.CategoryURL = categoryURL
+				listings = append(listings, listing)
 			}
+		})
+
+		if len(listings) > 0 {
+			log.Printf("Found %d listings using selector: %s\n", len(listings), selector)
+			break
 		}
-		return enrichedListings, nil
+	}
+	return listings
+}
+
+// pageURLFor returns the URL of the given results page using Avito's "p" parameter
+func pageURLFor(rawURL string, page int) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := parsedURL.Query()
+	query.Set("p", strconv.Itoa(page))
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
+
+// pageNumber returns the results page a URL is for from its "p" parameter, 1 when it has none
+func pageNumber(u *url.URL) int {
+	if u == nil {
+		return 1
+	}
+	if page, err := strconv.Atoi(u.Query().Get("p")); err == nil && page > 1 {
+		return page
+	}
+	return 1
+}
+
+// redirectedAway reports whether a request for pageURL was redirected to a shallower
+// path, such as the home page or a region's root Avito sends unknown categories to.
+// Redirects that keep the path depth, like a renamed category, are followed.
+func redirectedAway(pageURL string, finalURL *url.URL) bool {
+	requested, err := url.Parse(pageURL)
+	if err != nil || finalURL == nil {
+		return false
+	}
+
+	return pathDepth(finalURL.Path) < pathDepth(requested.Path)
+}
+
+// pathDepth returns the number of segments in a URL path
+func pathDepth(path string) int {
+	return len(strings.FieldsFunc(path, func(r rune) bool { return r == '/' }))
+}
+
+// renderListings fetches the category page through the Renderer and parses listings from the rendered HTML
+func (p *Parser) renderListings(categoryURL string, limit int) ([]models.Listing, error) {
+	log.Println("No listings found, rendering page with Renderer:", categoryURL)
+
+	htmlContent, err := p.opts.Renderer.RenderHTML(categoryURL)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering category page: %w", err)
+	}
+
+	listings, err := p.ParseItemsFromHTML(htmlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	listings = p.applyFilter(listings)
+	if limit > 0 && len(listings) > limit {
+		listings = listings[:limit]
+	}
+
+	for i := range listings {
+		listings[i].CategoryURL = categoryURL
 	}
 
 	return listings, nil
 }
 
-// handleCatalogPage handles the special case of catalog pages
-func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
+// handleCatalogPage handles the special case of catalog pages. On failure the listings
+// collected before the error are returned alongside it.
+func (p *Parser) handleCatalogPage(ctx context.Context, catalogURL string, limit int) ([]models.Listing, error) {
 	log.Println("Handling catalog page:", catalogURL)
 	var listings []models.Listing
 	var itemURLs []string
 
-	c := colly.NewCollector(
-		colly.AllowedDomains("www.avito.ru", "avito.ru"),
-		colly.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		colly.MaxDepth(1),
-	)
-
-	// Set up retry mechanism
-	c.SetRequestTimeout(30 * time.Second)
+	c := p.newCollector(ctx)
 
 	// Rate limiting
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
-		RandomDelay: 5 * time.Second,
-		Delay:       3 * time.Second,
-	})
+	c.Limit(p.limitRule())
 
 	c.OnRequest(func(r *colly.Request) {
 		log.Println("Visiting catalog:", r.URL)
-		// Respect rate limiting
-		waitForRateLimit()
 	})
 
 	c.OnError(func(r *colly.Response, err error) {
 		log.Println("Error:", err)
-		if r.StatusCode == 429 {
-			log.Println("Rate limited, waiting longer before retry")
-			time.Sleep(10 * time.Second)
-
-			// Try to retry with a different user agent
-			retries := 0
-			for retries < maxRetries {
-				retries++
-				log.Printf("Retry %d of %d...", retries, maxRetries)
-				time.Sleep(5 * time.Second * time.Duration(retries))
-
-				// Alternate user agents
-				userAgents := []string{
-					"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-					"Mozilla/5.0 (iPhone; CPU iPhone OS 13_2_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0.3 Mobile/15E148 Safari/604.1",
-					"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/92.0.4515.107 Safari/537.36",
-				}
-
-				r.Request.Headers.Set("User-Agent", userAgents[retries%len(userAgents)])
-
-				// Retry the request
-				err = c.Request("GET", r.Request.URL.String(), nil, r.Request.Ctx, nil)
-				if err == nil {
-					return
-				}
-
-				log.Printf("Retry %d failed: %v", retries, err)
-			}
-		}
 	})
 
 	c.OnResponse(func(r *colly.Response) {
@@ -311,15 +678,8 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 	c.OnHTML("div.items-items, div.catalog-items", func(e *colly.HTMLElement) {
 		log.Println("Found catalog items container")
 
-		// Try multiple selectors for items
-		itemSelectors := []string{
-			"div[data-item-id]",
-			"div.item-wrapper",
-			"div.catalog-item",
-			"div.item",
-		}
-
-		for _, selector := range itemSelectors {
+		// Try the configured selectors for items
+		for _, selector := range p.opts.Selectors.CatalogItems {
 			e.ForEach(selector, func(_ int, s *colly.HTMLElement) {
 				if limit > 0 && len(itemURLs) >= limit {
 					return
@@ -339,7 +699,7 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 				}
 
 				if href != "" {
-					href = normalizeURL(href)
+					href = normalizeLink(p.opts.BaseURL, href)
 					itemURLs = append(itemURLs, href)
 				}
 			})
@@ -359,7 +719,7 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 
 		href := e.ChildAttr("a[href]", "href")
 		if href != "" {
-			href = normalizeURL(href)
+			href = normalizeLink(p.opts.BaseURL, href)
 			itemURLs = append(itemURLs, href)
 		}
 	})
@@ -380,7 +740,7 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 
 			href, _ := s.Attr("href")
 			if strings.Contains(href, "/item/") {
-				href = normalizeURL(href)
+				href = normalizeLink(p.opts.BaseURL, href)
 				itemURLs = append(itemURLs, href)
 			}
 		})
@@ -395,7 +755,7 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 				href, _ := s.Attr("href")
 
 				// Skip external links and already processed ones
-				if !strings.HasPrefix(href, "/") && !strings.Contains(href, "avito.ru") {
+				if !strings.HasPrefix(href, "/") && !strings.HasPrefix(href, p.opts.BaseURL) && !strings.Contains(href, "avito.ru") {
 					return
 				}
 
@@ -409,7 +769,7 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 				}
 
 				// If we get here, this might be a subcategory or item
-				href = normalizeURL(href)
+				href = normalizeLink(p.opts.BaseURL, href)
 
 				// Skip the current URL
 				if href == catalogURL {
@@ -424,28 +784,47 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 		log.Printf("Found %d potential items or subcategories with fallback method\n", len(itemURLs))
 	})
 
-	// Wait for rate limiting before starting
-	waitForRateLimit()
+	p.trackRequestStats(ctx, c)
 
-	err := c.Visit(catalogURL)
+	err := p.visit(ctx, c, catalogURL)
 	if err != nil {
 		return nil, fmt.Errorf("error visiting catalog page: %w", err)
 	}
 
 	c.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Process found URLs (could be direct items or subcategories)
 	if len(itemURLs) > 0 {
 		log.Printf("Processing %d URLs from catalog\n", len(itemURLs))
+		processed := make(map[string]bool)
 		for i, url := range itemURLs {
 			if limit > 0 && len(listings) >= limit {
 				break
 			}
 
+			// Skip URLs linked from several places on the page
+			if processed[normalizeURL(url)] {
+				continue
+			}
+			processed[normalizeURL(url)] = true
+
 			log.Printf("Processing catalog URL %d of %d: %s\n", i+1, len(itemURLs), url)
 
-			// Respect rate limiting
-			waitForRateLimit()
+			if done, ok := p.checkpointed(url); ok {
+				if p.keepListing(done) {
+					listings = append(listings, done)
+				}
+				continue
+			}
+
+			if err := checkRequestBudget(ctx, url); err != nil {
+				log.Printf("Request budget exhausted, returning %d listings: %v", len(listings), err)
+				break
+			}
 
 			// Check if this is an item URL or potentially a subcategory
 			if strings.Contains(url, "/item/") {
@@ -453,33 +832,49 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 				listing := models.Listing{
 					URL:         url,
 					CategoryURL: catalogURL,
+					IsActive:    true,
 				}
 
 				// Try to extract ID from URL
-				matches := itemIDRegex.FindStringSubmatch(url)
-				if len(matches) > 1 {
-					if matches[1] != "" {
-						listing.ID = matches[1]
-					} else if matches[2] != "" {
-						listing.ID = matches[2]
+				listing.ID = extractItemID(url)
+
+				if p.opts.SkipDetails {
+					if p.keepListing(listing) {
+						listings = append(listings, listing)
 					}
+					continue
 				}
 
 				// Fetch details for this listing
-				enriched, err := GetListingDetails(listing)
+				enriched, err := p.GetListingDetailsContext(ctx, listing)
+				if err != nil && ctx.Err() != nil {
+					return dedupeListings(listings), ctx.Err()
+				}
 				if err != nil {
 					log.Printf("Error fetching details for URL %s: %v", url, err)
-					if listing.ID != "" {
+					if listing.ID != "" && p.keepListing(listing) {
 						listings = append(listings, listing)
 					}
 				} else {
-					listings = append(listings, enriched)
+					p.recordCheckpoint(enriched)
+					if p.keepListing(enriched) {
+						listings = append(listings, enriched)
+					}
 				}
 			} else {
 				// This might be a subcategory or another type of page
 				// Try to parse it as a category page to extract items
-				subListings, err := GetListings(url, 1) // Only get 1 item from each potential subcategory
+				// Only get 1 item from each potential subcategory. The internal path keeps the
+				// subcategory out of the Parser's counters, which this call already covers
+				subListings, err := p.getListings(ctx, p.regionalURL(url), 1)
 				if err != nil {
+					if ctx.Err() != nil {
+						return dedupeListings(listings), ctx.Err()
+					}
+					if errors.Is(err, ErrRequestBudgetExhausted) {
+						log.Printf("Request budget exhausted, returning %d listings: %v", len(listings), err)
+						break
+					}
 					log.Printf("Error processing potential subcategory %s: %v", url, err)
 					continue
 				}
@@ -494,122 +889,278 @@ func handleCatalogPage(catalogURL string, limit int) ([]models.Listing, error) {
 					}
 				}
 			}
-
-			// Add a delay between requests to be nice to the server
-			time.Sleep(3 * time.Second)
 		}
 	}
 
-	return listings, nil
+	if len(listings) == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoListingsFound, catalogURL)
+	}
+
+	return dedupeListings(listings), nil
 }
 
-// GetListingDetails fetches detailed information for a specific listing
+// GetListingDetails fetches detailed information for a specific listing using the default parser
 func GetListingDetails(listing models.Listing) (models.Listing, error) {
-	if listing.URL == "" {
-		return listing, fmt.Errorf("listing URL is empty")
-	}
+	return defaultParser.GetListingDetails(listing)
+}
 
-	c := colly.NewCollector(
-		colly.AllowedDomains("www.avito.ru", "avito.ru"),
-		colly.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		colly.MaxDepth(1),
-	)
+// GetListingDetailsContext fetches detailed information for a specific listing using the default parser,
+// aborting when the context is cancelled or its deadline expires
+func GetListingDetailsContext(ctx context.Context, listing models.Listing) (models.Listing, error) {
+	return defaultParser.GetListingDetailsContext(ctx, listing)
+}
 
-	// Set up retry mechanism
-	c.SetRequestTimeout(30 * time.Second)
+// GetListingDetails fetches detailed information for a specific listing
+func (p *Parser) GetListingDetails(listing models.Listing) (models.Listing, error) {
+	return p.GetListingDetailsContext(context.Background(), listing)
+}
 
-	c.OnRequest(func(r *colly.Request) {
-		log.Println("Visiting listing page:", r.URL)
-		// Respect rate limiting
-		waitForRateLimit()
-	})
+// GetListingDetailsContext fetches detailed information for a specific listing.
+// Concurrent calls for the same listing URL share a single fetch, which runs
+// under the context of the caller that started it.
+func (p *Parser) GetListingDetailsContext(ctx context.Context, listing models.Listing) (models.Listing, error) {
+	if listing.URL == "" {
+		return listing, fmt.Errorf("listing: %w", ErrEmptyURL)
+	}
 
-	c.OnError(func(r *colly.Response, err error) {
-		log.Println("Error visiting listing page:", err)
+	result, err, shared := p.details.Do(normalizeURL(listing.URL), func() (interface{}, error) {
+		return p.fetchListingDetails(ctx, listing)
 	})
 
-	// Extract title if we don't have it
-	if listing.Title == "" {
-		c.OnHTML("h1", func(e *colly.HTMLElement) {
-			listing.Title = strings.TrimSpace(e.Text)
-		})
+	enriched := result.(models.Listing)
+	if shared {
+		// Keep the caller's own context for cross-posted items
+		enriched.CategoryURL = listing.CategoryURL
 	}
 
-	// Parse listing details
-	c.OnHTML("body", func(e *colly.HTMLElement) {
-		// Extract description
-		description := e.DOM.Find("div[data-marker='item-description'], div.item-description").Text()
-		listing.Description = strings.TrimSpace(description)
+	return enriched, err
+}
 
-		// Extract images
-		e.DOM.Find("div.gallery-img-wrapper img, div.photo-slider-image-wrapper img").Each(func(_ int, s *goquery.Selection) {
-			if src, exists := s.Attr("src"); exists && src != "" {
-				listing.ImageURLs = append(listing.ImageURLs, src)
-			} else if srcset, exists := s.Attr("srcset"); exists && srcset != "" {
-				// Take the first image from srcset
-				parts := strings.Split(srcset, " ")
-				if len(parts) > 0 {
-					listing.ImageURLs = append(listing.ImageURLs, parts[0])
-				}
-			} else if dataSrc, exists := s.Attr("data-src"); exists && dataSrc != "" {
-				listing.ImageURLs = append(listing.ImageURLs, dataSrc)
-			}
-		})
+// GetListingByURL fetches a single listing from its page URL using the default parser
+func GetListingByURL(listingURL string) (models.Listing, error) {
+	return defaultParser.GetListingByURL(listingURL)
+}
 
-		// Extract location
-		location := e.DOM.Find("div[data-marker='item-address'], div.item-address").Text()
-		listing.Location = strings.TrimSpace(location)
+// GetListingByURLContext fetches a single listing from its page URL using the default parser,
+// aborting when the context is cancelled or its deadline expires
+func GetListingByURLContext(ctx context.Context, listingURL string) (models.Listing, error) {
+	return defaultParser.GetListingByURLContext(ctx, listingURL)
+}
 
-		// Extract price if we don't have it
+// GetListingByURL fetches a single listing from its page URL, such as
+// "https://www.avito.ru/moskva/telefony/iphone_15_4567891234". It returns
+// ErrNotItemURL for URLs on other sites and for Avito pages that aren't listings.
+func (p *Parser) GetListingByURL(listingURL string) (models.Listing, error) {
+	return p.GetListingByURLContext(context.Background(), listingURL)
+}
+
+// GetListingByURLContext works like GetListingByURL, aborting when the context is cancelled
+func (p *Parser) GetListingByURLContext(ctx context.Context, listingURL string) (models.Listing, error) {
+	listing, err := p.listingFromURL(listingURL)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	return p.GetListingDetailsContext(ctx, listing)
+}
+
+// listingFromURL checks that rawURL is a listing page on an allowed host and
+// returns a listing holding its normalized URL and ID
+func (p *Parser) listingFromURL(rawURL string) (models.Listing, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return models.Listing{}, fmt.Errorf("listing: %w", ErrEmptyURL)
+	}
+
+	listingURL := normalizeLink(p.opts.BaseURL, rawURL)
+	parsedURL, err := url.Parse(listingURL)
+	if err != nil {
+		return models.Listing{}, fmt.Errorf("%w: %s: %w", ErrNotItemURL, rawURL, err)
+	}
+
+	if !slices.Contains(p.allowedDomains(), parsedURL.Hostname()) {
+		return models.Listing{}, fmt.Errorf("%w: unexpected host in %s", ErrNotItemURL, rawURL)
+	}
+
+	id := extractItemID(parsedURL.Path)
+	if id == "" {
+		return models.Listing{}, fmt.Errorf("%w: no item ID in %s", ErrNotItemURL, rawURL)
+	}
+
+	return models.Listing{ID: id, URL: listingURL}, nil
+}
+
+// fetchListingDetails visits the listing page and extracts its details
+func (p *Parser) fetchListingDetails(ctx context.Context, listing models.Listing) (models.Listing, error) {
+	original := listing
+	layoutRecognized := true
+	closed := false
+
+	c := p.newCollector(ctx)
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Println("Visiting listing page:", r.URL)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Println("Error visiting listing page:", err)
+	})
+
+	// Extract title if we don't have it
+	if listing.Title == "" {
+		c.OnHTML("h1", func(e *colly.HTMLElement) {
+			listing.Title = cleanText(e.Text)
+		})
+	}
+
+	// Parse listing details
+	c.OnHTML("body", func(e *colly.HTMLElement) {
+		// Closed listings keep their page but show stale content, so nothing is extracted
+		if isClosedListing(e.DOM) {
+			closed = true
+			return
+		}
+
+		// Skip extraction entirely if none of the known blocks are present
+		if e.DOM.Find(strings.Join(detailSelectors, ", ")).Length() == 0 {
+			layoutRecognized = false
+			return
+		}
+
+		listing.IsActive = true
+
+		// Extract description
+		description := e.DOM.Find("div[data-marker='item-description'], div.item-description").First()
+		listing.Description = htmlText(description)
+		if descriptionHTML, err := description.Html(); err == nil {
+			listing.DescriptionHTML = strings.TrimSpace(descriptionHTML)
+		}
+
+		// Extract images
+		e.DOM.Find("div.gallery-img-wrapper img, div.photo-slider-image-wrapper img").Each(func(_ int, s *goquery.Selection) {
+			if imageURL := imageSource(s); imageURL != "" {
+				listing.ImageURLs = append(listing.ImageURLs, imageURL)
+			}
+		})
+		listing.ImageURLs = normalizeImageURLs(listing.ImageURLs)
+		if count := max(pagePhotoCount(e.DOM), len(listing.ImageURLs)); count > 0 {
+			listing.PhotoCount = count
+		}
+
+		// Detect a video walkthrough
+		listing.HasVideo = e.DOM.Find("*[data-marker*='video'], div.gallery-video, iframe[src*='youtube']").Length() > 0
+
+		// Detect Avito Delivery
+		listing.DeliveryAvailable = hasDeliveryButton(e.DOM)
+
+		// Extract location
+		location := e.DOM.Find("div[data-marker='item-address'], div.item-address").Text()
+		listing.Location = cleanText(location)
+
+		// Extract price if we don't have it
 		if listing.Price.Value == 0 {
 			priceText := e.DOM.Find("span.price-value, div.item-price, *[data-marker='item-price']").Text()
 			if priceText != "" {
-				listing.Price = parsePrice(priceText)
+				listing.Price = p.parsePrice(priceText)
 			}
 		}
 
 		// Extract publish date
 		dateText := e.DOM.Find("div[data-marker='item-date'], div.item-date").Text()
 		if dateText != "" {
-			listing.PublishedAt = parseDate(dateText)
+			if publishedAt, err := p.parseDate(dateText); err == nil {
+				listing.PublishedAt = publishedAt
+			} else {
+				log.Printf("Error parsing publish date for %s: %v", listing.URL, err)
+			}
+		}
+
+		// Extract the promo discount timer
+		timer := e.DOM.Find("*[data-marker='discount-timer'], *[data-marker='item-view/discount-timer'], div.discount-timer").First()
+		if timer.Length() > 0 {
+			listing.DiscountEndsAt = parseDiscountEnd(timer, p.now())
 		}
 
+		// Extract the category from the breadcrumbs
+		if path, categoryID := parseBreadcrumbs(e.DOM); len(path) > 0 {
+			listing.CategoryPath = path
+			listing.CategoryID = categoryID
+		}
+
+		// Extract the view and favorites counters
+		listing.Views, listing.Favorites = parseCounters(e.DOM)
+
 		// Extract attributes
-		attributes := make(map[string]string)
-		e.DOM.Find("div.item-params, ul.item-params-list li").Each(func(_ int, s *goquery.Selection) {
-			text := strings.TrimSpace(s.Text())
-			if text != "" {
-				parts := strings.Split(text, ":")
-				if len(parts) == 2 {
-					key := strings.TrimSpace(parts[0])
-					value := strings.TrimSpace(parts[1])
-					attributes[key] = value
-				}
-			}
-		})
+		if attributes := parseAttributes(e.DOM); len(attributes) > 0 {
+			listing.AttributesList = attributes
+			listing.Attributes = attributesMap(attributes)
+		}
+
+		// Extract the item condition from the parameters, or failing that the title
+		listing.Condition = parseCondition(listing.AttributesList, listing.Title)
+		listing.PricePerSquareMeter = pricePerSquareMeter(listing)
+
+		// Extract the typed parameters of car listings
+		listing.Car = parseCarDetails(listing)
+
+		// Extract coordinates from the map widget or the page's JSON state
+		if lat, lon, ok := parseCoordinates(e.DOM); ok {
+			listing.Latitude, listing.Longitude = lat, lon
+			listing.HasCoordinates = true
+		}
+
+		// Extract the seller; anonymous sellers have no block and leave the fields empty
+		sellerName, sellerType, sellerURL := parseSeller(e.DOM, p.opts.BaseURL)
+		if sellerName != "" {
+			listing.SellerName = sellerName
+		}
+		if sellerType != "" {
+			listing.SellerType = sellerType
+		}
+		if sellerURL != "" {
+			listing.SellerURL = sellerURL
+		}
 
-		// If any attributes were found, add them
-		if len(attributes) > 0 {
-			listing.Attributes = attributes
+		// Extract the link to the seller's storefront with their other listings
+		if count, storefrontURL := parseSellerItems(e.DOM, p.opts.BaseURL); count > 0 {
+			listing.SellerItemCount = count
+			listing.SellerURL = storefrontURL
 		}
+
+		p.applyExtractionLimits(&listing)
 	})
 
-	// Wait for rate limiting before starting
-	waitForRateLimit()
+	p.trackRequestStats(ctx, c)
 
-	err := c.Visit(listing.URL)
+	err := p.visit(ctx, c, listing.URL)
 	if err != nil {
 		return listing, fmt.Errorf("error visiting listing page: %w", err)
 	}
 
 	c.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return original, err
+	}
+
+	if closed {
+		original.Title = listing.Title
+		original.IsActive = false
+		return original, nil
+	}
+
+	if !layoutRecognized {
+		return original, fmt.Errorf("%w: %w: %s", ErrParseFailed, ErrLayoutUnrecognized, original.URL)
+	}
+
 	return listing, nil
 }
 
 // parseListing extracts listing information from an item card
-func parseListing(item *colly.HTMLElement) models.Listing {
+func (p *Parser) parseListing(item *colly.HTMLElement) models.Listing {
 	listing := models.Listing{
 		Attributes: make(map[string]string),
+		IsActive:   true,
 	}
 
 	// Extract ID
@@ -622,23 +1173,16 @@ func parseListing(item *colly.HTMLElement) models.Listing {
 		// Try to extract ID from URLs or other attributes
 		href := item.ChildAttr("a", "href")
 		if href != "" {
-			matches := itemIDRegex.FindStringSubmatch(href)
-			if len(matches) > 1 {
-				if matches[1] != "" {
-					id = matches[1]
-				} else if matches[2] != "" {
-					id = matches[2]
-				}
-			}
+			id = extractItemID(href)
 		}
 	}
 	listing.ID = id
 
 	// Extract title
-	title := strings.TrimSpace(item.ChildText("h3.title, div.title, a.title, *[data-marker='item-title']"))
+	title := cleanText(firstMatch(item.DOM, p.opts.Selectors.Titles).Text())
 	if title == "" {
 		// Try more general selectors
-		title = strings.TrimSpace(item.DOM.Find("h3, h2, a.snippet-link").First().Text())
+		title = cleanText(item.DOM.Find("h3, h2, a.snippet-link").First().Text())
 	}
 	listing.Title = title
 
@@ -657,137 +1201,1010 @@ func parseListing(item *colly.HTMLElement) models.Listing {
 			}
 		})
 	}
-	listing.URL = normalizeURL(url)
+	listing.URL = normalizeLink(p.opts.BaseURL, url)
 
 	// Extract price
-	priceText := strings.TrimSpace(item.ChildText("span.price, div.price, *[data-marker='item-price']"))
+	priceText := cleanText(firstMatch(item.DOM, p.opts.Selectors.Prices).Text())
 	if priceText == "" {
-		priceText = strings.TrimSpace(item.DOM.Find(".price, .snippet-price, .price-text").First().Text())
+		priceText = cleanText(item.DOM.Find(".price, .snippet-price, .price-text").First().Text())
 	}
 
-	if priceText != "" {
-		listing.Price = parsePrice(priceText)
+	// Prefer the raw numeric price from data attributes when available
+	if price, ok := priceFromAttributes(item.DOM, priceText); ok {
+		listing.Price = price
+	} else if priceText != "" {
+		listing.Price = p.parsePrice(priceText)
 	}
 
 	// Extract location
-	location := strings.TrimSpace(item.ChildText("div.geo-georeferences, *[data-marker='item-address']"))
+	location := cleanText(item.ChildText("div.geo-georeferences, *[data-marker='item-address']"))
 	if location == "" {
-		location = strings.TrimSpace(item.DOM.Find(".geo-georeferences, .item-address, .snippet-address").First().Text())
+		location = cleanText(item.DOM.Find(".geo-georeferences, .item-address, .snippet-address").First().Text())
 	}
 	listing.Location = location
 
-	// Extract image URL
-	imageURL := item.ChildAttr("img", "src")
-	if imageURL != "" {
-		listing.ImageURLs = []string{imageURL}
-	} else {
-		// Try to find images with data-src attribute
-		dataSrc := item.ChildAttr("img", "data-src")
-		if dataSrc != "" {
-			listing.ImageURLs = []string{dataSrc}
+	// Extract the publish date shown on the card, e.g. "2 часа назад"
+	if dateText := cleanText(item.ChildText("*[data-marker='item-date']")); dateText != "" {
+		if publishedAt, err := p.parseDate(dateText); err == nil {
+			listing.PublishedAt = publishedAt
 		}
 	}
 
+	listing.DeliveryAvailable = hasDeliveryBadge(item.DOM)
+	listing.IsPromoted = isPromotedCard(item.DOM)
+	listing.SellerType = cardSellerType(item.DOM)
+	listing.PhotoCount = cardPhotoCount(item.DOM)
+	listing.PricePerSquareMeter = pricePerSquareMeter(listing)
+
+	// Extract image URL
+	if imageURL := imageSource(item.DOM.Find("img").First()); imageURL != "" {
+		listing.ImageURLs = normalizeImageURLs([]string{imageURL})
+	}
+
 	return listing
 }
 
-// parsePrice extracts price information from text
-func parsePrice(priceText string) models.Price {
+// applyExtractionLimits truncates images and description according to MaxImages and MaxDescriptionLength
+func (p *Parser) applyExtractionLimits(listing *models.Listing) {
+	if p.opts.MaxImages > 0 && len(listing.ImageURLs) > p.opts.MaxImages {
+		listing.ImageURLs = listing.ImageURLs[:p.opts.MaxImages]
+	}
+
+	listing.Description = truncateText(listing.Description, p.opts.MaxDescriptionLength)
+}
+
+// extractItemID returns the numeric item ID at the end of a listing URL's path,
+// ignoring the query string, fragment and trailing slashes
+func extractItemID(rawURL string) string {
+	path := rawURL
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimRight(path, "/")
+
+	matches := itemIDRegex.FindStringSubmatch(path)
+	if len(matches) < 2 {
+		return ""
+	}
+	return matches[1]
+}
+
+// listingKey identifies a listing by ID, falling back to its normalized URL and,
+// for listings with neither, to its fingerprint
+func listingKey(listing models.Listing) string {
+	if listing.ID != "" {
+		return listing.ID
+	}
+	if listing.URL != "" {
+		return normalizeURL(listing.URL)
+	}
+
+	return listing.Fingerprint()
+}
+
+// dedupeListings removes repeated listings, keeping the first occurrence so ordering is stable
+func dedupeListings(listings []models.Listing) []models.Listing {
+	seen := make(map[string]bool, len(listings))
+	unique := make([]models.Listing, 0, len(listings))
+
+	for _, listing := range listings {
+		key := listingKey(listing)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, listing)
+	}
+
+	return unique
+}
+
+// cleanText collapses runs of unicode whitespace, such as the non-breaking spaces Avito
+// puts between digit groups and in addresses, into single spaces and trims the result.
+// Zero-width spaces and byte order marks are dropped.
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(strings.Map(dropInvisible, text)), " ")
+}
+
+// cleanMultilineText cleans each line of text like cleanText, keeping line breaks
+// but collapsing runs of blank lines into one
+func cleanMultilineText(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = cleanText(line)
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlText converts the contents of s to plain text. Line breaks in the markup are
+// ignored as a browser would, while <br> tags and block elements start new lines
+// and paragraphs are separated by a blank line.
+func htmlText(s *goquery.Selection) string {
+	var b strings.Builder
+
+	// Line breaks are held back until the next text, so that adjacent block
+	// boundaries and the whitespace between them don't add empty lines
+	breaks := 0
+	write := func(text string) {
+		if breaks > 0 {
+			if strings.TrimSpace(text) == "" {
+				return
+			}
+			if b.Len() > 0 {
+				b.WriteString(strings.Repeat("\n", breaks))
+			}
+			breaks = 0
+		}
+		b.WriteString(text)
+	}
+
+	var walk func(*goquery.Selection)
+	walk = func(s *goquery.Selection) {
+		s.Contents().Each(func(_ int, child *goquery.Selection) {
+			switch name := goquery.NodeName(child); {
+			case name == "#text":
+				write(strings.ReplaceAll(child.Text(), "\n", " "))
+			case name == "br":
+				breaks++
+			case name == "script" || name == "style":
+				// not rendered
+			case name == "p":
+				breaks = max(breaks, 2)
+				walk(child)
+				breaks = max(breaks, 2)
+			case blockElements[name]:
+				breaks = max(breaks, 1)
+				walk(child)
+				breaks = max(breaks, 1)
+			default:
+				walk(child)
+			}
+		})
+	}
+	walk(s)
+
+	return cleanMultilineText(b.String())
+}
+
+// dropInvisible removes zero-width characters that strings.Fields doesn't treat as space
+func dropInvisible(r rune) rune {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return -1
+	}
+	return r
+}
+
+// truncateText shortens text to at most limit characters, cutting on a rune boundary and adding an ellipsis
+func truncateText(text string, limit int) string {
+	if limit <= 0 {
+		return text
+	}
+
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
+
+// priceFromAttributes reads a raw numeric price from data-price or itemprop="price" attributes
+func priceFromAttributes(s *goquery.Selection, priceText string) (models.Price, bool) {
+	raw, exists := s.Attr("data-price")
+	if !exists {
+		raw, exists = s.Find("*[data-price]").First().Attr("data-price")
+	}
+	if !exists {
+		raw, exists = s.Find("*[data-marker='item-price'] meta[itemprop='price']").First().Attr("content")
+	}
+	if !exists {
+		return models.Price{}, false
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || value <= 0 {
+		return models.Price{}, false
+	}
+
+	currency := "RUB"
+	if c, ok := s.Find("meta[itemprop='priceCurrency']").First().Attr("content"); ok && c != "" {
+		currency = c
+	}
+
+	return models.Price{
+		Value:    value,
+		Currency: currency,
+		Text:     priceText,
+	}, true
+}
+
+// ParseStartingPrice handles "от 1 500 ₽" starting-from prices
+func ParseStartingPrice(priceText string) (models.Price, bool) {
+	text := strings.ToLower(cleanText(priceText))
+	if !strings.HasPrefix(text, "от ") {
+		return models.Price{}, false
+	}
+
+	price := parseDefaultPrice(priceText)
+	price.From = true
+	price.Min = price.Value
+	return price, true
+}
+
+// parsePrice extracts price information from text, trying the PriceParsers option
+// before the default parser
+func (p *Parser) parsePrice(priceText string) models.Price {
+	for _, parser := range p.opts.PriceParsers {
+		if parser == nil {
+			continue
+		}
+		if price, ok := parser(priceText); ok {
+			return price
+		}
+	}
+
+	return parseDefaultPrice(priceText)
+}
+
+// parseDefaultPrice extracts the value, bounds and currency from text.
+// Ranges set Min and Max with Value holding the lower bound, "до X" sets Max,
+// and negotiable prices ("Цена договорная") are flagged with a zero Value.
+func parseDefaultPrice(priceText string) models.Price {
+	priceText = cleanText(priceText)
 	price := models.Price{
 		Text: priceText,
 	}
 
-	// Default to RUB
-	price.Currency = "RUB"
+	lower := strings.ToLower(cleanText(priceText))
+	price.Currency = parseCurrency(lower)
+	price.Unit = parsePriceUnit(lower)
 
-	// Check for currency symbols
-	if strings.Contains(priceText, "$") {
-		price.Currency = "USD"
-	} else if strings.Contains(priceText, "€") {
-		price.Currency = "EUR"
+	if strings.Contains(lower, "договор") {
+		price.Negotiable = true
+		return price
+	}
+
+	// Price ranges
+	if matches := priceRangeRegex.FindStringSubmatch(priceText); matches != nil {
+		minValue, minOK := parseNumber(matches[1])
+		maxValue, maxOK := parseNumber(matches[2])
+		if minOK && maxOK {
+			price.Value = minValue
+			price.Min = minValue
+			price.Max = maxValue
+			price.IsRange = true
+			return price
+		}
 	}
 
 	// Extract numeric value
-	matches := priceRegex.FindString(priceText)
-	if matches != "" {
-		// Clean up the string
-		valueStr := strings.ReplaceAll(matches, " ", "")
-		valueStr = strings.ReplaceAll(valueStr, ",", ".")
+	if value, ok := parseNumber(priceRegex.FindString(priceText)); ok {
+		price.Value = value
+	}
 
-		// Parse as float
-		value, err := strconv.ParseFloat(valueStr, 64)
-		if err == nil {
-			price.Value = value
-		}
+	if strings.HasPrefix(lower, "до ") {
+		price.Max = price.Value
 	}
 
 	return price
 }
 
-// parseDate attempts to parse a date string from Avito into a time.Time
-func parseDate(dateStr string) time.Time {
-	// Avito may use relative dates like "сегодня", "вчера" or specific dates
-	dateStr = strings.ToLower(strings.TrimSpace(dateStr))
+// priceCurrencies maps currency symbols and abbreviations to ISO codes, checked in order
+var priceCurrencies = []struct {
+	markers  []string
+	currency string
+}{
+	{[]string{"$", "usd"}, "USD"},
+	{[]string{"€", "eur"}, "EUR"},
+	{[]string{"₸", "тенге", "kzt"}, "KZT"},
+	{[]string{"₴", "грн", "uah"}, "UAH"},
+}
+
+// parseCurrency detects the currency of a lowercased price text, defaulting to RUB
+func parseCurrency(lower string) string {
+	for _, candidate := range priceCurrencies {
+		for _, marker := range candidate.markers {
+			if strings.Contains(lower, marker) {
+				return candidate.currency
+			}
+		}
+	}
 
-	now := time.Now()
+	return "RUB"
+}
+
+// priceUnits maps unit suffixes used on Avito to the normalized PriceUnit constants
+var priceUnits = []struct {
+	suffixes []string
+	unit     string
+}{
+	{[]string{"в месяц", "/мес", "за месяц"}, models.PriceUnitMonth},
+	{[]string{"в сутки", "за сутки", "/сут"}, models.PriceUnitDay},
+	{[]string{"в час", "за час", "/час"}, models.PriceUnitHour},
+	{[]string{"за м²", "за м2", "за кв. м", "/м²"}, models.PriceUnitSquareMeter},
+	{[]string{"за сот.", "за сотку", "/сот"}, models.PriceUnitSotka},
+	{[]string{"за шт.", "за штуку", "/шт"}, models.PriceUnitPiece},
+}
 
-	if strings.Contains(dateStr, "сегодня") {
-		// Today
-		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	} else if strings.Contains(dateStr, "вчера") {
-		// Yesterday
-		return time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, now.Location())
+// parsePriceUnit detects the unit a lowercased price text refers to
+func parsePriceUnit(lower string) string {
+	for _, candidate := range priceUnits {
+		for _, suffix := range candidate.suffixes {
+			if strings.Contains(lower, suffix) {
+				return candidate.unit
+			}
+		}
 	}
 
-	// Try various date formats
-	formats := []string{
-		"02 января 2006",
-		"02 января",
-		"02 янв 2006",
-		"02 янв",
-		"02.01.2006",
-		"02.01.06",
+	return ""
+}
+
+// parseNumber parses a number such as "1 234 567", "1,234,567.89" or "1.234,5".
+// Spaces are always digit group separators. When both commas and dots appear the
+// last one is the decimal separator; a lone comma or dot is a group separator only
+// when it is followed by exactly three digits, as in "1,234", so "54,5" is 54.5.
+func parseNumber(text string) (float64, bool) {
+	valueStr := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+	valueStr = strings.TrimRight(valueStr, ".,")
+	if valueStr == "" {
+		return 0, false
 	}
 
-	for _, format := range formats {
-		t, err := time.Parse(format, dateStr)
-		if err == nil {
-			// If year is not specified, use current year
-			if t.Year() == 0 {
-				t = time.Date(now.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
-			}
+	lastComma, lastDot := strings.LastIndex(valueStr, ","), strings.LastIndex(valueStr, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		decimal := max(lastComma, lastDot)
+		valueStr = strings.NewReplacer(",", "", ".", "").Replace(valueStr[:decimal]) + "." + valueStr[decimal+1:]
+	case lastComma >= 0 || lastDot >= 0:
+		separator := ","
+		if lastDot >= 0 {
+			separator = "."
+		}
+		parts := strings.Split(valueStr, separator)
+		if len(parts) == 2 && (len(parts[1]) != 3 || strings.TrimLeft(parts[0], "-0") == "") {
+			valueStr = parts[0] + "." + parts[1]
+		} else {
+			valueStr = strings.Join(parts, "")
+		}
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// parseDate attempts to parse a date string from Avito into a time.Time.
+// It understands "сегодня"/"вчера", "2 часа назад", "5 марта", "5 мар 2024" and "05.03.2024",
+// each optionally followed by a time of day such as "в 10:30". Dates are interpreted
+// in the Parser's Location rather than the machine's local zone.
+func (p *Parser) parseDate(dateStr string) (time.Time, error) {
+	return parseDateAt(dateStr, p.now(), false)
+}
+
+// now returns the current time in the Location dates on Avito are shown in
+func (p *Parser) now() time.Time {
+	return time.Now().In(p.opts.Location)
+}
+
+// parseDateAt parses dateStr relative to now. Dates without a year are placed
+// in the past (publish dates) or, when future is set, in the future (deadlines).
+func parseDateAt(dateStr string, now time.Time, future bool) (time.Time, error) {
+	text := strings.ToLower(cleanText(dateStr))
+
+	// Relative durations; a missing count means one ("час назад")
+	if matches := relativeDateRegex.FindStringSubmatch(text); matches != nil {
+		count := 1
+		if matches[1] != "" {
+			count, _ = strconv.Atoi(matches[1])
+		}
+
+		var unit time.Duration
+		switch matches[2] {
+		case "минут":
+			unit = time.Minute
+		case "час":
+			unit = time.Hour
+		case "дн", "день":
+			unit = 24 * time.Hour
+		case "недел":
+			unit = 7 * 24 * time.Hour
+		}
+		return now.Add(-time.Duration(count) * unit), nil
+	}
+
+	hour, minute := 0, 0
+	if matches := timeOfDayRegex.FindStringSubmatch(text); matches != nil {
+		hour, _ = strconv.Atoi(matches[1])
+		minute, _ = strconv.Atoi(matches[2])
+		if hour > 23 || minute > 59 {
+			return time.Time{}, fmt.Errorf("invalid time of day in date %q", dateStr)
+		}
+	}
+
+	// Relative day names
+	if strings.Contains(text, "сегодня") {
+		return time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location()), nil
+	} else if strings.Contains(text, "вчера") {
+		return time.Date(now.Year(), now.Month(), now.Day()-1, hour, minute, 0, 0, now.Location()), nil
+	}
+
+	var (
+		day, year int
+		month     time.Month
+	)
+
+	if matches := numericDateRegex.FindStringSubmatch(text); matches != nil {
+		// Numeric style, e.g. "05.03.2024" or "05.03.24"
+		day, _ = strconv.Atoi(matches[1])
+		monthNum, _ := strconv.Atoi(matches[2])
+		year, _ = strconv.Atoi(matches[3])
+		if year < 100 {
+			year += 2000
+		}
+		month = time.Month(monthNum)
+	} else if matches := textDateRegex.FindStringSubmatch(text); matches != nil {
+		// Month name style, e.g. "5 марта 2024" or "15 дек"
+		day, _ = strconv.Atoi(matches[1])
+		month = russianMonth(matches[2])
+		year, _ = strconv.Atoi(matches[3])
+	} else {
+		return time.Time{}, fmt.Errorf("unrecognized date %q", dateStr)
+	}
+
+	if month < time.January || month > time.December || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("unrecognized date %q", dateStr)
+	}
+
+	if year == 0 {
+		// Avito omits the current year, so pick the nearest matching year
+		year = now.Year()
+		candidate := time.Date(year, month, day, hour, minute, 0, 0, now.Location())
+		if !future && candidate.After(now) {
+			year--
+		} else if future && candidate.Before(now) {
+			year++
+		}
+	}
+
+	t := time.Date(year, month, day, hour, minute, 0, 0, now.Location())
+	if t.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid day in date %q", dateStr)
+	}
+
+	return t, nil
+}
+
+// russianMonth maps a Russian month name in any case form or abbreviation
+// ("марта", "март", "мар.") to its time.Month, or 0 if it isn't a month
+func russianMonth(name string) time.Month {
+	runes := []rune(strings.TrimSuffix(name, "."))
+	if len(runes) < 3 {
+		return 0
+	}
+
+	return russianMonths[string(runes[:3])]
+}
+
+// parseDiscountEnd determines when a promo discount ends from its timer block.
+// It understands machine-readable end times, countdowns and absolute dates,
+// and returns a zero time when the end can't be determined.
+func parseDiscountEnd(timer *goquery.Selection, now time.Time) time.Time {
+	for _, attr := range []string{"datetime", "data-end-time", "data-end"} {
+		raw, exists := timer.Attr(attr)
+		if !exists {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t
+		}
+		if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return time.Unix(seconds, 0)
+		}
+	}
+
+	text := strings.ToLower(cleanText(timer.Text()))
+
+	// Absolute date style, e.g. "до 25 октября 23:59". It is tried first because the
+	// time of day would otherwise be read as a countdown; an end already past is dropped.
+	if t, err := parseDateAt(strings.TrimSpace(strings.TrimPrefix(text, "до")), now, true); err == nil {
+		if t.After(now) {
 			return t
 		}
+		return time.Time{}
+	}
+
+	// Countdown style: time remaining until the discount ends
+	if matches := countdownRegex.FindStringSubmatch(text); matches != nil {
+		days, _ := strconv.Atoi(matches[1])
+		hours, _ := strconv.Atoi(matches[2])
+		minutes, _ := strconv.Atoi(matches[3])
+		seconds, _ := strconv.Atoi(matches[4])
+
+		remaining := time.Duration(days)*24*time.Hour +
+			time.Duration(hours)*time.Hour +
+			time.Duration(minutes)*time.Minute +
+			time.Duration(seconds)*time.Second
+		return now.Add(remaining)
+	}
+
+	return time.Time{}
+}
+
+// parseCoordinates finds the listing's coordinates on its page.
+// It reports false when the page doesn't contain valid coordinates.
+func parseCoordinates(doc *goquery.Selection) (lat, lon float64, ok bool) {
+	// The map widget carries the coordinates as data attributes
+	mapWidget := doc.Find("*[data-map-lat][data-map-lon]").First()
+	if mapWidget.Length() > 0 {
+		latText, _ := mapWidget.Attr("data-map-lat")
+		lonText, _ := mapWidget.Attr("data-map-lon")
+		if lat, lon, ok := parseCoordinatePair(latText, lonText); ok {
+			return lat, lon, true
+		}
+	}
+
+	// Otherwise look in the JSON embedded in script tags
+	var found bool
+	doc.Find("script").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := html.UnescapeString(s.Text())
+		for _, re := range []*regexp.Regexp{coordsRegex, geoRegex} {
+			if matches := re.FindStringSubmatch(text); matches != nil {
+				lat, lon, found = parseCoordinatePair(matches[1], matches[2])
+				if found {
+					return false
+				}
+			}
+		}
+		return true
+	})
+
+	return lat, lon, found
+}
+
+// parseCoordinatePair parses and range-checks a latitude/longitude pair
+func parseCoordinatePair(latText, lonText string) (float64, float64, bool) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if (lat == 0 && lon == 0) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// parseAttributes extracts the parameters listed in a listing page's item-params block.
+// Rows look like "Количество комнат: 2", usually with the name in a separate span;
+// rows without a colon, such as "Балкон", are kept with an empty value.
+func parseAttributes(doc *goquery.Selection) []models.KeyValue {
+	var attributes []models.KeyValue
+
+	rows := doc.Find("*[data-marker='item-view/item-params'] li, div.item-params li, ul.item-params-list li")
+	if rows.Length() == 0 {
+		// Older layouts put every parameter on its own line of a single block
+		doc.Find("div.item-params").Each(func(_ int, block *goquery.Selection) {
+			for _, line := range strings.Split(block.Text(), "\n") {
+				if attribute, ok := parseAttribute("", line); ok {
+					attributes = append(attributes, attribute)
+				}
+			}
+		})
+		return attributes
+	}
+
+	rows.Each(func(_ int, row *goquery.Selection) {
+		label := row.Find("span[class*='label'], span[class*='Label']").First().Text()
+		if attribute, ok := parseAttribute(label, row.Text()); ok {
+			attributes = append(attributes, attribute)
+		}
+	})
+
+	return attributes
+}
+
+// parseAttribute splits a parameter row into its name and value. label is the
+// text of the row's name element, if it has one.
+func parseAttribute(label, text string) (models.KeyValue, bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	label = strings.Join(strings.Fields(label), " ")
+
+	var key, value string
+	switch {
+	case label != "" && strings.HasPrefix(text, label):
+		key, value = label, strings.TrimPrefix(text, label)
+	case strings.Contains(text, ":"):
+		key, value, _ = strings.Cut(text, ":")
+	default:
+		key = text
+	}
+
+	key = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(key), ":"))
+	value = strings.TrimSpace(value)
+	if key == "" {
+		return models.KeyValue{}, false
+	}
+
+	attribute := models.KeyValue{Key: key, Value: value}
+	if matches := attributeNumberRegex.FindStringSubmatch(value); matches != nil {
+		if number, ok := parseNumber(matches[1]); ok {
+			attribute.Number, attribute.Unit, attribute.IsNumeric = number, matches[2], true
+		}
+	}
+
+	return attribute, true
+}
+
+// attributesMap converts parameters into a map, joining the values of repeated parameters
+func attributesMap(attributes []models.KeyValue) map[string]string {
+	result := make(map[string]string, len(attributes))
+	for _, attribute := range attributes {
+		existing, ok := result[attribute.Key]
+		switch {
+		case !ok || existing == "":
+			result[attribute.Key] = attribute.Value
+		case attribute.Value != "" && attribute.Value != existing:
+			result[attribute.Key] = existing + ", " + attribute.Value
+		}
+	}
+	return result
+}
+
+// parseCondition determines whether an item is new or used from its "Состояние"
+// parameter, falling back to words such as "новый" or "б/у" in the title
+func parseCondition(attributes []models.KeyValue, title string) string {
+	for _, attribute := range attributes {
+		if strings.EqualFold(attribute.Key, "Состояние") {
+			if condition := normalizeCondition(attribute.Value); condition != models.ConditionUnknown {
+				return condition
+			}
+		}
+	}
+
+	if matches := titleConditionRegex.FindStringSubmatch(title); matches != nil {
+		return normalizeCondition(matches[1])
+	}
+
+	return models.ConditionUnknown
+}
+
+// areaAttributes are the parameters holding the area of a property, most relevant first
+var areaAttributes = []string{"Общая площадь", "Площадь", "Площадь дома", "Площадь помещения"}
+
+// pricePerSquareMeter returns the listing's price per м² of area, rounded to kopecks.
+// Prices already given per м² are returned as they are; rents and other prices per
+// period, and ranges, give 0 like listings without an area.
+func pricePerSquareMeter(listing models.Listing) float64 {
+	if listing.Price.Unit == models.PriceUnitSquareMeter {
+		return listing.Price.Value
+	}
+	if listing.Price.Unit != "" {
+		return 0
+	}
+
+	area := parseArea(listing.AttributesList, listing.Title)
+	if area <= 0 || listing.Price.Value <= 0 || listing.Price.IsRange {
+		return 0
+	}
+
+	return math.Round(listing.Price.Value/area*100) / 100
+}
+
+// parseArea returns the area in м² from a listing's parameters, falling back to its
+// title, or 0 when neither states it
+func parseArea(attributes []models.KeyValue, title string) float64 {
+	for _, key := range areaAttributes {
+		for _, attribute := range attributes {
+			if strings.EqualFold(attribute.Key, key) && attribute.IsNumeric && isSquareMeters(attribute.Unit) {
+				return attribute.Number
+			}
+		}
+	}
+
+	if matches := titleAreaRegex.FindStringSubmatch(title); matches != nil {
+		if area, ok := parseNumber(matches[1]); ok {
+			return area
+		}
 	}
 
-	// Default to current time if parsing fails
-	return now
+	return 0
 }
 
-// ParseItemsFromHTML extracts advertisement items (title, URL, price) from HTML content
+// isSquareMeters reports whether a parameter unit is square meters, e.g. "м²" or "кв. м"
+func isSquareMeters(unit string) bool {
+	unit = strings.ReplaceAll(strings.ToLower(unit), " ", "")
+	return unit == "м²" || unit == "м2" || unit == "кв.м" || unit == "кв.м."
+}
+
+// normalizeCondition maps a condition as written on Avito, e.g. "Новое с биркой",
+// "Б/у" or "Отличное", to one of the Condition constants
+func normalizeCondition(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case value == "":
+		return models.ConditionUnknown
+	case strings.HasPrefix(value, "нов"):
+		return models.ConditionNew
+	case strings.HasPrefix(value, "б/у"), strings.HasPrefix(value, "бу"), strings.HasPrefix(value, "б.у"),
+		strings.HasPrefix(value, "подерж"), strings.HasPrefix(value, "отличн"), strings.HasPrefix(value, "хорош"),
+		strings.HasPrefix(value, "удовлетвор"), strings.HasPrefix(value, "требует"), strings.Contains(value, "запчаст"):
+		return models.ConditionUsed
+	default:
+		return models.ConditionUnknown
+	}
+}
+
+// hasDeliveryBadge reports whether a listing card carries the Avito Delivery badge
+func hasDeliveryBadge(card *goquery.Selection) bool {
+	if card.Find("*[data-marker*='delivery'], *[class*='delivery'], *[class*='Delivery']").Length() > 0 {
+		return true
+	}
+
+	found := false
+	card.Find("span, div, i").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if s.Children().Length() == 0 && deliveryBadgeRegex.MatchString(s.Text()) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// isPromotedCard reports whether a listing card is a promoted one, from its VAS
+// (paid services) markers, its class names or a "VIP"/"Премиум" badge
+func isPromotedCard(card *goquery.Selection) bool {
+	if card.Find("*[data-marker^='item-vas'], *[data-marker='item-vip'], *[data-marker='vip-badge']").Length() > 0 {
+		return true
+	}
+	if promotedClassRegex.MatchString(card.AttrOr("class", "")) {
+		return true
+	}
+
+	promoted := false
+	card.Find("span, div, i").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if s.Children().Length() == 0 && promotedBadgeRegex.MatchString(cleanText(s.Text())) {
+			promoted = true
+		}
+		return !promoted
+	})
+	return promoted
+}
+
+// hasDeliveryButton reports whether a listing page offers buying with Avito Delivery.
+// Only the delivery widget and buy buttons are checked, since the page's header and
+// footer link to Avito Delivery help pages for every listing.
+func hasDeliveryButton(doc *goquery.Selection) bool {
+	if doc.Find("*[data-marker='delivery-item-button'], *[data-marker='item-view/delivery'], *[data-marker*='delivery-buy'], div.item-delivery").Length() > 0 {
+		return true
+	}
+
+	found := false
+	doc.Find("button, a[data-marker*='button'], a[role='button']").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		found = deliveryButtonRegex.MatchString(s.Text())
+		return !found
+	})
+	return found
+}
+
+// isClosedListing reports whether a listing page shows the notice of a sold, closed
+// or removed listing. Only notices and headings are checked, so descriptions that
+// mention selling something don't count.
+func isClosedListing(doc *goquery.Selection) bool {
+	if doc.Find("*[data-marker='item-view/closed-warning'], *[data-marker='item-closed'], div.item-closed-warning, div.item-view-closed").Length() > 0 {
+		return true
+	}
+
+	found := false
+	doc.Find("h1, h2, h3, *[class*='warning'], *[class*='closed'], *[data-marker*='warning']").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if s.Closest("*[data-marker='item-description'], div.item-description").Length() > 0 {
+			return true
+		}
+		found = closedListingRegex.MatchString(cleanText(s.Text()))
+		return !found
+	})
+	return found
+}
+
+// parseBreadcrumbs extracts the category names from a listing page's breadcrumbs and
+// the slug of the deepest one. The home page and region links are skipped, so a trail
+// like "Главная › Москва › Электроника › Телефоны" yields ["Электроника", "Телефоны"].
+func parseBreadcrumbs(doc *goquery.Selection) (path []string, categoryID string) {
+	links := doc.Find("*[data-marker='breadcrumbs'] a[href], *[itemtype*='BreadcrumbList'] a[href], div.breadcrumbs a[href], nav[aria-label*='readcrumb'] a[href]")
+
+	seen := make(map[string]bool)
+	links.Each(func(_ int, link *goquery.Selection) {
+		name := cleanText(link.Text())
+		href, _ := link.Attr("href")
+		parsedURL, err := url.Parse(href)
+		if name == "" || err != nil {
+			return
+		}
+
+		// Category links have the region as the first segment, e.g. "/moskva/telefony"
+		segments := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+		if len(segments) < 2 || seen[parsedURL.Path] {
+			return
+		}
+		seen[parsedURL.Path] = true
+
+		path = append(path, name)
+		if slug := categorySlugRegex.FindString(segments[len(segments)-1]); slug != "" {
+			categoryID = slug
+		}
+	})
+
+	return path, categoryID
+}
+
+// parseCounters extracts the view and favorites counts shown on a listing page
+func parseCounters(doc *goquery.Selection) (views, favorites int) {
+	viewsText := doc.Find("*[data-marker='item-view/total-views'], *[data-marker='item-views'], div.title-info-views").First().Text()
+	if viewsText == "" {
+		viewsText = doc.Find("*[data-marker='item-view/item-metadata'], div.title-info-metadata").Text()
+	}
+	if matches := viewsRegex.FindStringSubmatch(viewsText); matches != nil {
+		views = parseCount(matches[1])
+	}
+
+	favoritesText := doc.Find("*[data-marker='item-view/favorites'], *[data-marker='item-view/item-metadata'], div.title-info-metadata").Text()
+	if matches := favoritesRegex.FindStringSubmatch(favoritesText); matches != nil {
+		favorites = parseCount(matches[1] + matches[2])
+	}
+
+	return views, favorites
+}
+
+// parseCount parses a counter such as "1 234", ignoring separators between digit groups
+func parseCount(text string) int {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text)
+
+	count, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// parseSeller extracts the seller's name, type and profile URL from a listing page's
+// seller block, resolving the profile link against base
+func parseSeller(doc *goquery.Selection, base string) (name, sellerType, sellerURL string) {
+	block := doc.Find("*[data-marker='seller-info'], *[data-marker='item-view/seller-info'], div.seller-info").First()
+	if block.Length() == 0 {
+		return "", "", ""
+	}
+
+	link := block.Find("*[data-marker='seller-info/name'] a, a[data-marker='seller-link/link'], div.seller-info-name a").First()
+	name = cleanText(link.Text())
+	if name == "" {
+		name = cleanText(block.Find("*[data-marker='seller-info/name']").First().Text())
+	}
+	if href, ok := link.Attr("href"); ok && href != "" {
+		sellerURL = normalizeLink(base, href)
+	}
+
+	sellerType = sellerTypeFromLabel(block.Find("*[data-marker='seller-info/label'], div.seller-info-label").First().Text())
+	if sellerType == "" && strings.Contains(sellerURL, "/brands/") {
+		sellerType = models.SellerTypeCompany
+	}
+
+	return name, sellerType, sellerURL
+}
+
+// sellerItemsSelector matches the seller blocks of a listing page that may link to the
+// seller's other listings
+const sellerItemsSelector = "*[data-marker^='seller-info'], *[data-marker^='item-view/seller'], div.seller-info"
+
+// parseSellerItems extracts the number of the seller's listings and the link to their
+// storefront from the seller block of a listing page, resolving the link against base.
+// Links elsewhere on the page, such as similar listings, are ignored.
+func parseSellerItems(doc *goquery.Selection, base string) (count int, storefrontURL string) {
+	doc.Find(sellerItemsSelector).Find("a[href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		matches := sellerItemsRegex.FindStringSubmatch(s.Text())
+		if matches == nil {
+			return true
+		}
+
+		count = parseCount(matches[1])
+		storefrontURL = normalizeLink(base, s.AttrOr("href", ""))
+		return count == 0
+	})
+
+	if count == 0 {
+		return 0, ""
+	}
+	return count, storefrontURL
+}
+
+// cardSellerType determines the seller type shown on a listing card, from the label
+// next to the seller's name or a link to a company's storefront. Most cards show
+// neither, leaving the type to the listing page.
+func cardSellerType(card *goquery.Selection) string {
+	label := card.Find("*[data-marker='item-seller-info/label'], *[data-marker='seller-info/label']").First().Text()
+	if sellerType := sellerTypeFromLabel(label); sellerType != "" {
+		return sellerType
+	}
+	if card.Find("a[href*='/brands/']").Length() > 0 {
+		return models.SellerTypeCompany
+	}
+	return ""
+}
+
+// sellerTypeFromLabel classifies a seller by the label Avito shows next to its name
+func sellerTypeFromLabel(label string) string {
+	label = strings.ToLower(cleanText(label))
+	switch {
+	case label == "":
+		return ""
+	case strings.Contains(label, "частное лицо"):
+		return models.SellerTypePrivate
+	default:
+		// Companies, agencies, dealers and shops all carry their own label
+		return models.SellerTypeCompany
+	}
+}
+
+// ParseItemsFromHTML extracts advertisement items (title, URL, price) from HTML content.
+// The JSON state embedded in the page is preferred; CSS selectors are used only when it's absent.
 func ParseItemsFromHTML(htmlContent string) ([]models.Listing, error) {
-	var listings []models.Listing
+	return defaultParser.ParseItemsFromHTML(htmlContent)
+}
+
+// ParseItemsFromReader extracts advertisement items from HTML read from r, such as a saved
+// page or an HTTP response body, without buffering it into a string first
+func ParseItemsFromReader(r io.Reader) ([]models.Listing, error) {
+	return defaultParser.ParseItemsFromReader(r)
+}
 
-	// Create a goquery document from the HTML content
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+// ParseItemsFromHTML extracts advertisement items from HTML content using the Parser's Selectors
+func (p *Parser) ParseItemsFromHTML(htmlContent string) ([]models.Listing, error) {
+	return p.ParseItemsFromReader(strings.NewReader(htmlContent))
+}
+
+// ParseItemsFromReader extracts advertisement items from HTML read from r using the Parser's Selectors
+func (p *Parser) ParseItemsFromReader(r io.Reader) ([]models.Listing, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing HTML: %w", err)
+		return nil, fmt.Errorf("%w: error parsing HTML: %w", ErrParseFailed, err)
 	}
 
-	// Look for item containers using various selectors that might match Avito's structure
-	var itemSelectors = []string{
-		"div[data-marker='item']",
-		"div[data-marker='item-card']",
-		"div.iva-item-root",
-		"div.styles-item-m0DD4",
-		"div.js-item",
-		"div.item",
-		"div.item-card",
+	if listings, err := p.parseInitialDataDocument(doc); err == nil {
+		log.Printf("Found %d items in embedded page data\n", len(listings))
+		return listings, nil
 	}
 
-	// Try each selector until we find items
+	var listings []models.Listing
+
+	// Try each item selector until we find items
 	found := false
-	for _, selector := range itemSelectors {
+	for _, selector := range p.opts.Selectors.Items {
 		items := doc.Find(selector)
 		if items.Length() > 0 {
 			log.Printf("Found %d items using selector: %s\n", items.Length(), selector)
@@ -795,6 +2212,7 @@ func ParseItemsFromHTML(htmlContent string) ([]models.Listing, error) {
 			items.Each(func(i int, item *goquery.Selection) {
 				listing := models.Listing{
 					Attributes: make(map[string]string),
+					IsActive:   true,
 				}
 
 				// Extract ID from data attribute or URL
@@ -805,79 +2223,66 @@ func ParseItemsFromHTML(htmlContent string) ([]models.Listing, error) {
 					if itemURLNode.Length() > 0 {
 						href, exists := itemURLNode.Attr("href")
 						if exists {
-							matches := itemIDRegex.FindStringSubmatch(href)
-							if len(matches) > 1 {
-								if matches[1] != "" {
-									id = matches[1]
-								} else if matches[2] != "" {
-									id = matches[2]
-								}
-							}
+							id = extractItemID(href)
 						}
 					}
 				}
 				listing.ID = id
 
 				// Extract title
-				titleSelectors := []string{
-					"h3[itemprop='name']",
-					"*[data-marker='item-title']",
-					"div.title",
-					"h3.title",
-					"a.title",
-					"div.snippet-title",
-				}
-
-				for _, titleSelector := range titleSelectors {
-					titleNode := item.Find(titleSelector).First()
-					if titleNode.Length() > 0 {
-						listing.Title = strings.TrimSpace(titleNode.Text())
-						break
-					}
-				}
+				listing.Title = cleanText(firstMatch(item, p.opts.Selectors.Titles).Text())
 
 				// If no title found yet, look for links with text
 				if listing.Title == "" {
 					item.Find("a").Each(func(_ int, a *goquery.Selection) {
-						if listing.Title == "" && strings.TrimSpace(a.Text()) != "" {
+						if listing.Title == "" && cleanText(a.Text()) != "" {
 							href, exists := a.Attr("href")
 							if exists && strings.Contains(href, "/item/") {
-								listing.Title = strings.TrimSpace(a.Text())
+								listing.Title = cleanText(a.Text())
 							}
 						}
 					})
 				}
 
-				// Extract URL
+				// Extract URL, falling back to the first link for cards linking the
+				// listing by its slug rather than an "/item/" path
 				urlNode := item.Find("a[href*='/item/']").First()
+				if urlNode.Length() == 0 {
+					urlNode = item.Find("a[href]").First()
+				}
 				if urlNode.Length() > 0 {
 					href, exists := urlNode.Attr("href")
 					if exists {
-						listing.URL = normalizeURL(href)
+						listing.URL = normalizeLink(p.opts.BaseURL, href)
 					}
 				}
 
-				// Extract price
-				priceSelectors := []string{
-					"*[data-marker='item-price']",
-					"span.price-text-_YGDY",
-					"span.price",
-					"div.price",
-					"span[itemprop='price']",
-					"div.snippet-price",
+				// Extract price, preferring the raw numeric value from data attributes
+				if price, ok := priceFromAttributes(item, cleanText(item.Find("*[data-marker='item-price']").First().Text())); ok {
+					listing.Price = price
 				}
 
-				for _, priceSelector := range priceSelectors {
+				for _, priceSelector := range p.opts.Selectors.Prices {
+					if listing.Price.Value > 0 {
+						break
+					}
+
 					priceNode := item.Find(priceSelector).First()
 					if priceNode.Length() > 0 {
-						priceText := strings.TrimSpace(priceNode.Text())
+						priceText := cleanText(priceNode.Text())
 						if priceText != "" {
-							listing.Price = parsePrice(priceText)
+							listing.Price = p.parsePrice(priceText)
 							break
 						}
 					}
 				}
 
+				listing.DeliveryAvailable = hasDeliveryBadge(item)
+				listing.IsPromoted = isPromotedCard(item)
+				listing.SellerType = cardSellerType(item)
+				listing.PhotoCount = cardPhotoCount(item)
+				listing.PricePerSquareMeter = pricePerSquareMeter(listing)
+
 				// Only add if we have at least a title or URL
 				if listing.Title != "" || listing.URL != "" {
 					listings = append(listings, listing)
@@ -897,11 +2302,11 @@ func ParseItemsFromHTML(htmlContent string) ([]models.Listing, error) {
 		doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
 			href, exists := a.Attr("href")
 			if exists && strings.Contains(href, "/item/") {
-				title := strings.TrimSpace(a.Text())
+				title := cleanText(a.Text())
 
 				// If no text in the anchor itself, look for text in children
 				if title == "" {
-					title = strings.TrimSpace(a.Find("h3, div.title, span.title").First().Text())
+					title = cleanText(a.Find("h3, div.title, span.title").First().Text())
 				}
 
 				// Skip if no title found
@@ -910,26 +2315,20 @@ func ParseItemsFromHTML(htmlContent string) ([]models.Listing, error) {
 				}
 
 				listing := models.Listing{
-					Title: title,
-					URL:   normalizeURL(href),
+					Title:    title,
+					URL:      normalizeLink(p.opts.BaseURL, href),
+					IsActive: true,
 				}
 
 				// Extract ID from URL
-				matches := itemIDRegex.FindStringSubmatch(href)
-				if len(matches) > 1 {
-					if matches[1] != "" {
-						listing.ID = matches[1]
-					} else if matches[2] != "" {
-						listing.ID = matches[2]
-					}
-				}
+				listing.ID = extractItemID(href)
 
 				// Look for price near this element
 				// Either a sibling or a child within the parent container
 				parent := a.Parent()
-				priceText := strings.TrimSpace(parent.Find("span.price, div.price, *[data-marker='item-price']").First().Text())
+				priceText := cleanText(parent.Find("span.price, div.price, *[data-marker='item-price']").First().Text())
 				if priceText != "" {
-					listing.Price = parsePrice(priceText)
+					listing.Price = p.parsePrice(priceText)
 				}
 
 				listings = append(listings, listing)
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestExtractItemID(t *testing.T) {
+	tests := []struct {
+		rawURL, want string
+	}{
+		{"https://www.avito.ru/moskva/telefony/iphone_15_4567891234", "4567891234"},
+		{"https://www.avito.ru/moskva/telefony/iphone_15_4567891234?context=H4sIAAAA&slocation=621540", "4567891234"},
+		{"https://www.avito.ru/moskva/telefony/iphone_15_4567891234/", "4567891234"},
+		{"https://www.avito.ru/moskva/telefony/iphone_15_4567891234/?utm_source=tg#photos", "4567891234"},
+		{"https://www.avito.ru/item/4567891234", "4567891234"},
+		{"https://www.avito.ru/item/iphone_15_4567891234", "4567891234"},
+		{"/moskva/kvartiry/2-k._kvartira_45m_59et._4567891234", "4567891234"},
+		// Category pages have no ID, even with a page number in the query
+		{"https://www.avito.ru/moskva/telefony", ""},
+		{"https://www.avito.ru/moskva/telefony?p=2", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := extractItemID(tt.rawURL); got != tt.want {
+			t.Errorf("extractItemID(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+func TestGetListingByURL(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	// Links as copied from a browser, with tracking parameters and a fragment, and
+	// as found on a page
+	for _, listingURL := range []string{
+		srv.URL + "/moskva/telefony/iphone_15_1111111111?context=H4sIAAAA&slocation=621540#photos",
+		"/moskva/telefony/iphone_15_1111111111",
+	} {
+		listing, err := p.GetListingByURL(listingURL)
+		if err != nil {
+			t.Fatalf("GetListingByURL(%q): %v", listingURL, err)
+		}
+
+		if listing.ID != "1111111111" || listing.URL != srv.URL+"/moskva/telefony/iphone_15_1111111111" {
+			t.Errorf("GetListingByURL(%q) = ID %q, URL %q", listingURL, listing.ID, listing.URL)
+		}
+		if listing.Title == "" || listing.Description == "" {
+			t.Errorf("GetListingByURL(%q) didn't fetch the details: %+v", listingURL, listing)
+		}
+	}
+
+	if hits := srv.Hits("/moskva/telefony/iphone_15_1111111111"); hits != 2 {
+		t.Errorf("listing page was requested %d times, want 2", hits)
+	}
+}
+
+func TestGetListingByURLRejectsOtherPages(t *testing.T) {
+	p, err := NewParser(DefaultParserOptions())
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	tests := []struct {
+		rawURL string
+		want   error
+	}{
+		{"", ErrEmptyURL},
+		{"   ", ErrEmptyURL},
+		{"https://www.avito.ru/moskva/telefony", ErrNotItemURL},
+		{"https://www.avito.ru/", ErrNotItemURL},
+		{"https://www.avito.ru/user/abc123/profile", ErrNotItemURL},
+		{"https://example.com/moskva/telefony/iphone_15_4567891234", ErrNotItemURL},
+		{"https://www.avito.ru.example.com/moskva/telefony/iphone_15_4567891234", ErrNotItemURL},
+	}
+
+	// None of them are requested, so the default parser never goes to the network
+	for _, tt := range tests {
+		if _, err := p.GetListingByURL(tt.rawURL); !errors.Is(err, tt.want) {
+			t.Errorf("GetListingByURL(%q) = %v, want %v", tt.rawURL, err, tt.want)
+		}
+	}
+}
+
+func TestParseItemsFromReader(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatalf("opening fixture: %v", err)
+	}
+	defer f.Close()
+
+	listings, err := ParseItemsFromReader(f)
+	if err != nil {
+		t.Fatalf("ParseItemsFromReader: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listing IDs = %v, want %v", got, want)
+	}
+	iphone := listings[0]
+	if iphone.Title != "iPhone 15" || iphone.Price.Value != 65000 || iphone.URL != "https://www.avito.ru/moskva/telefony/iphone_15_1111111111" {
+		t.Errorf("listing = %+v, want the iPhone at 65000 with an absolute URL", iphone)
+	}
+}
+
+func TestParseItemsFromReaderError(t *testing.T) {
+	errRead := errors.New("connection reset")
+
+	listings, err := ParseItemsFromReader(iotest.ErrReader(errRead))
+	if !errors.Is(err, ErrParseFailed) || !errors.Is(err, errRead) {
+		t.Errorf("ParseItemsFromReader error = %v, want ErrParseFailed wrapping the read error", err)
+	}
+	if listings != nil {
+		t.Errorf("ParseItemsFromReader returned listings %v alongside the error", listingIDs(listings))
+	}
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// loadMoreSelector matches "показать ещё" buttons that carry the URL of the next batch
+const loadMoreSelector = "*[data-marker='load-more'], *[data-marker='catalog-load-more'], *[data-more-url]"
+
+// Regex to find the URL of the "показать ещё" batch endpoint in a page's JSON state or in a batch response
+var loadMoreURLRegex = regexp.MustCompile(`"(?:loadMoreUrl|moreItemsUrl|nextPageUrl)"\s*:\s*"((?:[^"\\]|\\.)+)"`)
+
+// pageLink is the location of the next batch of results: either a results page
+// or a JSON batch from the endpoint behind the "показать ещё" button
+type pageLink struct {
+	url   string
+	batch bool
+}
+
+// loadMoreURL returns the "показать ещё" endpoint found in a results page body, if any
+func loadMoreURL(body []byte) string {
+	matches := loadMoreURLRegex.FindSubmatch(body)
+	if matches == nil {
+		return ""
+	}
+
+	// The URL is a JSON string, so slashes may be escaped
+	var link string
+	if err := json.Unmarshal([]byte(`"`+string(matches[1])+`"`), &link); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(link)
+}
+
+// loadMoreButtonURL returns the batch URL carried by a "показать ещё" button
+func loadMoreButtonURL(e *colly.HTMLElement) string {
+	for _, attr := range []string{"data-more-url", "data-url", "href"} {
+		if link := strings.TrimSpace(e.Attr(attr)); link != "" && !strings.HasPrefix(link, "#") {
+			return e.Request.AbsoluteURL(link)
+		}
+	}
+	return ""
+}
+
+// parseItemsBatch parses a JSON batch of listings returned by the "показать ещё" endpoint.
+// It also returns the URL of the following batch when the response names one.
+func (p *Parser) parseItemsBatch(body []byte) ([]models.Listing, string, error) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, "", fmt.Errorf("%w: error decoding listings batch: %w", ErrParseFailed, err)
+	}
+
+	listings, err := p.listingsFromState(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return listings, loadMoreURL(body), nil
+}
+
+// scrapeListingsBatch fetches a JSON batch of listings from the "показать ещё" endpoint.
+// It also returns the URL of the following batch when the response names one.
+func (p *Parser) scrapeListingsBatch(ctx context.Context, batchURL, categoryURL string) ([]models.Listing, string, error) {
+	var listings []models.Listing
+	var nextURL string
+	var parseErr error
+
+	c := p.newCollector(ctx)
+	c.Limit(p.limitRule())
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Println("Visiting", r.URL)
+		r.Headers.Set("Accept", "application/json")
+		r.Headers.Set("X-Requested-With", "XMLHttpRequest")
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Println("Error:", err)
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		log.Printf("Received listings batch, size: %d bytes\n", len(r.Body))
+
+		batch, next, err := p.parseItemsBatch(r.Body)
+		if err != nil {
+			parseErr = err
+			return
+		}
+		if next != "" {
+			nextURL = r.Request.AbsoluteURL(next)
+		}
+
+		log.Printf("Found %d listings in batch\n", len(batch))
+		for _, listing := range batch {
+			listing.CategoryURL = categoryURL
+			p.applyExtractionLimits(&listing)
+			listings = append(listings, listing)
+		}
+	})
+
+	p.trackRequestStats(ctx, c)
+
+	if err := p.visit(ctx, c, batchURL); err != nil {
+		return nil, "", fmt.Errorf("error fetching listings batch: %w", err)
+	}
+
+	c.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	if parseErr != nil {
+		return nil, "", parseErr
+	}
+
+	return listings, nextURL, nil
+}
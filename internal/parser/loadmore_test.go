@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// loadMoreRoutes serve a results page with a "показать ещё" button and the two JSON
+// batches behind it. The page also links a second results page, which shouldn't be
+// needed while the batches last.
+var loadMoreRoutes = map[string]string{
+	"/moskva/telefony":         "category_loadmore.html",
+	"/moskva/telefony?p=2":     "category_page3.html",
+	"/web/1/catalog/items?p=2": "items_batch2.json",
+	"/web/1/catalog/items?p=3": "items_batch3.json",
+}
+
+func TestGetListingsFollowsLoadMoreBatches(t *testing.T) {
+	srv := newFixtureServer(t, loadMoreRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxPages: 3})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	// The iPhone repeated in the first batch and the banner are left out
+	want := []string{"1111111111", "2222222222", "3333333333", "4444444444", "5555555555"}
+	if got := listingIDs(listings); !reflect.DeepEqual(got, want) {
+		t.Fatalf("listing IDs = %v, want %v", got, want)
+	}
+	for _, listing := range listings {
+		if listing.CategoryURL != srv.URL+"/moskva/telefony" {
+			t.Errorf("listing %s has CategoryURL %q", listing.ID, listing.CategoryURL)
+		}
+	}
+	if pixel := listings[2]; pixel.URL != srv.URL+"/moskva/telefony/pixel_8_3333333333" || pixel.Price.Value != 45000 {
+		t.Errorf("batch listing = %+v, want the Pixel at 45000", pixel)
+	}
+
+	if hits := srv.Hits("/moskva/telefony?p=2"); hits != 0 {
+		t.Errorf("the second results page was requested %d times, want the batches instead", hits)
+	}
+	headers := srv.Headers()
+	if len(headers) != 3 {
+		t.Fatalf("server got %d requests, want the page and two batches", len(headers))
+	}
+	for _, header := range headers[1:] {
+		if got := header.Get("X-Requested-With"); got != "XMLHttpRequest" {
+			t.Errorf("batch request X-Requested-With = %q, want XMLHttpRequest", got)
+		}
+	}
+}
+
+func TestLoadMoreBatchesStopAtLimit(t *testing.T) {
+	srv := newFixtureServer(t, loadMoreRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxPages: 3})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 3)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222", "3333333333"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/web/1/catalog/items?p=3"); hits != 0 {
+		t.Errorf("the second batch was requested %d times after reaching the limit", hits)
+	}
+}
+
+func TestPagesWithoutLoadMoreArePaginated(t *testing.T) {
+	srv := newFixtureServer(t, planRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxPages: 2})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222", "3333333333"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/moskva/telefony?p=2"); hits != 1 {
+		t.Errorf("the second results page was requested %d times, want 1", hits)
+	}
+}
+
+func TestLoadMoreURL(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"escaped slashes", `{"loadMoreUrl":"\/web\/1\/catalog\/items?p=2"}`, "/web/1/catalog/items?p=2"},
+		{"more items", `window.state = {"moreItemsUrl": "/web/1/items?cursor=abc"};`, "/web/1/items?cursor=abc"},
+		{"next page", `{"nextPageUrl":"https://www.avito.ru/web/1/items?p=3"}`, "https://www.avito.ru/web/1/items?p=3"},
+		{"none", `{"items":[]}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loadMoreURL([]byte(tt.body)); got != tt.want {
+				t.Errorf("loadMoreURL(%s) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseItemsBatchRejectsInvalidJSON(t *testing.T) {
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	if _, _, err := p.parseItemsBatch([]byte("<html>")); err == nil {
+		t.Error("parseItemsBatch accepted an HTML page")
+	}
+}
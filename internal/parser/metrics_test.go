@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// metricRecorder collects what the metric hooks are called with
+type metricRecorder struct {
+	mu       sync.Mutex
+	requests []RequestStat
+	retries  []RetryStat
+	blocked  []RequestStat
+}
+
+// hook sets opts to report to the recorder
+func (m *metricRecorder) hook(opts ParserOptions) ParserOptions {
+	opts.OnRequestMetric = func(stat RequestStat) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.requests = append(m.requests, stat)
+	}
+	opts.OnRetryMetric = func(stat RetryStat) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.retries = append(m.retries, stat)
+	}
+	opts.OnBlockedMetric = func(stat RequestStat) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.blocked = append(m.blocked, stat)
+	}
+	return opts
+}
+
+func TestMetricHooksReportEveryRequest(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	var metrics metricRecorder
+	p := newFixtureParser(t, srv, metrics.hook(ParserOptions{}))
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 10); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if len(metrics.requests) != srv.TotalHits() {
+		t.Errorf("OnRequestMetric was called %d times for %d requests", len(metrics.requests), srv.TotalHits())
+	}
+	for _, stat := range metrics.requests {
+		if stat.Status != http.StatusOK || stat.URL == "" {
+			t.Errorf("request metric = %+v, want a 200 with its URL", stat)
+		}
+	}
+	if len(metrics.retries) != 0 || len(metrics.blocked) != 0 {
+		t.Errorf("got %d retry and %d blocked metrics, want none", len(metrics.retries), len(metrics.blocked))
+	}
+}
+
+func TestMetricHooksReportRetries(t *testing.T) {
+	srv, hits := rateLimitingServer(t, "")
+	var metrics metricRecorder
+	p := newTestParser(t, srv.URL, metrics.hook(ParserOptions{MaxRetries: 2, SkipDetails: true}))
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if len(metrics.requests) != int(hits.Load()) {
+		t.Errorf("OnRequestMetric was called %d times for %d requests", len(metrics.requests), hits.Load())
+	}
+	if len(metrics.retries) != 1 {
+		t.Fatalf("OnRetryMetric was called %d times, want 1", len(metrics.retries))
+	}
+	if retry := metrics.retries[0]; retry.Attempt != 1 || retry.Status != http.StatusTooManyRequests || retry.URL != srv.URL+"/moskva/telefony" {
+		t.Errorf("retry metric = %+v, want the first retry of the rate limited page", retry)
+	}
+}
+
+func TestMetricHooksReportBlockedPages(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "blocked.html"})
+	var metrics metricRecorder
+	p := newFixtureParser(t, srv, metrics.hook(ParserOptions{SkipDetails: true}))
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("GetListings error = %v, want ErrBlocked", err)
+	}
+
+	if len(metrics.blocked) != 1 || len(metrics.requests) != 1 {
+		t.Errorf("got %d blocked and %d request metrics, want 1 of each", len(metrics.blocked), len(metrics.requests))
+	}
+}
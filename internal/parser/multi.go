@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// GetListingsMulti fetches listings from several categories using the default parser
+func GetListingsMulti(categoryURLs []string, limitPerCategory int) ([]models.Listing, error) {
+	return defaultParser.GetListingsMulti(categoryURLs, limitPerCategory)
+}
+
+// GetListingsMultiContext fetches listings from several categories using the default parser,
+// aborting when the context is cancelled or its deadline expires
+func GetListingsMultiContext(ctx context.Context, categoryURLs []string, limitPerCategory int) ([]models.Listing, error) {
+	return defaultParser.GetListingsMultiContext(ctx, categoryURLs, limitPerCategory)
+}
+
+// GetListingsMulti fetches up to limitPerCategory listings from each category in turn and
+// merges them in category order. A listing found in several categories is kept once, with
+// the CategoryURL of the first category it was found in, so a category may contribute
+// fewer than limitPerCategory listings. A failure in one category doesn't stop the others;
+// the listings collected are returned with all errors joined together.
+func (p *Parser) GetListingsMulti(categoryURLs []string, limitPerCategory int) ([]models.Listing, error) {
+	return p.GetListingsMultiContext(context.Background(), categoryURLs, limitPerCategory)
+}
+
+// GetListingsMultiContext works like GetListingsMulti, aborting when the context is
+// cancelled or its deadline expires. The categories not reached are skipped and the
+// listings collected so far are returned with an error matching ctx.Err().
+func (p *Parser) GetListingsMultiContext(ctx context.Context, categoryURLs []string, limitPerCategory int) ([]models.Listing, error) {
+	var all []models.Listing
+	var errs []error
+	seen := make(map[string]bool)
+
+	for _, categoryURL := range categoryURLs {
+		log.Printf("Fetching listings from %s", categoryURL)
+
+		listings, err := p.GetListingsContext(ctx, categoryURL, limitPerCategory)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("category %s: %w", categoryURL, err))
+		}
+
+		for _, listing := range listings {
+			key := listingKey(listing)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if listing.CategoryURL == "" {
+				listing.CategoryURL = categoryURL
+			}
+			all = append(all, listing)
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return all, errors.Join(errs...)
+}
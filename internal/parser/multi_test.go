@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// multiRoutes serve two phone categories sharing the iPhone and the Samsung
+var multiRoutes = map[string]string{
+	"/moskva/telefony":  "category.html",
+	"/moskva/smartfony": "category_sellers.html",
+}
+
+func TestGetListingsMultiDedupesOverlappingCategories(t *testing.T) {
+	srv := newFixtureServer(t, multiRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	telefony, smartfony := srv.URL+"/moskva/telefony", srv.URL+"/moskva/smartfony"
+	listings, err := p.GetListingsMulti([]string{telefony, smartfony}, 0)
+	if err != nil {
+		t.Fatalf("GetListingsMulti: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222", "3333333333"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listing IDs = %v, want %v", got, want)
+	}
+
+	// Shared listings keep the first category they were found in
+	wantCategories := []string{telefony, telefony, smartfony}
+	for i, listing := range listings {
+		if listing.CategoryURL != wantCategories[i] {
+			t.Errorf("listing %s has CategoryURL %q, want %q", listing.ID, listing.CategoryURL, wantCategories[i])
+		}
+	}
+}
+
+func TestGetListingsMultiLimitsEachCategory(t *testing.T) {
+	srv := newFixtureServer(t, multiRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	// The first two smartphones were already found among the phones
+	listings, err := p.GetListingsMulti([]string{srv.URL + "/moskva/smartfony", srv.URL + "/moskva/telefony"}, 1)
+	if err != nil {
+		t.Fatalf("GetListingsMulti: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+}
+
+func TestGetListingsMultiContinuesAfterFailedCategory(t *testing.T) {
+	srv := newFixtureServer(t, multiRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	missing := srv.URL + "/moskva/planshety"
+	listings, err := p.GetListingsMulti([]string{srv.URL + "/moskva/telefony", missing, srv.URL + "/moskva/smartfony"}, 0)
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("GetListingsMulti error = %v, want one naming %s", err, missing)
+	}
+	if got := listingIDs(listings); len(got) != 3 {
+		t.Errorf("listing IDs = %v, want the 3 from the categories that loaded", got)
+	}
+}
+
+func TestGetListingsMultiStopsWhenCancelled(t *testing.T) {
+	srv := newFixtureServer(t, multiRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := p.GetListingsMultiContext(ctx, []string{srv.URL + "/moskva/telefony", srv.URL + "/moskva/smartfony"}, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetListingsMultiContext error = %v, want context.Canceled", err)
+	}
+	if hits := srv.Hits("/moskva/smartfony"); hits != 0 {
+		t.Errorf("the second category was requested %d times after cancelling", hits)
+	}
+}
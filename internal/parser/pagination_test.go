@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// listingIDs returns the IDs of listings in order
+func listingIDs(listings []models.Listing) []string {
+	var ids []string
+	for _, listing := range listings {
+		ids = append(ids, listing.ID)
+	}
+	return ids
+}
+
+func TestMaxEmptyPagesStopsOnRepeatedPages(t *testing.T) {
+	// Avito repeats the last page for every page past it
+	routes := map[string]string{
+		"/moskva/telefony":     "category.html",
+		"/moskva/telefony?p=2": "category.html",
+		"/moskva/telefony?p=3": "category.html",
+		"/moskva/telefony?p=4": "category.html",
+	}
+
+	tests := []struct {
+		maxEmptyPages int
+		wantPages     int
+	}{
+		{maxEmptyPages: 1, wantPages: 2},
+		{maxEmptyPages: 2, wantPages: 3},
+	}
+
+	for _, tt := range tests {
+		srv := newFixtureServer(t, routes)
+		p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxPages: 10, MaxEmptyPages: tt.maxEmptyPages})
+
+		listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+		if err != nil {
+			t.Fatalf("MaxEmptyPages %d: GetListings: %v", tt.maxEmptyPages, err)
+		}
+		if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("MaxEmptyPages %d: got listings %v, want %v", tt.maxEmptyPages, got, want)
+		}
+		if hits := srv.TotalHits(); hits != tt.wantPages {
+			t.Errorf("MaxEmptyPages %d: fetched %d pages, want %d", tt.maxEmptyPages, hits, tt.wantPages)
+		}
+	}
+}
+
+func TestMaxEmptyPagesCountsConsecutivePages(t *testing.T) {
+	// A page with new listings between repeated ones resets the count
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony":     "category.html",
+		"/moskva/telefony?p=2": "category.html",
+		"/moskva/telefony?p=3": "category_page3.html",
+		"/moskva/telefony?p=4": "category.html",
+		"/moskva/telefony?p=5": "category_page3.html",
+		"/moskva/telefony?p=6": "category.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxPages: 10, MaxEmptyPages: 2})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222", "3333333333"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got listings %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/moskva/telefony?p=5"); hits != 1 {
+		t.Error("pagination stopped although page 3 had new listings")
+	}
+	if hits := srv.Hits("/moskva/telefony?p=6"); hits != 0 {
+		t.Error("pagination went on after two pages in a row without new listings")
+	}
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// interruptingServer serves fixtures like fixtureServer but cancels the scrape when
+// the interrupt route is requested, leaving that request to fail
+func interruptingServer(t *testing.T, routes map[string]string, interrupt string, cancel context.CancelFunc) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RequestURI() == interrupt {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+
+		fixture, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetListingsReturnsPartialResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := interruptingServer(t, phoneRoutes, "/moskva/telefony?p=2", cancel)
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true, MaxPages: 3})
+
+	listings, err := p.GetListingsContext(ctx, srv.URL+"/moskva/telefony", 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetListingsContext error = %v, want context.Canceled", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !slices.Equal(got, want) {
+		t.Errorf("listings = %v, want the first page's %v", got, want)
+	}
+}
+
+func TestCatalogPageReturnsPartialResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	routes := map[string]string{
+		"/catalog/telefony":            "catalog.html",
+		"/item/iphone_15_1111111111":   "item_iphone.html",
+		"/item/samsung_s24_2222222222": "item_samsung.html",
+	}
+	srv := interruptingServer(t, routes, "/item/samsung_s24_2222222222/", cancel)
+	p := newTestParser(t, srv.URL, ParserOptions{})
+
+	listings, err := p.GetListingsContext(ctx, srv.URL+"/catalog/telefony", 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetListingsContext error = %v, want context.Canceled", err)
+	}
+	if len(listings) == 0 || listings[0].ID != "1111111111" || listings[0].SellerName != "Иван" {
+		t.Errorf("listings = %+v, want the iPhone fetched before the failure", listings)
+	}
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestCardPhotoCounts(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "category_photos.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxImages: 1})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	want := map[string]int{
+		"1111111111": 4,  // slider
+		"2222222222": 12, // "12 фото" badge
+		"3333333333": 8,  // slider of 3 and "Ещё 5 фото"
+		"4444444444": 1,  // a single image
+		"5555555555": 0,  // no photos
+	}
+	if len(listings) != len(want) {
+		t.Fatalf("got %d listings, want %d", len(listings), len(want))
+	}
+	for _, listing := range listings {
+		if listing.PhotoCount != want[listing.ID] {
+			t.Errorf("listing %s: PhotoCount = %d, want %d", listing.ID, listing.PhotoCount, want[listing.ID])
+		}
+	}
+}
+
+func TestPagePhotoCount(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		maxImages int
+		wantCount int
+		wantURLs  int
+	}{
+		// The counter shows more photos than the gallery has loaded
+		{"item_gallery.html", 0, 9, 3},
+		// Limiting the image URLs leaves the count alone
+		{"item_gallery.html", 1, 9, 1},
+		{"item_iphone.html", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		listing := fetchFixtureListing(t, tt.fixture, ParserOptions{MaxImages: tt.maxImages})
+		if listing.PhotoCount != tt.wantCount || len(listing.ImageURLs) != tt.wantURLs {
+			t.Errorf("%s with MaxImages %d: PhotoCount = %d with %d image URLs, want %d with %d",
+				tt.fixture, tt.maxImages, listing.PhotoCount, len(listing.ImageURLs), tt.wantCount, tt.wantURLs)
+		}
+	}
+}
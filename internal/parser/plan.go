@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+)
+
+// Plan lists the URLs a GetListings call would visit, for auditing a scrape before running it
+type Plan struct {
+	// CategoryURL is the category URL after scoping it to the Region
+	CategoryURL string
+	// PageURLs are the results pages fetched to find listings, in order
+	PageURLs []string
+	// ItemURLs are the listing pages whose details would be fetched. It is empty
+	// with SkipDetails and leaves out listings already recorded in the Checkpoint.
+	ItemURLs []string
+}
+
+// URLs returns every planned URL, results pages first
+func (pl Plan) URLs() []string {
+	urls := make([]string, 0, len(pl.PageURLs)+len(pl.ItemURLs))
+	urls = append(urls, pl.PageURLs...)
+	return append(urls, pl.ItemURLs...)
+}
+
+// PlanListings returns the URLs GetListings would visit using the default parser
+func PlanListings(categoryURL string, limit int) (Plan, error) {
+	return defaultParser.PlanListings(categoryURL, limit)
+}
+
+// PlanListingsContext returns the URLs GetListings would visit using the default parser,
+// aborting when the context is cancelled or its deadline expires
+func PlanListingsContext(ctx context.Context, categoryURL string, limit int) (Plan, error) {
+	return defaultParser.PlanListingsContext(ctx, categoryURL, limit)
+}
+
+// PlanListings returns the URLs GetListings would visit for the same arguments without
+// scraping them. The results pages still have to be fetched to learn the listing URLs
+// and where pagination leads, but no listing page is requested.
+func (p *Parser) PlanListings(categoryURL string, limit int) (Plan, error) {
+	return p.PlanListingsContext(context.Background(), categoryURL, limit)
+}
+
+// PlanListingsContext is PlanListings with a context. Like GetListings, it returns
+// the plan made so far alongside any error.
+func (p *Parser) PlanListingsContext(ctx context.Context, categoryURL string, limit int) (Plan, error) {
+	if categoryURL == "" {
+		return Plan{}, fmt.Errorf("category: %w", ErrEmptyURL)
+	}
+
+	plan := Plan{CategoryURL: p.regionalURL(categoryURL)}
+	if catalogRegex.MatchString(plan.CategoryURL) {
+		return plan, fmt.Errorf("planning catalog pages is not supported: %s", plan.CategoryURL)
+	}
+	if shopRegex.MatchString(plan.CategoryURL) {
+		return plan, fmt.Errorf("planning shop pages is not supported: %s", plan.CategoryURL)
+	}
+
+	listings, pageURLs, err := p.collectListingPages(p.withRequestBudget(ctx), plan.CategoryURL, limit)
+	plan.PageURLs = pageURLs
+	if p.opts.SkipDetails {
+		return plan, err
+	}
+
+	for _, listing := range listings {
+		if _, ok := p.checkpointed(listing.URL); !ok {
+			plan.ItemURLs = append(plan.ItemURLs, listing.URL)
+		}
+	}
+
+	return plan, err
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// planRoutes serve two results pages of phones; the listing pages are routed too
+// so that any detail request would succeed and be counted
+var planRoutes = map[string]string{
+	"/moskva/telefony":                        "category.html",
+	"/moskva/telefony?p=2":                    "category_page3.html",
+	"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+	"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+	"/moskva/telefony/pixel_8_3333333333":     "item_iphone.html",
+}
+
+func TestPlanListingsFetchesNoDetails(t *testing.T) {
+	srv := newFixtureServer(t, planRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{MaxPages: 2})
+
+	plan, err := p.PlanListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("PlanListings: %v", err)
+	}
+
+	wantPages := []string{srv.URL + "/moskva/telefony", srv.URL + "/moskva/telefony?p=2"}
+	wantItems := []string{
+		srv.URL + "/moskva/telefony/iphone_15_1111111111",
+		srv.URL + "/moskva/telefony/samsung_s24_2222222222",
+		srv.URL + "/moskva/telefony/pixel_8_3333333333",
+	}
+	if plan.CategoryURL != srv.URL+"/moskva/telefony" {
+		t.Errorf("CategoryURL = %q", plan.CategoryURL)
+	}
+	if !reflect.DeepEqual(plan.PageURLs, wantPages) {
+		t.Errorf("PageURLs = %v, want %v", plan.PageURLs, wantPages)
+	}
+	if !reflect.DeepEqual(plan.ItemURLs, wantItems) {
+		t.Errorf("ItemURLs = %v, want %v", plan.ItemURLs, wantItems)
+	}
+	if got := plan.URLs(); len(got) != 5 || got[0] != wantPages[0] || got[4] != wantItems[2] {
+		t.Errorf("URLs = %v, want the pages then the items", got)
+	}
+
+	// Only the results pages were requested
+	if hits := srv.TotalHits(); hits != 2 {
+		t.Errorf("server got %d requests, want the 2 results pages", hits)
+	}
+	for _, itemURL := range wantItems {
+		if hits := srv.Hits(strings.TrimPrefix(itemURL, srv.URL)); hits != 0 {
+			t.Errorf("%s was fetched %d times", itemURL, hits)
+		}
+	}
+}
+
+func TestPlanListingsRespectsLimitAndSkipDetails(t *testing.T) {
+	srv := newFixtureServer(t, planRoutes)
+
+	p := newFixtureParser(t, srv, ParserOptions{MaxPages: 2})
+	plan, err := p.PlanListings(srv.URL+"/moskva/telefony", 1)
+	if err != nil {
+		t.Fatalf("PlanListings: %v", err)
+	}
+	if len(plan.PageURLs) != 1 || len(plan.ItemURLs) != 1 {
+		t.Errorf("plan with a limit of 1 = %+v, want one page and one item", plan)
+	}
+
+	p = newFixtureParser(t, srv, ParserOptions{MaxPages: 2, SkipDetails: true})
+	plan, err = p.PlanListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("PlanListings: %v", err)
+	}
+	if len(plan.PageURLs) != 2 || len(plan.ItemURLs) != 0 {
+		t.Errorf("plan with SkipDetails = %+v, want pages only", plan)
+	}
+}
+
+func TestPlanListingsEmptyURL(t *testing.T) {
+	p, err := NewParser(DefaultParserOptions())
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	if _, err := p.PlanListings("", 10); !errors.Is(err, ErrEmptyURL) {
+		t.Errorf("PlanListings = %v, want ErrEmptyURL", err)
+	}
+}
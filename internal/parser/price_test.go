@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// priceCard is a category page with a single card whose price is only given as text
+const priceCard = `<div data-marker="item" data-item-id="1111111111">
+	<a href="/moskva/posuda/tarelka_1111111111"><h3 itemprop="name">Тарелка</h3></a>
+	<span data-marker="item-price">%s</span>
+</div>`
+
+// parseCardPrice parses the price of priceCard showing text with p
+func parseCardPrice(t *testing.T, p *Parser, text string) models.Price {
+	t.Helper()
+
+	listings, err := p.ParseItemsFromHTML(strings.Replace(priceCard, "%s", text, 1))
+	if err != nil {
+		t.Fatalf("ParseItemsFromHTML: %v", err)
+	}
+	if len(listings) != 1 {
+		t.Fatalf("got %d listings, want 1", len(listings))
+	}
+	return listings[0].Price
+}
+
+func TestDefaultPriceParsersHandleStartingPrices(t *testing.T) {
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	price := parseCardPrice(t, p, "от 1 500 ₽")
+	if !price.From || price.Value != 1500 || price.Min != 1500 {
+		t.Errorf("price = %+v, want a starting price of 1500", price)
+	}
+
+	price = parseCardPrice(t, p, "2 000 ₽")
+	if price.From || price.Value != 2000 {
+		t.Errorf("price = %+v, want a plain price of 2000", price)
+	}
+}
+
+func TestPriceParsersOption(t *testing.T) {
+	// Wholesale prices like "50 ₽ / шт. от 100 шт." only quote the unit price
+	wholesale := func(priceText string) (models.Price, bool) {
+		if !strings.Contains(priceText, "от 100 шт") {
+			return models.Price{}, false
+		}
+		return models.Price{Value: 50, Currency: "RUB", Unit: "шт", Text: priceText}, true
+	}
+
+	custom, err := NewParser(ParserOptions{PriceParsers: []PriceParser{wholesale}})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	plain, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	text := "50 ₽ / шт. от 100 шт."
+	if price := parseCardPrice(t, custom, text); price.Value != 50 || price.Unit != "шт" {
+		t.Errorf("custom parser price = %+v, want 50 per piece", price)
+	}
+
+	// Text the custom parser rejects falls through to the default parser
+	if price := parseCardPrice(t, custom, "3 000 ₽"); price.Value != 3000 {
+		t.Errorf("fallback price = %+v, want 3000", price)
+	}
+
+	// Other Parsers keep the default chain
+	if price := parseCardPrice(t, plain, text); price.Unit != "" {
+		t.Errorf("custom price parser leaked to another Parser: %+v", price)
+	}
+}
+
+func TestPriceFromAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		card string
+		want models.Price
+	}{
+		{
+			name: "data-price with a lazy-loaded placeholder",
+			card: `<span data-marker="item-price" data-price="12500"><span class="price-token"></span></span>`,
+			want: models.Price{Value: 12500, Currency: "RUB"},
+		},
+		{
+			name: "data-price overriding the visible text",
+			card: `<span data-marker="item-price" data-price="12500">12 5OO ₽</span>`,
+			want: models.Price{Value: 12500, Currency: "RUB", Text: "12 5OO ₽"},
+		},
+		{
+			name: "microdata price and currency",
+			card: `<span data-marker="item-price"><meta itemprop="priceCurrency" content="USD"><meta itemprop="price" content="300">$300</span>`,
+			want: models.Price{Value: 300, Currency: "USD", Text: "$300"},
+		},
+		{
+			name: "unusable data-price falls back to the text",
+			card: `<span data-marker="item-price" data-price="">4 000 ₽</span>`,
+			want: models.Price{Value: 4000, Currency: "RUB", Text: "4 000 ₽"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := `<div data-marker="item" data-item-id="1111111111">
+				<a href="/moskva/posuda/tarelka_1111111111"><h3 itemprop="name">Тарелка</h3></a>` + tt.card + `</div>`
+			listings, err := ParseItemsFromHTML(html)
+			if err != nil {
+				t.Fatalf("ParseItemsFromHTML: %v", err)
+			}
+			if len(listings) != 1 {
+				t.Fatalf("got %d listings, want 1", len(listings))
+			}
+			if got := listings[0].Price; got != tt.want {
+				t.Errorf("price = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePriceFormats(t *testing.T) {
+	tests := []struct {
+		text string
+		want models.Price
+	}{
+		{"1 500 000 ₽", models.Price{Value: 1500000, Currency: "RUB"}},
+		{"от 1 500 000 ₽", models.Price{Value: 1500000, Min: 1500000, From: true, Currency: "RUB"}},
+		{"до 30 000 ₽", models.Price{Value: 30000, Max: 30000, Currency: "RUB"}},
+		{"1 000 – 2 000 ₽", models.Price{Value: 1000, Min: 1000, Max: 2000, IsRange: true, Currency: "RUB"}},
+		{"1 000-2 000 ₽", models.Price{Value: 1000, Min: 1000, Max: 2000, IsRange: true, Currency: "RUB"}},
+		{"Цена договорная", models.Price{Negotiable: true, Currency: "RUB"}},
+		{"Договорная", models.Price{Negotiable: true, Currency: "RUB"}},
+		{"Бесплатно", models.Price{Currency: "RUB"}},
+	}
+
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tt.want.Text = tt.text
+			if got := p.parsePrice(tt.text); got != tt.want {
+				t.Errorf("parsePrice(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePriceUnits(t *testing.T) {
+	tests := []struct {
+		text  string
+		value float64
+		unit  string
+	}{
+		{"45 000 ₽ в месяц", 45000, models.PriceUnitMonth},
+		{"45 000 ₽ за месяц", 45000, models.PriceUnitMonth},
+		{"3 500 ₽ в сутки", 3500, models.PriceUnitDay},
+		{"3 500 ₽ за сутки", 3500, models.PriceUnitDay},
+		{"800 ₽ в час", 800, models.PriceUnitHour},
+		{"120 000 ₽ за м²", 120000, models.PriceUnitSquareMeter},
+		{"120 000 ₽ за м2", 120000, models.PriceUnitSquareMeter},
+		{"250 000 ₽ за сот.", 250000, models.PriceUnitSotka},
+		{"50 ₽ за шт.", 50, models.PriceUnitPiece},
+		{"8 500 000 ₽", 8500000, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			price := parseDefaultPrice(tt.text)
+			if price.Unit != tt.unit {
+				t.Errorf("parseDefaultPrice(%q).Unit = %q, want %q", tt.text, price.Unit, tt.unit)
+			}
+			if price.Value != tt.value {
+				t.Errorf("parseDefaultPrice(%q).Value = %v, want %v", tt.text, price.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestParsePriceSeparatorsAndCurrencies(t *testing.T) {
+	tests := []struct {
+		text     string
+		value    float64
+		currency string
+	}{
+		{"65 000 ₽", 65000, "RUB"},
+		{"65\u00a0000\u00a0₽", 65000, "RUB"},
+		{"1\u202f234\u202f567 ₽", 1234567, "RUB"},
+		{"1,234,567 ₽", 1234567, "RUB"},
+		{"1.234.567 руб.", 1234567, "RUB"},
+		{"1 234 567,89 ₽", 1234567.89, "RUB"},
+		{"$1,299.99", 1299.99, "USD"},
+		{"1 299,99 $", 1299.99, "USD"},
+		{"2.500,50 €", 2500.5, "EUR"},
+		{"€ 2,500", 2500, "EUR"},
+		{"15 000 ₸", 15000, "KZT"},
+		{"15 000 тенге", 15000, "KZT"},
+		{"₴ 2 500", 2500, "UAH"},
+		{"2 500 грн.", 2500, "UAH"},
+		{"99,9 ₽", 99.9, "RUB"},
+	}
+
+	p, err := NewParser(ParserOptions{})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+
+	for _, tt := range tests {
+		got := p.parsePrice(tt.text)
+		if got.Value != tt.value || got.Currency != tt.currency {
+			t.Errorf("parsePrice(%q) = %v %s, want %v %s", tt.text, got.Value, got.Currency, tt.value, tt.currency)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"1 234 567", 1234567, true},
+		{"1,234,567", 1234567, true},
+		{"1.234.567", 1234567, true},
+		{"1,234,567.89", 1234567.89, true},
+		{"1.234.567,89", 1234567.89, true},
+		// A lone separator followed by three digits groups them
+		{"1,234", 1234, true},
+		{"1.234", 1234, true},
+		{"54,5", 54.5, true},
+		{"0,125", 0.125, true},
+		{"45.", 45, true},
+		{"", 0, false},
+		{"1-2", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
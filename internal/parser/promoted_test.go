@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPromotedListingsIncluded(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "category_promoted.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	promoted := map[string]bool{}
+	for _, listing := range listings {
+		promoted[listing.ID] = listing.IsPromoted
+	}
+	want := map[string]bool{
+		"9999999999": true,
+		"1111111111": false,
+		"8888888888": true,
+		"7777777777": true,
+		"2222222222": false,
+	}
+	if !reflect.DeepEqual(promoted, want) {
+		t.Errorf("IsPromoted by ID = %v, want %v", promoted, want)
+	}
+}
+
+func TestExcludePromoted(t *testing.T) {
+	routes := map[string]string{
+		"/moskva/telefony":                        "category_promoted.html",
+		"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+		"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+	}
+	srv := newFixtureServer(t, routes)
+	p := newFixtureParser(t, srv, ParserOptions{ExcludePromoted: true})
+
+	// Promoted listings don't count toward the limit and their pages aren't fetched
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 2)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+	if hits := srv.TotalHits(); hits != 3 {
+		t.Errorf("server got %d requests, want the category and the 2 organic listing pages", hits)
+	}
+}
+
+func TestIsPromotedCard(t *testing.T) {
+	tests := []struct {
+		name string
+		card string
+		want bool
+	}{
+		{"vip marker", `<div data-marker="item-vip">VIP</div>`, true},
+		{"vas badges", `<div data-marker="item-vas-badges"></div>`, true},
+		{"vip badge", `<span data-marker="vip-badge"></span>`, true},
+		{"premium badge text", `<span>Премиум</span>`, true},
+		{"highlighted badge text", `<div><i>Выделено</i></div>`, true},
+		{"organic", `<h3>iPhone 15</h3><span>65 000 ₽</span>`, false},
+		{"badge word in prose", `<p>VIP-обслуживание и реклама не нужны</p>`, false},
+		{"badge word in a longer label", `<span>Реклама на сайте</span>`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := parseFragment(t, `<div id="card">`+tt.card+`</div>`).Find("#card")
+			if got := isPromotedCard(card); got != tt.want {
+				t.Errorf("isPromotedCard = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for class, want := range map[string]bool{
+		"iva-item-root iva-item-vip": true,
+		"item item_premium":          true,
+		"item highlighted":           true,
+		"iva-item-root":              false,
+		"item-vipers":                false,
+	} {
+		card := parseFragment(t, `<div id="card" class="`+class+`"></div>`).Find("#card")
+		if got := isPromotedCard(card); got != want {
+			t.Errorf("isPromotedCard with class %q = %v, want %v", class, got, want)
+		}
+	}
+}
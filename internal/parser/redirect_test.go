@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// homepageRedirectServer redirects the stale category to Avito's homepage, which links
+// to listings of other categories, and serves the phones fixture otherwise. It counts
+// the requests for the homepage.
+func homepageRedirectServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	category, err := os.ReadFile(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const homepage = `<html><body><h1>Авито</h1>
+		<a href="/item/1010101010"><h3>Рекомендуем: диван</h3></a>
+		<a href="/item/2020202020"><h3>Рекомендуем: велосипед</h3></a>
+		</body></html>`
+
+	var homepageHits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/moskva/staraya_kategoriya":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/":
+			homepageHits.Add(1)
+			_, _ = w.Write([]byte(homepage))
+		case "/sankt-peterburg/telefony":
+			http.Redirect(w, r, "/moskva/telefony", http.StatusFound)
+		default:
+			_, _ = w.Write(category)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &homepageHits
+}
+
+func TestRedirectToHomepage(t *testing.T) {
+	srv, homepageHits := homepageRedirectServer(t)
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/staraya_kategoriya", 10)
+	if !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("GetListings error = %v, want ErrCategoryNotFound", err)
+	}
+	if len(listings) != 0 {
+		t.Errorf("GetListings returned %d listings from the homepage", len(listings))
+	}
+	if hits := homepageHits.Load(); hits != 1 {
+		t.Errorf("homepage was requested %d times, want only through the redirect", hits)
+	}
+
+	if _, err := p.CountListings(srv.URL + "/moskva/staraya_kategoriya"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("CountListings error = %v, want ErrCategoryNotFound", err)
+	}
+}
+
+func TestRedirectToSameDepthIsFollowed(t *testing.T) {
+	srv, _ := homepageRedirectServer(t)
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true})
+
+	// A region switch keeps the category, so its listings are still wanted
+	listings, err := p.GetListings(srv.URL+"/sankt-peterburg/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+}
+
+func TestRedirectedAway(t *testing.T) {
+	tests := []struct {
+		requested, final string
+		want             bool
+	}{
+		{"https://www.avito.ru/moskva/telefony", "https://www.avito.ru/", true},
+		{"https://www.avito.ru/moskva/telefony", "https://www.avito.ru/moskva", true},
+		{"https://www.avito.ru/moskva/telefony/apple", "https://www.avito.ru/moskva/telefony", true},
+		{"https://www.avito.ru/moskva/telefony", "https://www.avito.ru/moskva/telefony?p=1", false},
+		{"https://www.avito.ru/moskva/telefony", "https://www.avito.ru/sankt-peterburg/telefony", false},
+		{"https://www.avito.ru/moskva/telefony", "https://www.avito.ru/moskva/telefony/", false},
+	}
+
+	for _, tt := range tests {
+		final, err := url.Parse(tt.final)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := redirectedAway(tt.requested, final); got != tt.want {
+			t.Errorf("redirectedAway(%q, %q) = %v, want %v", tt.requested, tt.final, got, tt.want)
+		}
+	}
+}
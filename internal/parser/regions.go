@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// allRegions is the path segment Avito uses for country-wide results
+const allRegions = "all"
+
+// Regions maps the Avito URL slugs of known regions and cities to their names.
+// Add entries here to use regions that aren't listed.
+var Regions = map[string]string{
+	allRegions:              "Вся Россия",
+	"moskva":                "Москва",
+	"moskovskaya_oblast":    "Московская область",
+	"sankt-peterburg":       "Санкт-Петербург",
+	"leningradskaya_oblast": "Ленинградская область",
+	"novosibirsk":           "Новосибирск",
+	"ekaterinburg":          "Екатеринбург",
+	"kazan":                 "Казань",
+	"nizhniy_novgorod":      "Нижний Новгород",
+	"chelyabinsk":           "Челябинск",
+	"samara":                "Самара",
+	"omsk":                  "Омск",
+	"rostov-na-donu":        "Ростов-на-Дону",
+	"ufa":                   "Уфа",
+	"krasnoyarsk":           "Красноярск",
+	"voronezh":              "Воронеж",
+	"perm":                  "Пермь",
+	"volgograd":             "Волгоград",
+	"krasnodar":             "Краснодар",
+	"saratov":               "Саратов",
+	"tyumen":                "Тюмень",
+	"tolyatti":              "Тольятти",
+	"izhevsk":               "Ижевск",
+	"barnaul":               "Барнаул",
+	"ulyanovsk":             "Ульяновск",
+	"irkutsk":               "Иркутск",
+	"habarovsk":             "Хабаровск",
+	"yaroslavl":             "Ярославль",
+	"vladivostok":           "Владивосток",
+	"mahachkala":            "Махачкала",
+	"tomsk":                 "Томск",
+	"orenburg":              "Оренбург",
+	"kemerovo":              "Кемерово",
+	"novokuznetsk":          "Новокузнецк",
+	"ryazan":                "Рязань",
+	"astrahan":              "Астрахань",
+	"naberezhnye_chelny":    "Набережные Челны",
+	"penza":                 "Пенза",
+	"lipetsk":               "Липецк",
+	"kirov":                 "Киров",
+	"cheboksary":            "Чебоксары",
+	"tula":                  "Тула",
+	"kaliningrad":           "Калининград",
+	"sochi":                 "Сочи",
+}
+
+// validateRegion returns an error if region isn't one of the known Regions
+func validateRegion(region string) error {
+	if _, ok := Regions[region]; !ok {
+		return fmt.Errorf("unknown region: %q", region)
+	}
+	return nil
+}
+
+// WithRegion rewrites a country-wide Avito URL such as "https://www.avito.ru/all/avtomobili"
+// or a search URL "https://www.avito.ru/all?q=..." to the given region.
+// URLs that are already scoped to a region are returned unchanged.
+func WithRegion(rawURL, region string) (string, error) {
+	if err := validateRegion(region); err != nil {
+		return "", err
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	path := strings.TrimPrefix(parsedURL.Path, "/")
+	first, rest, _ := strings.Cut(path, "/")
+	if first != allRegions {
+		return rawURL, nil
+	}
+
+	parsedURL.Path = "/" + region
+	if rest != "" {
+		parsedURL.Path += "/" + rest
+	}
+	return parsedURL.String(), nil
+}
+
+// CategoriesForRegion returns a copy of categories with every URL scoped to region
+func CategoriesForRegion(categories []models.Category, region string) ([]models.Category, error) {
+	result := make([]models.Category, 0, len(categories))
+
+	for _, category := range categories {
+		regionalURL, err := WithRegion(category.URL, region)
+		if err != nil {
+			return nil, err
+		}
+		category.URL = regionalURL
+
+		if len(category.Subcategories) > 0 {
+			category.Subcategories, err = CategoriesForRegion(category.Subcategories, region)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, category)
+	}
+
+	return result, nil
+}
+
+// regionalURL scopes rawURL to the Parser's Region, if one is set
+func (p *Parser) regionalURL(rawURL string) string {
+	if p.opts.Region == "" {
+		return rawURL
+	}
+
+	// Region was validated in NewParser, so only a malformed URL can fail here
+	scoped, err := WithRegion(rawURL, p.opts.Region)
+	if err != nil {
+		return rawURL
+	}
+	return scoped
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestWithRegion(t *testing.T) {
+	tests := []struct {
+		rawURL, region, want string
+	}{
+		{"https://www.avito.ru/all/avtomobili", "moskva", "https://www.avito.ru/moskva/avtomobili"},
+		{"https://www.avito.ru/all/avtomobili", "sankt-peterburg", "https://www.avito.ru/sankt-peterburg/avtomobili"},
+		{"https://www.avito.ru/all/nedvizhimost/kvartiry/sdam", "kazan", "https://www.avito.ru/kazan/nedvizhimost/kvartiry/sdam"},
+		{"https://www.avito.ru/all?q=iphone", "sankt-peterburg", "https://www.avito.ru/sankt-peterburg?q=iphone"},
+		// URLs already scoped to a region are left alone
+		{"https://www.avito.ru/kazan/avtomobili", "moskva", "https://www.avito.ru/kazan/avtomobili"},
+	}
+
+	for _, tt := range tests {
+		got, err := WithRegion(tt.rawURL, tt.region)
+		if err != nil {
+			t.Errorf("WithRegion(%q, %q): %v", tt.rawURL, tt.region, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("WithRegion(%q, %q) = %q, want %q", tt.rawURL, tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestWithUnknownRegion(t *testing.T) {
+	if got, err := WithRegion("https://www.avito.ru/all/avtomobili", "moskow"); err == nil {
+		t.Errorf("WithRegion with an unknown region = %q, want an error", got)
+	}
+	if _, err := NewParser(ParserOptions{Region: "moskow"}); err == nil {
+		t.Error("NewParser accepted an unknown region")
+	}
+}
+
+func TestCategoriesForRegion(t *testing.T) {
+	tree, err := GetCategories()
+	if err != nil {
+		t.Fatalf("GetCategories: %v", err)
+	}
+
+	regional, err := CategoriesForRegion(tree, "sankt-peterburg")
+	if err != nil {
+		t.Fatalf("CategoriesForRegion: %v", err)
+	}
+
+	if got, want := regional[0].URL, "https://www.avito.ru/sankt-peterburg/transport"; got != want {
+		t.Errorf("top category URL = %q, want %q", got, want)
+	}
+	if got, want := regional[0].Subcategories[0].URL, "https://www.avito.ru/sankt-peterburg/avtomobili"; got != want {
+		t.Errorf("subcategory URL = %q, want %q", got, want)
+	}
+	// The tree passed in isn't modified
+	if got := tree[0].Subcategories[0].URL; got != "https://www.avito.ru/all/avtomobili" {
+		t.Errorf("original subcategory URL changed to %q", got)
+	}
+}
+
+func TestGetListingsInRegion(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{Region: "moskva", SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/all/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+	if hits := srv.Hits("/moskva/telefony"); hits != 1 {
+		t.Errorf("regional category page was requested %d times, want 1", hits)
+	}
+}
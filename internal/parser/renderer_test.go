@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// stubRenderer returns a fixture page, or err, and records the URLs it was asked to render
+type stubRenderer struct {
+	fixture string
+	err     error
+	urls    []string
+}
+
+func (r *stubRenderer) RenderHTML(url string) (string, error) {
+	r.urls = append(r.urls, url)
+	if r.err != nil {
+		return "", r.err
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", r.fixture))
+	return string(body), err
+}
+
+func TestRendererParsesPagesWithoutStaticItems(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "empty.html"})
+	renderer := &stubRenderer{fixture: "category.html"}
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, Renderer: renderer})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if want := []string{srv.URL + "/moskva/telefony"}; !reflect.DeepEqual(renderer.urls, want) {
+		t.Errorf("rendered %v, want %v", renderer.urls, want)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+	for _, listing := range listings {
+		if listing.CategoryURL != srv.URL+"/moskva/telefony" {
+			t.Errorf("listing %s has CategoryURL %q", listing.ID, listing.CategoryURL)
+		}
+	}
+}
+
+func TestRendererSkippedForStaticItems(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "category.html"})
+	renderer := &stubRenderer{fixture: "catalog.html"}
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, Renderer: renderer})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if len(renderer.urls) != 0 {
+		t.Errorf("rendered %v although the static page had listings", renderer.urls)
+	}
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+}
+
+func TestRendererError(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "empty.html"})
+	errRender := errors.New("browser crashed")
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, Renderer: &stubRenderer{err: errRender}})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); !errors.Is(err, errRender) {
+		t.Errorf("GetListings error = %v, want the Renderer's error", err)
+	}
+}
@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// phoneRoutes serve a category page with two listings and their listing pages
+var phoneRoutes = map[string]string{
+	"/moskva/telefony":                        "category.html",
+	"/moskva/telefony/iphone_15_1111111111":   "item_iphone.html",
+	"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+}
+
+func TestGetListingsReplaysFixtures(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	want := []models.Listing{
+		{
+			ID:              "1111111111",
+			Title:           "iPhone 15",
+			Description:     "Отличный телефон, полный комплект.",
+			DescriptionHTML: "<p>Отличный телефон, полный комплект.</p>",
+			Price:           models.Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+			URL:             srv.URL + "/moskva/telefony/iphone_15_1111111111",
+			Location:        "Москва, Тверская ул., 1",
+			CategoryID:      "telefony",
+			CategoryPath:    []string{"Электроника", "Телефоны"},
+			CategoryURL:     srv.URL + "/moskva/telefony",
+			PublishedAt:     time.Date(2024, time.March, 5, 10, 15, 0, 0, moscowLocation),
+			Attributes:      map[string]string{"Состояние": "Б/у", "Память": "128 ГБ"},
+			AttributesList: []models.KeyValue{
+				{Key: "Состояние", Value: "Б/у"},
+				{Key: "Память", Value: "128 ГБ", Number: 128, Unit: "ГБ", IsNumeric: true},
+			},
+			IsActive:   true,
+			Condition:  "used",
+			SellerName: "Иван",
+			SellerType: models.SellerTypePrivate,
+			SellerURL:  srv.URL + "/user/abc123/profile",
+		},
+		{
+			ID:                "2222222222",
+			Title:             "Samsung Galaxy S24",
+			Description:       "Новый, в плёнке.",
+			DescriptionHTML:   "<p>Новый, в плёнке.</p>",
+			Price:             models.Price{Value: 54000, Currency: "RUB", Text: "54 000 ₽"},
+			URL:               srv.URL + "/moskva/telefony/samsung_s24_2222222222",
+			Location:          "Москва, Арбат, 10",
+			CategoryURL:       srv.URL + "/moskva/telefony",
+			PublishedAt:       time.Date(2024, time.April, 1, 9, 0, 0, 0, moscowLocation),
+			Attributes:        map[string]string{},
+			DeliveryAvailable: true,
+			IsActive:          true,
+			SellerName:        "Phone Shop",
+			SellerType:        models.SellerTypeCompany,
+			SellerURL:         srv.URL + "/brands/phoneshop",
+		},
+	}
+	if !reflect.DeepEqual(listings, want) {
+		t.Errorf("GetListings returned\n%#v\nwant\n%#v", listings, want)
+	}
+
+	for route := range phoneRoutes {
+		if hits := srv.Hits(route); hits != 1 {
+			t.Errorf("%s was requested %d times, want 1", route, hits)
+		}
+	}
+}
+
+func TestGetListingsSkipDetailsReplaysCards(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	var titles []string
+	for _, listing := range listings {
+		titles = append(titles, listing.Title)
+		if !strings.HasPrefix(listing.URL, srv.URL+"/") {
+			t.Errorf("listing URL %q isn't resolved against the base URL", listing.URL)
+		}
+	}
+	if want := []string{"iPhone 15", "Samsung Galaxy S24"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+	if hits := srv.TotalHits(); hits != 1 {
+		t.Errorf("server got %d requests, want only the category page", hits)
+	}
+}
+
+func TestParsersWithDifferentBaseURLs(t *testing.T) {
+	first := newFixtureServer(t, phoneRoutes)
+	second := newFixtureServer(t, phoneRoutes)
+	parsers := map[*fixtureServer]*Parser{
+		first:  newFixtureParser(t, first, ParserOptions{SkipDetails: true}),
+		second: newFixtureParser(t, second, ParserOptions{SkipDetails: true}),
+	}
+
+	var wg sync.WaitGroup
+	for srv, p := range parsers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+			if err != nil {
+				t.Errorf("GetListings from %s: %v", srv.URL, err)
+				return
+			}
+			for _, listing := range listings {
+				if !strings.HasPrefix(listing.URL, srv.URL+"/") {
+					t.Errorf("listing from %s has URL %q", srv.URL, listing.URL)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The default Parser still resolves links against Avito
+	if got := defaultParser.opts.BaseURL; got != defaultBaseURL {
+		t.Errorf("default parser base URL = %q, want %q", got, defaultBaseURL)
+	}
+}
+
+func TestNewParserBaseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseURL     string
+		wantBaseURL string
+		wantDomains []string
+		wantErr     bool
+	}{
+		{name: "default", wantBaseURL: defaultBaseURL},
+		{name: "local server", baseURL: "http://127.0.0.1:8080/", wantBaseURL: "http://127.0.0.1:8080", wantDomains: []string{"127.0.0.1"}},
+		{name: "avito", baseURL: "https://www.avito.ru", wantBaseURL: defaultBaseURL},
+		{name: "relative", baseURL: "/moskva", wantErr: true},
+		{name: "other scheme", baseURL: "ftp://example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(ParserOptions{BaseURL: tt.baseURL})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewParser(%q) succeeded, want an error", tt.baseURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewParser(%q): %v", tt.baseURL, err)
+			}
+
+			if p.opts.BaseURL != tt.wantBaseURL {
+				t.Errorf("BaseURL = %q, want %q", p.opts.BaseURL, tt.wantBaseURL)
+			}
+			if !reflect.DeepEqual(p.opts.AllowedDomains, tt.wantDomains) {
+				t.Errorf("AllowedDomains = %q, want %q", p.opts.AllowedDomains, tt.wantDomains)
+			}
+		})
+	}
+}
+
+func TestSkipDetailsIssuesNoDetailRequests(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony":  "category.html",
+		"/all?q=iphone":     "category.html",
+		"/catalog/telefony": "catalog.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	streamed := func(categoryURL string) ([]models.Listing, error) {
+		out, errs := p.StreamListings(context.Background(), categoryURL, 10)
+		var listings []models.Listing
+		var err error
+		for out != nil || errs != nil {
+			select {
+			case listing, ok := <-out:
+				if !ok {
+					out = nil
+					continue
+				}
+				listings = append(listings, listing)
+			case e, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				err = e
+			}
+		}
+		return listings, err
+	}
+
+	tests := []struct {
+		name  string
+		route string
+		call  func() ([]models.Listing, error)
+	}{
+		{"category", "/moskva/telefony", func() ([]models.Listing, error) { return p.GetListings(srv.URL+"/moskva/telefony", 10) }},
+		{"search", "/all?q=iphone", func() ([]models.Listing, error) { return p.SearchListings("iphone", 10) }},
+		{"catalog", "/catalog/telefony", func() ([]models.Listing, error) { return p.GetListings(srv.URL+"/catalog/telefony", 10) }},
+		{"stream", "/moskva/telefony", func() ([]models.Listing, error) { return streamed(srv.URL + "/moskva/telefony") }},
+	}
+
+	for _, tt := range tests {
+		before := srv.TotalHits()
+		listings, err := tt.call()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(listings) != 2 {
+			t.Errorf("%s: got %d listings, want 2", tt.name, len(listings))
+		}
+		if hits := srv.TotalHits() - before; hits != 1 {
+			t.Errorf("%s: server got %d requests, want only %s", tt.name, hits, tt.route)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Keys used to count retries in a request's colly context, which retries share
+const (
+	proxyAttemptsKey = "proxyAttempts"
+	retryAttemptsKey = "retryAttempts"
+)
+
+// backoffDelay returns the wait before retry attempt n (starting at 1). The delay doubles
+// with every attempt from base up to maxDelay, and a random half of it is jittered away
+// so that concurrent clients don't retry in lockstep.
+func backoffDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := maxDelay
+	if attempt < 63 {
+		if scaled := base << (attempt - 1); scaled > 0 && scaled < maxDelay {
+			delay = scaled
+		}
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retry is the single place failed requests are retried from, so that each failure
+// leads to at most one new request: through the next proxy while there is one left
+// to try, otherwise after a backoff when the response was a 429. Nothing is retried
+// when MaxRetries is 0.
+func (p *Parser) retry(ctx context.Context, r *colly.Response) {
+	if p.opts.MaxRetries == 0 {
+		return
+	}
+	if !p.retryOnNextProxy(ctx, r) {
+		p.retryWithBackoff(ctx, r)
+	}
+}
+
+// retryWithBackoff re-issues a request that was rate limited with a 429 after the
+// backoff the DelayStrategy gives, switching the user agent. When the response has a
+// Retry-After header the retry waits at least as long as it asks. Retries share the
+// request's colly context, so the attempt count carries over and stops at MaxRetries.
+// It reports whether a retry was made.
+func (p *Parser) retryWithBackoff(ctx context.Context, r *colly.Response) bool {
+	if r.StatusCode != http.StatusTooManyRequests || ctx.Err() != nil {
+		return false
+	}
+
+	attempt, _ := r.Ctx.GetAny(retryAttemptsKey).(int)
+	if attempt >= p.opts.MaxRetries {
+		log.Printf("Giving up on %s after %d retries", r.Request.URL, attempt)
+		return false
+	}
+	attempt++
+	r.Ctx.Put(retryAttemptsKey, attempt)
+
+	delay := p.delays.Delay(ctx, attempt)
+	if r.Headers != nil {
+		if retryAfter, ok := parseRetryAfter(r.Headers.Get("Retry-After"), time.Now()); ok {
+			delay = max(delay, retryAfter)
+		}
+	}
+	log.Printf("Rate limited, retry %d of %d in %v", attempt, p.opts.MaxRetries, delay)
+	if sleepContext(ctx, delay) != nil {
+		return false
+	}
+
+	current, _ := r.Ctx.GetAny(userAgentKey).(string)
+	r.Ctx.Put(userAgentKey, p.nextUserAgent(current))
+	p.countRetry(r, attempt, delay)
+	if err := r.Request.Retry(); err != nil {
+		log.Printf("Retry %d failed: %v", attempt, err)
+	}
+	return true
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// retryOnNextProxy re-issues a request that failed with a 429 or a connection error.
+// The proxy switcher rotates on every request, so the retry goes through the next proxy.
+// It reports false when there is no other proxy left to try.
+func (p *Parser) retryOnNextProxy(ctx context.Context, r *colly.Response) bool {
+	if len(p.opts.Proxies) < 2 || ctx.Err() != nil {
+		return false
+	}
+	if r.StatusCode != http.StatusTooManyRequests && r.StatusCode != 0 {
+		return false
+	}
+
+	attempts, _ := r.Ctx.GetAny(proxyAttemptsKey).(int)
+	if attempts >= len(p.opts.Proxies)-1 {
+		return false
+	}
+	r.Ctx.Put(proxyAttemptsKey, attempts+1)
+
+	log.Printf("Retrying %s through the next proxy", r.Request.URL)
+	p.countRetry(r, attempts+1, 0)
+	if err := r.Request.Retry(); err != nil {
+		log.Printf("Proxy retry failed: %v", err)
+	}
+	return true
+}
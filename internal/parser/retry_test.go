@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelayBounds(t *testing.T) {
+	const (
+		base     = 2 * time.Second
+		maxDelay = 60 * time.Second
+	)
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		nominal := maxDelay
+		if attempt <= 6 {
+			nominal = base << (attempt - 1) // 2s, 4s, ... 64s capped to 60s below
+		}
+		nominal = min(nominal, maxDelay)
+
+		for i := 0; i < 50; i++ {
+			delay := backoffDelay(attempt, base, maxDelay)
+			if delay < nominal/2 || delay > nominal {
+				t.Fatalf("backoffDelay(%d) = %v, want between %v and %v", attempt, delay, nominal/2, nominal)
+			}
+		}
+	}
+}
+
+func TestBackoffDelayJitters(t *testing.T) {
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		seen[backoffDelay(3, time.Second, time.Minute)] = true
+	}
+	if len(seen) < 2 {
+		t.Error("backoffDelay returned the same delay every time, want jitter")
+	}
+}
+
+func TestBackoffDelayWithoutBase(t *testing.T) {
+	if delay := backoffDelay(1, 0, 0); delay != 0 {
+		t.Errorf("backoffDelay with no base = %v, want 0", delay)
+	}
+}
+
+// rateLimitingServer answers the first request with a 429 carrying the Retry-After
+// value, if any, and later ones with the category fixture
+func rateLimitingServer(t *testing.T, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	page, err := os.ReadFile(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	srv, hits := rateLimitingServer(t, "5")
+	p := newTestParser(t, srv.URL, ParserOptions{MaxRetries: 1, SkipDetails: true})
+
+	// The backoff alone is zero, so a retry within the deadline would have ignored the header
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := p.GetListingsContext(ctx, srv.URL+"/moskva/telefony", 0)
+
+	if err == nil {
+		t.Fatal("GetListingsContext succeeded before Retry-After elapsed")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server got %d requests, want the retry held back", n)
+	}
+	if elapsed := time.Since(start); elapsed >= 5*time.Second {
+		t.Errorf("cancelled call took %v, want it to stop waiting at the deadline", elapsed)
+	}
+}
+
+func TestRetryAfterHeaderIsWaitedOut(t *testing.T) {
+	srv, hits := rateLimitingServer(t, "1")
+
+	var retries []RetryStat
+	p := newTestParser(t, srv.URL, ParserOptions{
+		MaxRetries:    1,
+		SkipDetails:   true,
+		OnRetryMetric: func(stat RetryStat) { retries = append(retries, stat) },
+	})
+
+	start := time.Now()
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if len(listings) != 2 || hits.Load() != 2 {
+		t.Errorf("got %d listings from %d requests, want 2 from 2", len(listings), hits.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retry came after %v, before Retry-After elapsed", elapsed)
+	}
+	if len(retries) != 1 || retries[0].Delay != time.Second || retries[0].Status != http.StatusTooManyRequests {
+		t.Errorf("retries = %+v, want one after 1s", retries)
+	}
+}
+
+func TestRetryWithoutRetryAfterHeader(t *testing.T) {
+	srv, hits := rateLimitingServer(t, "")
+	p := newTestParser(t, srv.URL, ParserOptions{MaxRetries: 1, SkipDetails: true})
+
+	// The zero backoff of the test parser applies
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := p.GetListingsContext(ctx, srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListingsContext: %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.April, 3, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"5", 5 * time.Second, true},
+		{" 120 ", 2 * time.Minute, true},
+		{"0", 0, true},
+		{"Wed, 03 Apr 2024 12:00:30 GMT", 30 * time.Second, true},
+		// A date in the past means the retry can go at once
+		{"Wed, 03 Apr 2024 11:59:00 GMT", 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// alwaysRateLimitedServer answers every request to route with a 429 and serves the
+// category fixture elsewhere. It counts the requests to route.
+func alwaysRateLimitedServer(t *testing.T, route string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	page, err := os.ReadFile(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == route {
+			hits.Add(1)
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetriesSendEachRequestOnce(t *testing.T) {
+	for _, maxRetries := range []int{0, 1, 3} {
+		srv, hits := alwaysRateLimitedServer(t, "/moskva/telefony")
+		p := newTestParser(t, srv.URL, ParserOptions{MaxRetries: maxRetries, SkipDetails: true})
+
+		_, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("MaxRetries %d: GetListings error = %v, want ErrRateLimited", maxRetries, err)
+		}
+		if got, want := hits.Load(), int32(maxRetries+1); got != want {
+			t.Errorf("MaxRetries %d: server got %d requests, want %d", maxRetries, got, want)
+		}
+	}
+}
+
+func TestRetriesOfListingPages(t *testing.T) {
+	const maxRetries = 2
+	srv, hits := alwaysRateLimitedServer(t, "/moskva/telefony/iphone_15_1111111111")
+	p := newTestParser(t, srv.URL, ParserOptions{MaxRetries: maxRetries})
+
+	// The Samsung page is served the category fixture, which isn't a listing page
+	_, _ = p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if got := hits.Load(); got != maxRetries+1 {
+		t.Errorf("the rate limited listing page got %d requests, want %d", got, maxRetries+1)
+	}
+}
+
+func TestServerErrorsAreNotRetried(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "oops", http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := newTestParser(t, srv.URL, ParserOptions{MaxRetries: 3, SkipDetails: true})
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err == nil {
+		t.Error("GetListings succeeded against a failing server")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1", got)
+	}
+}
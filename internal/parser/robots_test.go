@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+// robotsRoutes serve a robots.txt disallowing /all/ and the same category under
+// /all/ and /moskva/
+var robotsRoutes = map[string]string{
+	"/robots.txt":      "robots.txt",
+	"/all/telefony":    "category.html",
+	"/moskva/telefony": "category.html",
+}
+
+func TestRespectRobotsTxt(t *testing.T) {
+	srv := newFixtureServer(t, robotsRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{RespectRobotsTxt: true, SkipDetails: true})
+
+	_, err := p.GetListings(srv.URL+"/all/telefony", 10)
+	if !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("GetListings on a disallowed path = %v, want ErrDisallowedByRobots", err)
+	}
+	if hits := srv.Hits("/all/telefony"); hits != 0 {
+		t.Errorf("disallowed page was requested %d times", hits)
+	}
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings on an allowed path: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+	if hits := srv.Hits("/robots.txt"); hits == 0 {
+		t.Error("robots.txt wasn't requested")
+	}
+}
+
+func TestIgnoreRobotsTxtByDefault(t *testing.T) {
+	srv := newFixtureServer(t, robotsRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/all/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+	if hits := srv.Hits("/robots.txt"); hits != 0 {
+		t.Errorf("robots.txt was requested %d times, want 0", hits)
+	}
+}
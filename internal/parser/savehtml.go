@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// maxSavedNameLength caps the URL part of saved file names, well below file system limits
+const maxSavedNameLength = 150
+
+// Regex to match runs of characters that aren't safe in file names
+var unsafeFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// saveResponses writes the body of every response c receives to SaveHTMLDir
+func (p *Parser) saveResponses(c *colly.Collector) {
+	if p.opts.SaveHTMLDir == "" {
+		return
+	}
+
+	c.OnResponse(func(r *colly.Response) {
+		if err := os.MkdirAll(p.opts.SaveHTMLDir, 0o755); err != nil {
+			log.Printf("Error creating HTML directory: %v", err)
+			return
+		}
+
+		ext := ".html"
+		if strings.Contains(r.Headers.Get("Content-Type"), "json") {
+			ext = ".json"
+		}
+
+		path := filepath.Join(p.opts.SaveHTMLDir, savedFileName(r.Request.URL.String(), time.Now())+ext)
+		if err := os.WriteFile(path, r.Body, 0o644); err != nil {
+			log.Printf("Error saving response from %s: %v", r.Request.URL, err)
+		}
+	})
+}
+
+// savedFileName turns a URL and the time it was fetched into a file name without
+// an extension, e.g. "www.avito.ru_moskva_telefony_p_2_20240305T101530.123456789"
+func savedFileName(rawURL string, fetchedAt time.Time) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(rawURL, "https://"), "http://")
+	name = strings.Trim(unsafeFileNameRegex.ReplaceAllString(name, "_"), "_.")
+	if len(name) > maxSavedNameLength {
+		name = name[:maxSavedNameLength]
+	}
+	return name + "_" + fetchedAt.Format("20060102T150405.000000000")
+}
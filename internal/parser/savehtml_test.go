@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Regex to match the fetch time and extension ending a saved page's file name
+var savedTimeRegex = regexp.MustCompile(`^\d{8}T\d{6}\.\d{9}\.html$`)
+
+// savedFiles returns the names of the files in dir, sorted
+func savedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading %s: %v", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSaveHTMLDirWritesVisitedPages(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	dir := filepath.Join(t.TempDir(), "pages")
+	p := newFixtureParser(t, srv, ParserOptions{SaveHTMLDir: dir})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 10); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	names := savedFiles(t, dir)
+	if len(names) != len(phoneRoutes) {
+		t.Fatalf("saved %d files, want one per visited page: %q", len(names), names)
+	}
+
+	host := strings.NewReplacer(":", "_").Replace(strings.TrimPrefix(srv.URL, "http://"))
+	for route, fixture := range phoneRoutes {
+		prefix := host + strings.ReplaceAll(route, "/", "_") + "_"
+
+		var saved string
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) && savedTimeRegex.MatchString(name[len(prefix):]) {
+				saved = name
+			}
+		}
+		if saved == "" {
+			t.Errorf("no file saved for %s among %q", route, names)
+			continue
+		}
+
+		got, err := os.ReadFile(filepath.Join(dir, saved))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s doesn't hold the page served for %s", saved, route)
+		}
+	}
+}
+
+func TestSaveHTMLDirUsesJSONExtension(t *testing.T) {
+	srv := newFixtureServer(t, loadMoreRoutes)
+	dir := t.TempDir()
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, MaxPages: 2, SaveHTMLDir: dir})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	var html, json int
+	for _, name := range savedFiles(t, dir) {
+		switch filepath.Ext(name) {
+		case ".html":
+			html++
+		case ".json":
+			json++
+		}
+	}
+	if html != 1 || json != 1 {
+		t.Errorf("saved %d HTML and %d JSON files, want the page and the batch", html, json)
+	}
+}
+
+func TestSavedFileName(t *testing.T) {
+	fetchedAt := time.Date(2024, time.March, 5, 10, 15, 30, 123456789, time.UTC)
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.avito.ru/moskva/telefony?p=2", "www.avito.ru_moskva_telefony_p_2_20240305T101530.123456789"},
+		{"https://www.avito.ru/moskva/telefony/iphone_15_1111111111", "www.avito.ru_moskva_telefony_iphone_15_1111111111_20240305T101530.123456789"},
+		{"http://127.0.0.1:8080/all?q=кофе машина", "127.0.0.1_8080_all_q_20240305T101530.123456789"},
+		{"https://www.avito.ru/../../etc/passwd", "www.avito.ru_.._.._etc_passwd_20240305T101530.123456789"},
+	}
+
+	for _, tt := range tests {
+		if got := savedFileName(tt.url, fetchedAt); got != tt.want {
+			t.Errorf("savedFileName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	long := savedFileName("https://www.avito.ru/"+strings.Repeat("a", 500), fetchedAt)
+	if name := strings.TrimSuffix(long, "_20240305T101530.123456789"); len(name) != maxSavedNameLength {
+		t.Errorf("savedFileName of a long URL kept %d characters, want %d", len(name), maxSavedNameLength)
+	}
+}
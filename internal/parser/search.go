@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// SearchListings searches all of Avito for query using the default parser
+func SearchListings(query string, limit int) ([]models.Listing, error) {
+	return defaultParser.SearchListings(query, limit)
+}
+
+// SearchListings searches Avito for query and returns up to limit listings.
+// The search covers the Parser's Region, or the whole country if none is set.
+// Runs of whitespace in the query are collapsed; the query is URL-encoded as is otherwise.
+func (p *Parser) SearchListings(query string, limit int) ([]models.Listing, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return nil, errors.New("search query must not be empty")
+	}
+
+	searchURL, err := buildCategoryURL(p.opts.BaseURL, "", "", URLOptions{Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.GetListings(searchURL, limit)
+}
+
+// CompareRegions runs the same query in each region using the default parser
+func CompareRegions(query string, regions []string, limit int) (map[string][]models.Listing, error) {
+	return defaultParser.CompareRegions(query, regions, limit)
+}
+
+// CompareRegions runs the same query in each region and returns the results keyed by region.
+// A failure in one region doesn't stop the others; all errors are returned joined together.
+func (p *Parser) CompareRegions(query string, regions []string, limit int) (map[string][]models.Listing, error) {
+	results := make(map[string][]models.Listing, len(regions))
+	var errs []error
+
+	for _, region := range regions {
+		searchURL, err := buildCategoryURL(p.opts.BaseURL, region, "", URLOptions{Query: query})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		log.Printf("Searching %q in region %s", query, region)
+
+		listings, err := p.GetListings(searchURL, limit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("region %s: %w", region, err))
+		}
+		if len(listings) > 0 {
+			results[region] = listings
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareRegionsKeepsResultsOfOtherRegions(t *testing.T) {
+	fixtures := newFixtureServer(t, map[string]string{
+		"/moskva?q=iphone": "category.html",
+	})
+	// Saint Petersburg fails with a server error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/sankt-peterburg") {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		fixtures.serve(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true})
+
+	results, err := p.CompareRegions("iphone", []string{"sankt-peterburg", "moskva"}, 0)
+	if err == nil {
+		t.Fatal("CompareRegions returned no error for the failed region")
+	}
+	if !strings.Contains(err.Error(), "region sankt-peterburg") {
+		t.Errorf("error = %q, want it to name sankt-peterburg", err)
+	}
+	if strings.Contains(err.Error(), "region moskva") {
+		t.Errorf("error = %q names the region that succeeded", err)
+	}
+
+	if _, ok := results["sankt-peterburg"]; ok {
+		t.Errorf("results have an entry for the failed region: %v", results["sankt-peterburg"])
+	}
+	if got, want := listingIDs(results["moskva"]), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("moskva listing IDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Selectors are the CSS selectors used to find listing cards and their fields on
+// category, search and rendered pages. They let callers adapt to markup changes
+// on Avito without a new release.
+//
+// Listings are taken from the JSON state embedded in the page whenever it is present;
+// the selectors only apply to pages without it. Cards are looked for in the search
+// results container, or anywhere on the page when it has none. Each list is tried in order and the
+// first selector that matches wins, so more specific selectors should come first.
+// For prices, the raw value from data-price and itemprop="price" attributes takes
+// precedence over the Prices selectors. When no selector matches, links to "/item/"
+// pages are used as a last resort.
+type Selectors struct {
+	// Items match listing cards
+	Items []string
+	// Titles match the title inside a card; generic headings are tried after them
+	Titles []string
+	// Prices match the price text inside a card
+	Prices []string
+	// CatalogItems match item blocks on "/catalog/" pages
+	CatalogItems []string
+}
+
+// DefaultSelectors returns the selectors used for any list left empty in ParserOptions.Selectors
+func DefaultSelectors() Selectors {
+	return Selectors{
+		Items: []string{
+			"div[data-marker='item']",
+			"div[data-marker='item-card']",
+			"div.iva-item-root",
+			"div.styles-item-m0DD4",
+			"div.js-item",
+			"div.item",
+			"div.item-card",
+		},
+		Titles: []string{
+			"h3[itemprop='name']",
+			"*[data-marker='item-title']",
+			"div.title",
+			"h3.title",
+			"a.title",
+			"div.snippet-title",
+		},
+		Prices: []string{
+			"*[data-marker='item-price']",
+			"span.price-text-_YGDY",
+			"span.price",
+			"div.price",
+			"span[itemprop='price']",
+			"div.snippet-price",
+		},
+		CatalogItems: []string{
+			"div[data-item-id]",
+			"div.item-wrapper",
+			"div.catalog-item",
+			"div.item",
+		},
+	}
+}
+
+// withDefaults replaces empty selector lists with the defaults
+func (s Selectors) withDefaults() Selectors {
+	defaults := DefaultSelectors()
+	if len(s.Items) == 0 {
+		s.Items = defaults.Items
+	}
+	if len(s.Titles) == 0 {
+		s.Titles = defaults.Titles
+	}
+	if len(s.Prices) == 0 {
+		s.Prices = defaults.Prices
+	}
+	if len(s.CatalogItems) == 0 {
+		s.CatalogItems = defaults.CatalogItems
+	}
+	return s
+}
+
+// validate checks that every selector is valid CSS
+func (s Selectors) validate() error {
+	for _, selector := range slices.Concat(s.Items, s.Titles, s.Prices, s.CatalogItems) {
+		if _, err := cascadia.ParseGroup(selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+	}
+	return nil
+}
+
+// firstMatch returns the first element within s matched by the earliest selector
+// that matches anything, or an empty selection
+func firstMatch(s *goquery.Selection, selectors []string) *goquery.Selection {
+	for _, selector := range selectors {
+		if match := s.Find(selector).First(); match.Length() > 0 {
+			return match
+		}
+	}
+	return s.Slice(0, 0)
+}
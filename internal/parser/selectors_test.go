@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectorsOverride(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "category_custom.html"})
+	p := newFixtureParser(t, srv, ParserOptions{
+		SkipDetails: true,
+		Selectors: Selectors{
+			Items:  []string{"article.offer"},
+			Titles: []string{"span.offer-name"},
+			Prices: []string{"p.offer-cost"},
+		},
+	})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got listings %v, want %v", got, want)
+	}
+	if listings[0].Title != "iPhone 15" || listings[0].Price.Value != 65000 {
+		t.Errorf("first listing = %q at %v, want iPhone 15 at 65000", listings[0].Title, listings[0].Price.Value)
+	}
+	if listings[1].Title != "Samsung Galaxy S24" || listings[1].Price.Value != 54000 {
+		t.Errorf("second listing = %q at %v, want Samsung Galaxy S24 at 54000", listings[1].Title, listings[1].Price.Value)
+	}
+}
+
+func TestSelectorsDefaultForEmptyLists(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/telefony": "category.html"})
+
+	// Only the card selector is overridden; titles and prices fall back to the defaults
+	p := newFixtureParser(t, srv, ParserOptions{
+		SkipDetails: true,
+		Selectors:   Selectors{Items: []string{"div[data-item-id]"}},
+	})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 || listings[0].Title != "iPhone 15" || listings[0].Price.Value != 65000 {
+		t.Errorf("got listings %+v, want the two phones with titles and prices", listings)
+	}
+	if got := p.opts.Selectors.Prices; !reflect.DeepEqual(got, DefaultSelectors().Prices) {
+		t.Errorf("Prices = %v, want the defaults", got)
+	}
+}
+
+func TestSelectorsFirstMatchWins(t *testing.T) {
+	card := parseFragment(t, `<div><span class="old-price">70 000 ₽</span><span class="price">65 000 ₽</span></div>`)
+
+	if got := firstMatch(card, []string{"span.price", "span.old-price"}).Text(); got != "65 000 ₽" {
+		t.Errorf("firstMatch = %q, want the earlier selector's match", got)
+	}
+	if got := firstMatch(card, []string{"span.missing"}); got.Length() != 0 {
+		t.Errorf("firstMatch matched %d elements, want none", got.Length())
+	}
+}
+
+func TestNewParserRejectsInvalidSelectors(t *testing.T) {
+	if _, err := NewParser(ParserOptions{Selectors: Selectors{Titles: []string{"h3[itemprop="}}}); err == nil {
+		t.Error("NewParser accepted an invalid selector")
+	}
+}
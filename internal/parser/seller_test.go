@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestGetListingDetailsSeller(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	tests := []struct {
+		path                 string
+		name, sellerType, at string
+	}{
+		{"/moskva/telefony/iphone_15_1111111111", "Иван", models.SellerTypePrivate, "/user/abc123/profile"},
+		{"/moskva/telefony/samsung_s24_2222222222", "Phone Shop", models.SellerTypeCompany, "/brands/phoneshop"},
+	}
+
+	for _, tt := range tests {
+		listing, err := p.GetListingDetails(models.Listing{URL: srv.URL + tt.path})
+		if err != nil {
+			t.Fatalf("GetListingDetails(%s): %v", tt.path, err)
+		}
+		if listing.SellerName != tt.name || listing.SellerType != tt.sellerType || listing.SellerURL != srv.URL+tt.at {
+			t.Errorf("%s: seller = %q, %q, %q, want %q, %q, %q", tt.path,
+				listing.SellerName, listing.SellerType, listing.SellerURL, tt.name, tt.sellerType, srv.URL+tt.at)
+		}
+	}
+}
+
+func TestParseSeller(t *testing.T) {
+	tests := []struct {
+		name                        string
+		page                        string
+		wantName, wantType, wantURL string
+	}{
+		{
+			name: "agency label",
+			page: `<div data-marker="seller-info">
+				<div data-marker="seller-info/name"><a href="/user/xyz/profile">Этажи</a></div>
+				<div data-marker="seller-info/label">Агентство</div>
+			</div>`,
+			wantName: "Этажи", wantType: models.SellerTypeCompany, wantURL: "https://www.avito.ru/user/xyz/profile",
+		},
+		{
+			name: "storefront link without a label",
+			page: `<div data-marker="item-view/seller-info">
+				<a data-marker="seller-link/link" href="/brands/phoneshop">Phone Shop</a>
+			</div>`,
+			wantName: "Phone Shop", wantType: models.SellerTypeCompany, wantURL: "https://www.avito.ru/brands/phoneshop",
+		},
+		{
+			name:     "name without a link",
+			page:     `<div data-marker="seller-info"><div data-marker="seller-info/name">Мария</div></div>`,
+			wantName: "Мария",
+		},
+		{
+			name: "no seller block",
+			page: `<h1>Диван</h1>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatalf("parsing page: %v", err)
+			}
+
+			name, sellerType, sellerURL := parseSeller(doc.Selection, defaultBaseURL)
+			if name != tt.wantName || sellerType != tt.wantType || sellerURL != tt.wantURL {
+				t.Errorf("parseSeller = %q, %q, %q, want %q, %q, %q",
+					name, sellerType, sellerURL, tt.wantName, tt.wantType, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestParseSellerItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      string
+		wantCount int
+		wantURL   string
+	}{
+		{
+			name: "seller block",
+			page: `<div data-marker="seller-info">
+				<a href="/user/abc123/profile">Иван</a>
+				<a href="/user/abc123/profile?src=item">Ещё 12 объявлений продавца</a>
+			</div>`,
+			wantCount: 12,
+			wantURL:   "https://www.avito.ru/user/abc123/profile",
+		},
+		{
+			name: "non-breaking thousands separator",
+			page: "<div data-marker=\"item-view/seller-info\">" +
+				"<a href=\"/brands/phoneshop\">Ещё 1\u00a0234\u00a0объявления продавца</a></div>",
+			wantCount: 1234,
+			wantURL:   "https://www.avito.ru/brands/phoneshop",
+		},
+		{
+			name: "count outside the seller block",
+			page: `<div data-marker="seller-info"><a href="/user/abc123/profile">Иван</a></div>
+				<div data-marker="similar-items"><a href="/moskva/telefony">Ещё 50 объявлений в категории</a></div>`,
+		},
+		{
+			name: "single listing",
+			page: `<div data-marker="seller-info"><a href="/user/abc123/profile">Иван</a></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatalf("parsing page: %v", err)
+			}
+
+			count, storefrontURL := parseSellerItems(doc.Selection, defaultBaseURL)
+			if count != tt.wantCount || storefrontURL != tt.wantURL {
+				t.Errorf("parseSellerItems = %d, %q, want %d, %q", count, storefrontURL, tt.wantCount, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// Regex to detect if the URL is a shop or seller profile page like "/brands/i12345" or "/user/abc123/profile"
+var shopRegex = regexp.MustCompile(`^(?:https?://[^/]+)?/(?:user|company|brands)/[^/?#]+`)
+
+// Regex to match the path kinds of shop pages that belong to companies rather than private sellers
+var companyShopRegex = regexp.MustCompile(`^(?:https?://[^/]+)?/(?:company|brands)/`)
+
+// shopNameSelectors match the shop or seller name on a shop page, most specific first
+var shopNameSelectors = []string{
+	"*[data-marker='shop-name']",
+	"*[data-marker='profile/name']",
+	"*[data-marker='seller-info/name']",
+	"h1",
+}
+
+// handleShopPage collects the listings of a shop or seller profile page. Every listing
+// gets the shop's name as SellerName and the shop URL as SellerURL. The page is parsed
+// like a rendered page: from its embedded JSON state, the Items selectors, or links to
+// "/item/" pages, whichever finds listings first.
+func (p *Parser) handleShopPage(ctx context.Context, shopURL string, limit int) ([]models.Listing, error) {
+	log.Println("Handling shop page:", shopURL)
+	var listings []models.Listing
+	var shopName string
+	var parseErr error
+
+	c := p.newCollector(ctx)
+
+	// Rate limiting
+	c.Limit(p.limitRule())
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Println("Visiting shop:", r.URL)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Println("Error:", err)
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		log.Printf("Received shop response, size: %d bytes\n", len(r.Body))
+
+		listings, parseErr = p.ParseItemsFromHTML(string(r.Body))
+	})
+
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		shopName = parseShopName(e.DOM)
+	})
+
+	p.trackRequestStats(ctx, c)
+
+	err := p.visit(ctx, c, shopURL)
+	if err != nil {
+		return nil, fmt.Errorf("error visiting shop page: %w", err)
+	}
+
+	c.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	// Fall back to a fully rendered page when the layout wasn't recognized
+	if len(listings) == 0 && p.opts.Renderer != nil {
+		log.Println("No listings found, rendering shop page with Renderer:", shopURL)
+		htmlContent, err := p.opts.Renderer.RenderHTML(shopURL)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering shop page: %w", err)
+		}
+		if listings, err = p.ParseItemsFromHTML(htmlContent); err != nil {
+			return nil, err
+		}
+		if doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent)); err == nil && shopName == "" {
+			shopName = parseShopName(doc.Selection)
+		}
+	}
+
+	var kept []models.Listing
+	for _, listing := range dedupeListings(listings) {
+		if limit > 0 && len(kept) >= limit {
+			break
+		}
+
+		listing.CategoryURL = shopURL
+		p.applyExtractionLimits(&listing)
+		setShopSeller(&listing, shopName, shopURL)
+		if p.keepListing(listing) {
+			kept = append(kept, listing)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoListingsFound, shopURL)
+	}
+
+	if p.opts.SkipDetails {
+		return kept, nil
+	}
+
+	enriched, err := p.enrichListings(ctx, kept)
+	for i := range enriched {
+		setShopSeller(&enriched[i], shopName, shopURL)
+	}
+	return p.applyFilter(dedupeListings(enriched)), err
+}
+
+// parseShopName extracts the shop or seller name from a shop page
+func parseShopName(doc *goquery.Selection) string {
+	if name := cleanText(firstMatch(doc, shopNameSelectors).Text()); name != "" {
+		return name
+	}
+	return cleanText(doc.Find("meta[property='og:title']").AttrOr("content", ""))
+}
+
+// setShopSeller fills the seller fields of a listing found on a shop page,
+// keeping those already parsed from the listing page
+func setShopSeller(listing *models.Listing, shopName, shopURL string) {
+	if listing.SellerName == "" {
+		listing.SellerName = shopName
+	}
+	if listing.SellerURL == "" {
+		listing.SellerURL = normalizeURL(shopURL)
+	}
+	if listing.SellerType == "" && companyShopRegex.MatchString(shopURL) {
+		listing.SellerType = models.SellerTypeCompany
+	}
+}
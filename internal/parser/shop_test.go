@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+func TestGetListingsFromShopPage(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/brands/phoneshop": "shop.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	shopURL := srv.URL + "/brands/phoneshop"
+	listings, err := p.GetListings(shopURL, 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222", "3333333333"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listing IDs = %v, want %v", got, want)
+	}
+	for _, listing := range listings {
+		if listing.SellerName != "Phone Shop" || listing.SellerType != models.SellerTypeCompany || listing.SellerURL != shopURL {
+			t.Errorf("listing %s has seller %q (%s, %s), want the shop", listing.ID, listing.SellerName, listing.SellerType, listing.SellerURL)
+		}
+		if listing.CategoryURL != shopURL {
+			t.Errorf("listing %s has CategoryURL %q, want the shop URL", listing.ID, listing.CategoryURL)
+		}
+	}
+	if listings[2].Price.Value != 45000 {
+		t.Errorf("Pixel price = %+v, want 45000", listings[2].Price)
+	}
+}
+
+func TestShopPageLimit(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/brands/phoneshop": "shop.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/brands/phoneshop", 2)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+}
+
+func TestShopPageWithUnrecognizedLayout(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/user/abc123/profile": "empty.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/user/abc123/profile", 0)
+	if !errors.Is(err, ErrNoListingsFound) {
+		t.Errorf("GetListings error = %v, want ErrNoListingsFound", err)
+	}
+	if len(listings) != 0 {
+		t.Errorf("got %d listings from an empty profile", len(listings))
+	}
+}
+
+func TestParseShopName(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"shop marker", `<h1>Объявления</h1><span data-marker="shop-name"> Phone Shop </span>`, "Phone Shop"},
+		{"profile marker", `<div data-marker="profile/name">Иван</div>`, "Иван"},
+		{"heading", `<h1>Мебельный двор</h1>`, "Мебельный двор"},
+		{"open graph title", `<head><meta property="og:title" content="Phone Shop"></head>`, "Phone Shop"},
+		{"nothing", `<p>Объявления</p>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseShopName(parseFragment(t, tt.page)); got != tt.want {
+				t.Errorf("parseShopName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShopRegex(t *testing.T) {
+	for rawURL, want := range map[string]bool{
+		"https://www.avito.ru/brands/phoneshop":        true,
+		"https://www.avito.ru/company/12345":           true,
+		"https://www.avito.ru/user/abc123/profile":     true,
+		"/brands/i12345?p=2":                           true,
+		"https://www.avito.ru/moskva/telefony":         false,
+		"https://www.avito.ru/moskva/brands/phoneshop": false,
+		"https://www.avito.ru/brands":                  false,
+	} {
+		if got := shopRegex.MatchString(rawURL); got != want {
+			t.Errorf("shopRegex.MatchString(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestShopPageListingDetails(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/brands/phoneshop":                       "shop.html",
+		"/moskva/telefony/iphone_15_1111111111":   "item_gallery.html",
+		"/moskva/telefony/samsung_s24_2222222222": "item_samsung.html",
+		"/moskva/telefony/pixel_8_3333333333":     "item_description.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	listings, err := p.GetListings(srv.URL+"/brands/phoneshop", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 3 {
+		t.Fatalf("got %d listings, want 3", len(listings))
+	}
+	// Only the Samsung page names its seller; the shop fills in the others
+	for _, listing := range listings {
+		if listing.Description == "" {
+			t.Errorf("listing %s has no description, want its details fetched", listing.ID)
+		}
+		if listing.SellerName != "Phone Shop" || listing.SellerType != models.SellerTypeCompany {
+			t.Errorf("listing %s has seller %q (%s), want the shop", listing.ID, listing.SellerName, listing.SellerType)
+		}
+	}
+	if hits := srv.TotalHits(); hits != 4 {
+		t.Errorf("server got %d requests, want the shop page and 3 listing pages", hits)
+	}
+}
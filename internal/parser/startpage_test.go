@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStartPageSkipsEarlierPages(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony":     "category.html",
+		"/moskva/telefony?p=3": "category_page3.html",
+		"/moskva/telefony?p=4": "category_delivery.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, StartPage: 3, MaxPages: 2})
+
+	plan, err := p.PlanListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("PlanListings: %v", err)
+	}
+	if want := []string{srv.URL + "/moskva/telefony?p=3", srv.URL + "/moskva/telefony?p=4"}; !reflect.DeepEqual(plan.PageURLs, want) {
+		t.Errorf("PageURLs = %v, want %v", plan.PageURLs, want)
+	}
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got, want := listingIDs(listings), []string{"3333333333", "1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listing IDs = %v, want %v", got, want)
+	}
+	if hits := srv.Hits("/moskva/telefony"); hits != 0 {
+		t.Errorf("the first page was requested %d times", hits)
+	}
+}
+
+func TestStartPageKeepsSearchQuery(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/all?p=2&q=iphone": "category_page3.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, StartPage: 2})
+
+	listings, err := p.GetListings(srv.URL+"/all?q=iphone", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if got := listingIDs(listings); !reflect.DeepEqual(got, []string{"3333333333"}) {
+		t.Errorf("listing IDs = %v, want the Pixel from page 2", got)
+	}
+}
+
+func TestStartPageOutOfRange(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "category.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, err := os.ReadFile(filepath.Join("testdata", "empty.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Avito sends some pages past the last one back to the first page and shows
+	// others without results
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Query().Get("p") {
+		case "":
+			_, _ = w.Write(page)
+		case "9":
+			http.Redirect(w, r, "/moskva/telefony", http.StatusFound)
+		default:
+			_, _ = w.Write(empty)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, startPage := range []int{5, 9} {
+		p := newTestParser(t, srv.URL, ParserOptions{SkipDetails: true, StartPage: startPage})
+		listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+		if !errors.Is(err, ErrOffsetOutOfRange) {
+			t.Errorf("StartPage %d: GetListings error = %v, want ErrOffsetOutOfRange", startPage, err)
+		}
+		if len(listings) != 0 {
+			t.Errorf("StartPage %d: got %d listings, want none", startPage, len(listings))
+		}
+	}
+}
+
+func TestStartPageOption(t *testing.T) {
+	if _, err := NewParser(ParserOptions{StartPage: -1}); err == nil {
+		t.Error("NewParser accepted a negative StartPage")
+	}
+
+	// Starting at page 1 is the same as not setting a start page
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, StartPage: 1})
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if hits := srv.Hits("/moskva/telefony"); hits != 1 {
+		t.Errorf("the first page was requested %d times, want 1", hits)
+	}
+}
+
+func TestPageURLFor(t *testing.T) {
+	tests := []struct {
+		url  string
+		page int
+		want string
+	}{
+		{"https://www.avito.ru/moskva/telefony", 2, "https://www.avito.ru/moskva/telefony?p=2"},
+		{"https://www.avito.ru/moskva/telefony?p=2", 3, "https://www.avito.ru/moskva/telefony?p=3"},
+		{"https://www.avito.ru/all?q=iphone&s=104", 4, "https://www.avito.ru/all?p=4&q=iphone&s=104"},
+	}
+
+	for _, tt := range tests {
+		if got := pageURLFor(tt.url, tt.page); got != tt.want {
+			t.Errorf("pageURLFor(%q, %d) = %q, want %q", tt.url, tt.page, got, tt.want)
+		}
+	}
+}
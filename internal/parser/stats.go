@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// RequestStat describes a single HTTP request made while scraping
+type RequestStat struct {
+	URL       string        `json:"url"`
+	Status    int           `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	FromCache bool          `json:"fromCache"`
+}
+
+// RetryStat describes a request that is about to be retried
+type RetryStat struct {
+	URL string `json:"url"`
+	// Status is the status of the failed attempt, or 0 for a connection error
+	Status int `json:"status"`
+	// Attempt counts the retries of the request, starting at 1
+	Attempt int `json:"attempt"`
+	// Delay is the backoff before the retry is sent
+	Delay time.Duration `json:"delay"`
+}
+
+// Stats are counters of the work a Parser has done since it was created or its
+// stats were last reset. Calls running at the same time all add to them, so
+// Duration can exceed the wall-clock time that passed. For details of each
+// request, use GetListingsWithStats.
+type Stats struct {
+	// Requests is the number of HTTP requests answered or failed, including retries.
+	// Pages served from the Cache are counted as CacheHits instead.
+	Requests  int `json:"requests"`
+	CacheHits int `json:"cacheHits"`
+	// Retries is the number of requests re-issued after a 429 or a proxy failure
+	Retries int `json:"retries"`
+	// RateLimited is the number of 429 responses
+	RateLimited int `json:"rateLimited"`
+	// Blocked is the number of anti-bot pages served instead of content
+	Blocked int `json:"blocked"`
+	// Errors is the number of failed requests, including 429 responses
+	Errors int `json:"errors"`
+	// ListingsFound is the number of listings returned by GetListings and StreamListings
+	ListingsFound int `json:"listingsFound"`
+	// Duration is the time spent in GetListings and StreamListings calls
+	Duration time.Duration `json:"duration"`
+}
+
+// parserStats holds a Parser's counters, which its collectors update concurrently
+type parserStats struct {
+	requests, cacheHits, retries, rateLimited, blocked, errors, listingsFound atomic.Int64
+	duration                                                                  atomic.Int64 // nanoseconds
+}
+
+// Stats returns a snapshot of the Parser's counters
+func (p *Parser) Stats() Stats {
+	return Stats{
+		Requests:      int(p.stats.requests.Load()),
+		CacheHits:     int(p.stats.cacheHits.Load()),
+		Retries:       int(p.stats.retries.Load()),
+		RateLimited:   int(p.stats.rateLimited.Load()),
+		Blocked:       int(p.stats.blocked.Load()),
+		Errors:        int(p.stats.errors.Load()),
+		ListingsFound: int(p.stats.listingsFound.Load()),
+		Duration:      time.Duration(p.stats.duration.Load()),
+	}
+}
+
+// ResetStats sets the Parser's counters back to zero, e.g. before a scrape to be measured
+func (p *Parser) ResetStats() {
+	for _, counter := range []*atomic.Int64{
+		&p.stats.requests, &p.stats.cacheHits, &p.stats.retries, &p.stats.rateLimited,
+		&p.stats.blocked, &p.stats.errors, &p.stats.listingsFound, &p.stats.duration,
+	} {
+		counter.Store(0)
+	}
+}
+
+// countRequests updates the Parser's counters from every response and failed request of c
+func (p *Parser) countRequests(c *colly.Collector) {
+	record := func(r *colly.Response) {
+		if r.Headers != nil && r.Headers.Get(cacheHeader) != "" {
+			p.stats.cacheHits.Add(1)
+		} else {
+			p.stats.requests.Add(1)
+		}
+		if r.StatusCode == http.StatusTooManyRequests {
+			p.stats.rateLimited.Add(1)
+		}
+		if isBlockPage(r.Body) {
+			p.stats.blocked.Add(1)
+		}
+	}
+
+	c.OnResponse(record)
+	c.OnError(func(r *colly.Response, _ error) {
+		p.stats.errors.Add(1)
+		record(r)
+	})
+}
+
+// countScrape adds a finished GetListings or StreamListings call to the Parser's counters
+func (p *Parser) countScrape(started time.Time, listings int) {
+	p.stats.listingsFound.Add(int64(listings))
+	p.stats.duration.Add(int64(time.Since(started)))
+}
+
+// requestStatsKey is the context key under which a call's requestStats are stored
+type requestStatsKey struct{}
+
+// requestStats collects the RequestStat of every request made on behalf of one
+// GetListingsWithStats call
+type requestStats struct {
+	mu    sync.Mutex
+	stats []RequestStat
+}
+
+// requestStatsFrom returns the recorder carried by ctx, or nil when telemetry is disabled
+func requestStatsFrom(ctx context.Context) *requestStats {
+	recorder, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	return recorder
+}
+
+func (s *requestStats) add(stat RequestStat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = append(s.stats, stat)
+}
+
+// all returns the stats recorded so far
+func (s *requestStats) all() []RequestStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.stats)
+}
+
+// GetListingsWithStats works like GetListings and also returns per-request telemetry
+func GetListingsWithStats(categoryURL string, limit int) ([]models.Listing, []RequestStat, error) {
+	return defaultParser.GetListingsWithStats(categoryURL, limit)
+}
+
+// GetListingsWithStats works like GetListings and also returns per-request telemetry
+func (p *Parser) GetListingsWithStats(categoryURL string, limit int) ([]models.Listing, []RequestStat, error) {
+	return p.GetListingsWithStatsContext(context.Background(), categoryURL, limit)
+}
+
+// GetListingsWithStatsContext works like GetListingsContext and also returns per-request
+// telemetry. Only the requests made for this call are returned, even when other calls
+// run on the same Parser at the same time.
+func (p *Parser) GetListingsWithStatsContext(ctx context.Context, categoryURL string, limit int) ([]models.Listing, []RequestStat, error) {
+	recorder := &requestStats{}
+	ctx = context.WithValue(ctx, requestStatsKey{}, recorder)
+
+	listings, err := p.GetListingsContext(ctx, categoryURL, limit)
+	return listings, recorder.all(), err
+}
+
+// trackRequestStats records status and timing for every request made by the collector
+// into the recorder carried by ctx and reports it to the metric hooks. It must be
+// attached after rate limiting callbacks so waiting time is not counted.
+func (p *Parser) trackRequestStats(ctx context.Context, c *colly.Collector) {
+	recorder := requestStatsFrom(ctx)
+	onRequest, onBlocked := p.opts.OnRequestMetric, p.opts.OnBlockedMetric
+	if recorder == nil && onRequest == nil && onBlocked == nil {
+		return
+	}
+
+	c.OnRequest(func(r *colly.Request) {
+		r.Ctx.Put("requestStartedAt", time.Now())
+	})
+
+	record := func(r *colly.Response) {
+		stat := RequestStat{
+			URL:       r.Request.URL.String(),
+			Status:    r.StatusCode,
+			FromCache: r.Headers != nil && r.Headers.Get(cacheHeader) != "",
+		}
+		if startedAt, ok := r.Ctx.GetAny("requestStartedAt").(time.Time); ok {
+			stat.Duration = time.Since(startedAt)
+		}
+
+		if recorder != nil {
+			recorder.add(stat)
+		}
+		if onRequest != nil {
+			onRequest(stat)
+		}
+		if onBlocked != nil && isBlockPage(r.Body) {
+			onBlocked(stat)
+		}
+	}
+
+	c.OnResponse(record)
+	c.OnError(func(r *colly.Response, _ error) {
+		record(r)
+	})
+}
+
+// countRetry records that the request of r is about to be retried
+func (p *Parser) countRetry(r *colly.Response, attempt int, delay time.Duration) {
+	p.stats.retries.Add(1)
+	if p.opts.OnRetryMetric != nil {
+		p.opts.OnRetryMetric(RetryStat{
+			URL:     r.Request.URL.String(),
+			Status:  r.StatusCode,
+			Attempt: attempt,
+			Delay:   delay,
+		})
+	}
+}
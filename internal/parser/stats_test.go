@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGetListingsWithStats(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	listings, stats, err := p.GetListingsWithStats(srv.URL+"/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListingsWithStats: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Fatalf("got %d listings, want 2", len(listings))
+	}
+
+	var urls []string
+	for _, stat := range stats {
+		urls = append(urls, stat.URL)
+		if stat.Status != http.StatusOK || stat.FromCache {
+			t.Errorf("stat for %s = %+v, want an uncached 200", stat.URL, stat)
+		}
+	}
+	sort.Strings(urls)
+
+	want := []string{
+		srv.URL + "/moskva/telefony",
+		srv.URL + "/moskva/telefony/iphone_15_1111111111",
+		srv.URL + "/moskva/telefony/samsung_s24_2222222222",
+	}
+	if strings.Join(urls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stats cover\n%s\nwant\n%s", strings.Join(urls, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGetListingsWithStatsConcurrentCalls(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		srv := newFixtureServer(t, phoneRoutes)
+		p := newFixtureParser(t, srv, ParserOptions{})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, stats, err := p.GetListingsWithStats(srv.URL+"/moskva/telefony", 10)
+			if err != nil {
+				t.Errorf("GetListingsWithStats: %v", err)
+				return
+			}
+			if len(stats) != 3 {
+				t.Errorf("got %d stats, want 3", len(stats))
+			}
+			for _, stat := range stats {
+				if !strings.HasPrefix(stat.URL, srv.URL+"/") {
+					t.Errorf("stats of %s include a request to %s", srv.URL, stat.URL)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParserStatsCountScriptedRequests(t *testing.T) {
+	// The category page is rate limited once, then served
+	srv, hits := rateLimitingServer(t, "")
+	p := newTestParser(t, srv.URL, ParserOptions{MaxRetries: 1, SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/telefony", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	stats := p.Stats()
+	want := Stats{Requests: 2, Retries: 1, RateLimited: 1, Errors: 1, ListingsFound: len(listings), Duration: stats.Duration}
+	if stats != want {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+	if stats.Requests != int(hits.Load()) {
+		t.Errorf("Stats counted %d requests, server got %d", stats.Requests, hits.Load())
+	}
+	if stats.Duration <= 0 {
+		t.Errorf("Stats.Duration = %v, want the time spent in GetListings", stats.Duration)
+	}
+
+	p.ResetStats()
+	if stats := p.Stats(); stats != (Stats{}) {
+		t.Errorf("Stats after ResetStats = %+v, want zero", stats)
+	}
+}
+
+func TestParserStatsCountBlockedPagesAndCacheHits(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/moskva/telefony": "category.html",
+		"/moskva/noutbuki": "blocked.html",
+	})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true, Cache: NewMemoryCache(10)})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/noutbuki", 0); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("GetListings error = %v, want ErrBlocked", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+			t.Fatalf("GetListings: %v", err)
+		}
+	}
+
+	// The second phone scrape is served from the cache; block pages aren't cached
+	stats := p.Stats()
+	if stats.Requests != 2 || stats.CacheHits != 1 || stats.Blocked != 1 || stats.ListingsFound != 4 {
+		t.Errorf("Stats = %+v, want 2 requests, 1 cache hit, 1 block page and 4 listings", stats)
+	}
+	if hits := srv.TotalHits(); hits != stats.Requests {
+		t.Errorf("server got %d requests, Stats counted %d", hits, stats.Requests)
+	}
+}
+
+func TestParserStatsCountCatalogSubcategoriesOnce(t *testing.T) {
+	srv := newFixtureServer(t, sectionRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/catalog/elektronika", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	// Each subcategory yields the iPhone, which the catalog page keeps once
+	if len(listings) != 1 {
+		t.Fatalf("got %d listings, want 1", len(listings))
+	}
+	if found := p.Stats().ListingsFound; found != 1 {
+		t.Errorf("Stats.ListingsFound = %d, want 1", found)
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// StreamListings streams listings from a category URL using the default parser
+func StreamListings(ctx context.Context, categoryURL string, limit int) (<-chan models.Listing, <-chan error) {
+	return defaultParser.StreamListings(ctx, categoryURL, limit)
+}
+
+// StreamListings emits each listing as soon as its details have been fetched.
+// Failures for individual listings are sent to the error channel and the listing
+// is still emitted with the data from the category page. Both channels are closed
+// when the limit is reached, the context is done or scraping completes, and both
+// must be drained by the caller.
+func (p *Parser) StreamListings(ctx context.Context, categoryURL string, limit int) (<-chan models.Listing, <-chan error) {
+	out := make(chan models.Listing)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		started, sent := time.Now(), 0
+		defer func() { p.countScrape(started, sent) }()
+
+		sendError := func(err error) bool {
+			select {
+			case errs <- err:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		sendListings := func(listings []models.Listing) bool {
+			for _, listing := range listings {
+				select {
+				case out <- listing:
+					sent++
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		if categoryURL == "" {
+			sendError(fmt.Errorf("category: %w", ErrEmptyURL))
+			return
+		}
+		categoryURL := p.regionalURL(categoryURL)
+		ctx, cancel := p.withMaxDuration(p.withRequestBudget(ctx))
+		defer cancel()
+
+		// Catalog and shop pages enrich listings while traversing, so they are emitted at the end
+		if catalogRegex.MatchString(categoryURL) || shopRegex.MatchString(categoryURL) {
+			handle := p.handleCatalogPage
+			if shopRegex.MatchString(categoryURL) {
+				handle = p.handleShopPage
+			}
+			listings, err := handle(ctx, categoryURL, limit)
+			if !sendListings(listings) {
+				return
+			}
+			if err != nil {
+				sendError(deadlineError(ctx, err))
+			}
+			return
+		}
+
+		listings, err := p.collectListings(ctx, categoryURL, limit)
+		if err != nil {
+			// Out of time the cards found so far are emitted without their details
+			if deadlineReached(ctx) && !sendListings(listings) {
+				return
+			}
+			sendError(deadlineError(ctx, err))
+			return
+		}
+
+		seen := make(map[string]bool, len(listings))
+		budgetReported := false
+		for i, listing := range listings {
+			log.Printf("Fetching details for listing %d of %d", i+1, len(listings))
+
+			enriched, err := p.enrichListing(ctx, listing)
+			if err != nil {
+				if ctx.Err() != nil {
+					sendError(deadlineError(ctx, ctx.Err()))
+					return
+				}
+				// Once the request budget is used up the remaining listings are emitted
+				// as found on the category page, reporting the exhaustion only once
+				if errors.Is(err, ErrRequestBudgetExhausted) {
+					if budgetReported {
+						err = nil
+					}
+					budgetReported = true
+				}
+				if err != nil && !sendError(fmt.Errorf("listing %s: %w", listing.ID, err)) {
+					return
+				}
+			}
+
+			key := listingKey(enriched)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if !sendListings(p.applyFilter([]models.Listing{enriched})) {
+				return
+			}
+		}
+	}()
+
+	return out, errs
+}
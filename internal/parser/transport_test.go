@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureTransport serves the fixtures in testdata by request path without a server,
+// like the example in the Transport option's documentation
+type fixtureTransport struct {
+	routes map[string]string
+
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (f *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.hosts = append(f.hosts, req.URL.Host)
+	f.mu.Unlock()
+
+	fixture, ok := f.routes[req.URL.Path]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Request: req}, nil
+	}
+	file, err := os.Open(filepath.Join("testdata", fixture))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       file,
+		Request:    req,
+	}, nil
+}
+
+// newOfflineParser creates a Parser for Avito that sends its requests through transport
+// and doesn't wait between them
+func newOfflineParser(t *testing.T, transport http.RoundTripper) *Parser {
+	t.Helper()
+
+	opts := DefaultParserOptions()
+	opts.Transport = transport
+	opts.MinDelay = time.Nanosecond
+	opts.DelayStrategy = ConstantDelay(0)
+	opts.MaxPages = 1
+
+	p, err := NewParser(opts)
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	return p
+}
+
+func TestTransportServesAvitoPages(t *testing.T) {
+	transport := &fixtureTransport{routes: phoneRoutes}
+	p := newOfflineParser(t, transport)
+
+	listings, err := p.GetListings("https://www.avito.ru/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	if got, want := listingIDs(listings), []string{"1111111111", "2222222222"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listing IDs = %v, want %v", got, want)
+	}
+	if got, want := listings[0].URL, "https://www.avito.ru/moskva/telefony/iphone_15_1111111111"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+	if listings[0].SellerName != "Иван" {
+		t.Errorf("SellerName = %q, want the seller from the listing page", listings[0].SellerName)
+	}
+
+	for _, host := range transport.hosts {
+		if host != "www.avito.ru" {
+			t.Errorf("transport got a request for %s", host)
+		}
+	}
+	if len(transport.hosts) < 3 {
+		t.Errorf("transport got %d requests, want the category and 2 listing pages", len(transport.hosts))
+	}
+}
+
+// redirectTransport sends every request to a test server instead of its own host
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (r redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = r.target.Scheme, r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTransportToTestServer(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newOfflineParser(t, redirectTransport{target: target})
+
+	listings, err := p.GetListings("https://www.avito.ru/moskva/telefony", 10)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 2 || listings[1].Description == "" {
+		t.Errorf("got %d listings, want 2 with their details", len(listings))
+	}
+	if hits := srv.TotalHits(); hits < 3 {
+		t.Errorf("test server got %d requests, want the category and 2 listing pages", hits)
+	}
+}
+
+func TestTransportWithProxiesMustBeHTTPTransport(t *testing.T) {
+	opts := ParserOptions{Transport: &fixtureTransport{}, Proxies: []string{"http://127.0.0.1:3128"}}
+	if _, err := NewParser(opts); err == nil {
+		t.Error("NewParser accepted proxies with a custom RoundTripper")
+	}
+
+	opts.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	if _, err := NewParser(opts); err != nil {
+		t.Errorf("NewParser with proxies and an *http.Transport: %v", err)
+	}
+}
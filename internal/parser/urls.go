@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Regex to match a category path segment such as "telefony" or "mobilnye_telefony-ASgBAgICAUSwwQ2I_Dc"
+var categorySegmentRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// URLOptions are the query parameters BuildCategoryURL adds to a category URL
+type URLOptions struct {
+	// Query is a search phrase, sent as "q"
+	Query string
+
+	// MinPrice and MaxPrice restrict results to a price window in rubles through
+	// Avito's "pmin" and "pmax" parameters. Zero leaves the bound open.
+	MinPrice int
+	MaxPrice int
+
+	// Sort sets the order of the results; the zero value keeps Avito's default
+	Sort SortOrder
+
+	// Page is the results page to open, sent as "p"; 0 and 1 both mean the first page
+	Page int
+}
+
+// validate checks that the URL options are consistent
+func (opts URLOptions) validate() error {
+	if opts.MinPrice < 0 || opts.MaxPrice < 0 {
+		return fmt.Errorf("price bounds must not be negative, got min %d and max %d", opts.MinPrice, opts.MaxPrice)
+	}
+	if opts.MaxPrice != 0 && opts.MinPrice > opts.MaxPrice {
+		return fmt.Errorf("min price %d is greater than max price %d", opts.MinPrice, opts.MaxPrice)
+	}
+	if !opts.Sort.valid() {
+		return fmt.Errorf("unknown sort order: %q", opts.Sort)
+	}
+	if opts.Page < 0 {
+		return fmt.Errorf("page must not be negative, got %d", opts.Page)
+	}
+
+	return nil
+}
+
+// setQuery sets the query parameters for the options, leaving other parameters as they are
+func (opts URLOptions) setQuery(query url.Values) {
+	if q := strings.Join(strings.Fields(opts.Query), " "); q != "" {
+		query.Set("q", q)
+	}
+	if opts.MinPrice > 0 {
+		query.Set("pmin", strconv.Itoa(opts.MinPrice))
+	}
+	if opts.MaxPrice > 0 {
+		query.Set("pmax", strconv.Itoa(opts.MaxPrice))
+	}
+	if opts.Sort != SortDefault {
+		query.Set("s", string(opts.Sort))
+	}
+	if opts.Page > 1 {
+		query.Set("p", strconv.Itoa(opts.Page))
+	}
+}
+
+// BuildCategoryURL builds the URL of a category within a region, for example
+// BuildCategoryURL("moskva", "telefony", URLOptions{MaxPrice: 30000}) returns
+// "https://www.avito.ru/moskva/telefony?pmax=30000".
+//
+// An empty region means the whole country. categorySlug may name a nested category
+// like "transport/avtomobili"; leave it empty to search all categories with Query.
+func BuildCategoryURL(region, categorySlug string, opts URLOptions) (string, error) {
+	return buildCategoryURL(defaultBaseURL, region, categorySlug, opts)
+}
+
+// buildCategoryURL builds a category URL like BuildCategoryURL on the site root base
+func buildCategoryURL(base, region, categorySlug string, opts URLOptions) (string, error) {
+	if region == "" {
+		region = allRegions
+	}
+	if err := validateRegion(region); err != nil {
+		return "", err
+	}
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+
+	path := "/" + region
+	if categorySlug = strings.Trim(categorySlug, "/"); categorySlug != "" {
+		for _, segment := range strings.Split(categorySlug, "/") {
+			if !categorySegmentRegex.MatchString(segment) {
+				return "", fmt.Errorf("invalid category slug: %q", categorySlug)
+			}
+		}
+		path += "/" + categorySlug
+	}
+
+	query := url.Values{}
+	opts.setQuery(query)
+
+	builtURL := base + path
+	if len(query) > 0 {
+		builtURL += "?" + query.Encode()
+	}
+	return builtURL, nil
+}
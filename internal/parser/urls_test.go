@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildCategoryURL(t *testing.T) {
+	tests := []struct {
+		region, slug string
+		opts         URLOptions
+		want         string
+	}{
+		{"moskva", "telefony", URLOptions{}, "https://www.avito.ru/moskva/telefony"},
+		{"moskva", "telefony", URLOptions{MaxPrice: 30000}, "https://www.avito.ru/moskva/telefony?pmax=30000"},
+		{"", "avtomobili", URLOptions{}, "https://www.avito.ru/all/avtomobili"},
+		{"sankt-peterburg", "/transport/avtomobili/", URLOptions{Page: 1}, "https://www.avito.ru/sankt-peterburg/transport/avtomobili"},
+		{"moskva", "", URLOptions{Query: "  iphone   15 pro "}, "https://www.avito.ru/moskva?q=iphone+15+pro"},
+		{"moskva", "", URLOptions{Query: "чехол & плёнка"}, "https://www.avito.ru/moskva?q=%D1%87%D0%B5%D1%85%D0%BE%D0%BB+%26+%D0%BF%D0%BB%D1%91%D0%BD%D0%BA%D0%B0"},
+		{
+			"moskva", "telefony",
+			URLOptions{Query: "iphone", MinPrice: 10000, MaxPrice: 60000, Sort: SortByPriceAsc, Page: 3},
+			"https://www.avito.ru/moskva/telefony?p=3&pmax=60000&pmin=10000&q=iphone&s=1",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := BuildCategoryURL(tt.region, tt.slug, tt.opts)
+		if err != nil {
+			t.Errorf("BuildCategoryURL(%q, %q, %+v): %v", tt.region, tt.slug, tt.opts, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("BuildCategoryURL(%q, %q, %+v) = %q, want %q", tt.region, tt.slug, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCategoryURLOptionCombinations(t *testing.T) {
+	// Every subset of the options, each set option adding exactly its parameter
+	for mask := 0; mask < 1<<5; mask++ {
+		var opts URLOptions
+		want := url.Values{}
+		if mask&1 != 0 {
+			opts.Query = "iphone 15"
+			want.Set("q", "iphone 15")
+		}
+		if mask&2 != 0 {
+			opts.MinPrice = 10000
+			want.Set("pmin", "10000")
+		}
+		if mask&4 != 0 {
+			opts.MaxPrice = 60000
+			want.Set("pmax", "60000")
+		}
+		if mask&8 != 0 {
+			opts.Sort = SortByDateDesc
+			want.Set("s", "104")
+		}
+		if mask&16 != 0 {
+			opts.Page = 2
+			want.Set("p", "2")
+		}
+
+		got, err := BuildCategoryURL("moskva", "telefony", opts)
+		if err != nil {
+			t.Errorf("options %+v: %v", opts, err)
+			continue
+		}
+		parsedURL, err := url.Parse(got)
+		if err != nil {
+			t.Errorf("options %+v built an invalid URL %q: %v", opts, got, err)
+			continue
+		}
+		if parsedURL.Path != "/moskva/telefony" || parsedURL.Query().Encode() != want.Encode() {
+			t.Errorf("options %+v built %q, want query %q", opts, got, want.Encode())
+		}
+	}
+}
+
+func TestBuildCategoryURLRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name         string
+		region, slug string
+		opts         URLOptions
+	}{
+		{"unknown region", "atlantida", "telefony", URLOptions{}},
+		{"slug with a query", "moskva", "telefony?q=x", URLOptions{}},
+		{"slug with spaces", "moskva", "mobilnye telefony", URLOptions{}},
+		{"empty segment", "moskva", "transport//avtomobili", URLOptions{}},
+		{"negative price", "moskva", "telefony", URLOptions{MinPrice: -1}},
+		{"inverted prices", "moskva", "telefony", URLOptions{MinPrice: 60000, MaxPrice: 10000}},
+		{"unknown sort", "moskva", "telefony", URLOptions{Sort: "popular"}},
+		{"negative page", "moskva", "telefony", URLOptions{Page: -1}},
+	}
+
+	for _, tt := range tests {
+		if got, err := BuildCategoryURL(tt.region, tt.slug, tt.opts); err == nil {
+			t.Errorf("%s: BuildCategoryURL = %q, want an error", tt.name, got)
+		}
+	}
+}
+
+func TestSearchUsesBuiltURL(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/all?q=iphone+15": "category.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.SearchListings("  iphone  15 ", 0)
+	if err != nil {
+		t.Fatalf("SearchListings: %v", err)
+	}
+	if len(listings) != 2 {
+		t.Errorf("got %d listings, want 2", len(listings))
+	}
+	if hits := srv.Hits("/all?q=iphone+15"); hits != 1 {
+		t.Errorf("search page was requested %d times, want 1", hits)
+	}
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// userAgentKey stores the user agent chosen for a request in its colly context,
+// which retries of the request share
+const userAgentKey = "userAgent"
+
+// defaultUserAgents are current desktop and mobile browsers, rotated by default
+var defaultUserAgents = []string{
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+}
+
+// randomUserAgent picks a user agent from the pool
+func (p *Parser) randomUserAgent() string {
+	return p.opts.UserAgents[rand.Intn(len(p.opts.UserAgents))]
+}
+
+// nextUserAgent returns the user agent after current in the pool
+func (p *Parser) nextUserAgent(current string) string {
+	i := slices.Index(p.opts.UserAgents, current)
+	return p.opts.UserAgents[(i+1)%len(p.opts.UserAgents)]
+}
+
+// rotateUserAgents sends every request made by c with a user agent from the pool.
+// The agent is chosen once per request, so its retries keep the same one unless
+// a retry switches it on purpose.
+func (p *Parser) rotateUserAgents(c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		userAgent, _ := r.Ctx.GetAny(userAgentKey).(string)
+		if userAgent == "" {
+			userAgent = p.randomUserAgent()
+			r.Ctx.Put(userAgentKey, userAgent)
+		}
+		r.Headers.Set("User-Agent", userAgent)
+	})
+}
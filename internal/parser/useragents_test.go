@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRequestsDrawFromUserAgentPool(t *testing.T) {
+	pool := []string{"TestAgent/1.0", "TestAgent/2.0"}
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{UserAgents: pool})
+
+	for range 10 {
+		if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+			t.Fatalf("GetListings: %v", err)
+		}
+	}
+
+	// Category and listing pages alike are sent with agents from the pool
+	used := make(map[string]int)
+	for _, header := range srv.Headers() {
+		userAgent := header.Get("User-Agent")
+		if !slices.Contains(pool, userAgent) {
+			t.Fatalf("request sent with user agent %q, want one from %v", userAgent, pool)
+		}
+		used[userAgent]++
+	}
+	// 30 requests all using the same agent out of two would mean no rotation
+	if len(used) != len(pool) {
+		t.Errorf("user agents used: %v, want both agents of the pool", used)
+	}
+}
+
+func TestSingleUserAgent(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{UserAgent: "TestAgent/1.0"})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	for _, header := range srv.Headers() {
+		if userAgent := header.Get("User-Agent"); userAgent != "TestAgent/1.0" {
+			t.Errorf("request sent with user agent %q, want TestAgent/1.0", userAgent)
+		}
+	}
+}
+
+func TestDefaultUserAgentPool(t *testing.T) {
+	srv := newFixtureServer(t, phoneRoutes)
+	p := newFixtureParser(t, srv, ParserOptions{})
+
+	if _, err := p.GetListings(srv.URL+"/moskva/telefony", 0); err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+
+	for _, header := range srv.Headers() {
+		if userAgent := header.Get("User-Agent"); !slices.Contains(defaultUserAgents, userAgent) {
+			t.Errorf("request sent with user agent %q, want one of the defaults", userAgent)
+		}
+	}
+}
+
+func TestNewParserRejectsEmptyUserAgent(t *testing.T) {
+	if _, err := NewParser(ParserOptions{UserAgents: []string{"TestAgent/1.0", ""}}); err == nil {
+		t.Error("NewParser accepted an empty user agent")
+	}
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"  iPhone 15  ", "iPhone 15"},
+		{"65\u00a0000\u00a0₽", "65 000 ₽"},
+		{"1\u202f234\u2009567", "1 234 567"},
+		{"Москва,\u00a0\u00a0ул.\u2002Ленина", "Москва, ул. Ленина"},
+		{"\u200bВелосипед\ufeff", "Велосипед"},
+		{"line\nbreak\tand\r\ntabs", "line break and tabs"},
+		{"\u3000\u2003", ""},
+	}
+
+	for _, tt := range tests {
+		if got := cleanText(tt.text); got != tt.want {
+			t.Errorf("cleanText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCleanMultilineText(t *testing.T) {
+	text := "\u00a0Первая\u00a0строка \n\n\u2003\n  Вторая\tстрока\u200b\n\n"
+	if got, want := cleanMultilineText(text), "Первая строка\n\nВторая строка"; got != want {
+		t.Errorf("cleanMultilineText = %q, want %q", got, want)
+	}
+}
+
+func TestListingWithUnicodeSpaces(t *testing.T) {
+	listing := fetchFixtureListing(t, "item_whitespace.html", ParserOptions{})
+
+	if want := "Велосипед Stels Navigator"; listing.Title != want {
+		t.Errorf("Title = %q, want %q", listing.Title, want)
+	}
+	if listing.Price.Value != 12500 || listing.Price.Text != "12 500 ₽" {
+		t.Errorf("Price = %+v, want 12500 shown as %q", listing.Price, "12 500 ₽")
+	}
+	if want := "Москва, ул. Ленина, 1"; listing.Location != want {
+		t.Errorf("Location = %q, want %q", listing.Location, want)
+	}
+	if want := `Рама 18", колёса 26".`; listing.Description != want {
+		t.Errorf("Description = %q, want %q", listing.Description, want)
+	}
+	if want := time.Date(2024, time.March, 5, 10, 30, 0, 0, moscowLocation); !listing.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %v, want %v", listing.PublishedAt, want)
+	}
+
+	if got := listing.Attributes["Диаметр колёс"]; got != `26 "` {
+		t.Errorf("Attributes[Диаметр колёс] = %q, want %q", got, `26 "`)
+	}
+	if len(listing.AttributesList) != 2 || listing.AttributesList[1].Number != 14.5 || listing.AttributesList[1].Unit != "кг" {
+		t.Errorf("AttributesList = %+v, want the weight as 14.5 кг", listing.AttributesList)
+	}
+}
+
+func TestCardWithUnicodeSpaces(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{"/moskva/velosipedy": "category_whitespace.html"})
+	p := newFixtureParser(t, srv, ParserOptions{SkipDetails: true})
+
+	listings, err := p.GetListings(srv.URL+"/moskva/velosipedy", 0)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	if len(listings) != 1 {
+		t.Fatalf("got %d listings, want 1", len(listings))
+	}
+
+	listing := listings[0]
+	if listing.Title != "Велосипед Stels" || listing.Price.Value != 12500 || listing.Price.Text != "12 500 ₽" {
+		t.Errorf("card = %q at %+v", listing.Title, listing.Price)
+	}
+	if listing.Location != "Москва, Арбат" {
+		t.Errorf("Location = %q, want %q", listing.Location, "Москва, Арбат")
+	}
+}
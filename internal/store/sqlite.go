@@ -0,0 +1,388 @@
+// Package store persists scraped listings to a SQLite database
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/itcaat/avitolog/internal/models"
+
+	// Registers the pure Go "sqlite" driver
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the tables on first use. The listings table keeps the main fields
+// in columns for querying and the full listing as JSON in data.
+const schema = `
+CREATE TABLE IF NOT EXISTS listings (
+	id             TEXT PRIMARY KEY,
+	title          TEXT NOT NULL,
+	description    TEXT NOT NULL,
+	url            TEXT NOT NULL,
+	price_value    REAL NOT NULL,
+	price_currency TEXT NOT NULL,
+	price_text     TEXT NOT NULL,
+	location       TEXT NOT NULL,
+	latitude       REAL NOT NULL,
+	longitude      REAL NOT NULL,
+	category_id    TEXT NOT NULL,
+	category_url   TEXT NOT NULL,
+	published_at   TEXT NOT NULL,
+	seller_name    TEXT NOT NULL,
+	seller_type    TEXT NOT NULL,
+	seller_url     TEXT NOT NULL,
+	data           TEXT NOT NULL,
+	fingerprint    TEXT NOT NULL DEFAULT '',
+	first_seen_at  TEXT NOT NULL,
+	updated_at     TEXT NOT NULL,
+	changed_at     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS images (
+	listing_id TEXT NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+	position   INTEGER NOT NULL,
+	url        TEXT NOT NULL,
+	PRIMARY KEY (listing_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS attributes (
+	listing_id TEXT NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+	position   INTEGER NOT NULL,
+	key        TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	PRIMARY KEY (listing_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS price_history (
+	listing_id  TEXT NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+	value       REAL NOT NULL,
+	currency    TEXT NOT NULL,
+	text        TEXT NOT NULL,
+	observed_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS price_history_listing ON price_history (listing_id, observed_at);
+`
+
+// addedColumns are the listings columns added after the table was first released,
+// which databases created before then lack
+var addedColumns = []struct{ name, definition string }{
+	{"fingerprint", "TEXT NOT NULL DEFAULT ''"},
+	{"changed_at", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// ErrMissingID is returned by Save for listings without an ID, which can't be upserted
+var ErrMissingID = errors.New("listing has no ID")
+
+// Store saves listings to a SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite database at path, creating it and its schema if needed.
+// Use ":memory:" for a temporary in-memory database.
+func OpenSQLite(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	// Every connection to ":memory:" is a separate database, and SQLite allows
+	// a single writer anyway
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error enabling foreign keys: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating schema: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// migrate adds the columns of addedColumns missing from an existing listings table
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('listings')")
+	if err != nil {
+		return fmt.Errorf("error reading schema: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("error reading schema: %w", err)
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading schema: %w", err)
+	}
+
+	for _, column := range addedColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE listings ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return fmt.Errorf("error adding column %s: %w", column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save inserts a listing or updates the stored one with the same ID, replacing its
+// images and attributes. Its price is added to the price history when it differs
+// from the last recorded one.
+func (s *Store) Save(listing models.Listing) error {
+	if listing.ID == "" {
+		return fmt.Errorf("%w: %s", ErrMissingID, listing.URL)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveListing(tx, listing, formatTime(time.Now())); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing listing %s: %w", listing.ID, err)
+	}
+
+	return nil
+}
+
+// UpsertChanged saves the listings whose content changed since they were last stored,
+// going by models.Listing.Fingerprint, along with new ones, and returns them. Listings
+// that are stored unchanged aren't written at all, which keeps frequent rescrapes cheap.
+// The changed rows get a new changed-at time. All listings are saved in one transaction,
+// so on error none of them are.
+func (s *Store) UpsertChanged(listings []models.Listing) (changed []models.Listing, err error) {
+	for _, listing := range listings {
+		if listing.ID == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingID, listing.URL)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := formatTime(time.Now())
+	for _, listing := range listings {
+		var stored string
+		err := tx.QueryRow("SELECT fingerprint FROM listings WHERE id = ?", listing.ID).Scan(&stored)
+		switch {
+		case err == nil && stored == listing.Fingerprint():
+			continue
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return nil, fmt.Errorf("error reading listing %s: %w", listing.ID, err)
+		}
+
+		if err := saveListing(tx, listing, now); err != nil {
+			return nil, err
+		}
+		changed = append(changed, listing)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing listings: %w", err)
+	}
+
+	return changed, nil
+}
+
+// saveListing upserts a listing with its images, attributes and price within tx.
+// The changed-at time is only moved to now when the listing's fingerprint differs
+// from the stored one.
+func saveListing(tx *sql.Tx, listing models.Listing, now string) error {
+	data, err := json.Marshal(listing)
+	if err != nil {
+		return fmt.Errorf("error encoding listing %s: %w", listing.ID, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO listings (
+			id, title, description, url, price_value, price_currency, price_text,
+			location, latitude, longitude, category_id, category_url, published_at,
+			seller_name, seller_type, seller_url, data, fingerprint, first_seen_at,
+			updated_at, changed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			url = excluded.url,
+			price_value = excluded.price_value,
+			price_currency = excluded.price_currency,
+			price_text = excluded.price_text,
+			location = excluded.location,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude,
+			category_id = excluded.category_id,
+			category_url = excluded.category_url,
+			published_at = excluded.published_at,
+			seller_name = excluded.seller_name,
+			seller_type = excluded.seller_type,
+			seller_url = excluded.seller_url,
+			data = excluded.data,
+			fingerprint = excluded.fingerprint,
+			updated_at = excluded.updated_at,
+			changed_at = CASE
+				WHEN listings.fingerprint = excluded.fingerprint THEN listings.changed_at
+				ELSE excluded.changed_at
+			END`,
+		listing.ID, listing.Title, listing.Description, listing.URL,
+		listing.Price.Value, listing.Price.Currency, listing.Price.Text,
+		listing.Location, listing.Latitude, listing.Longitude,
+		listing.CategoryID, listing.CategoryURL, formatTime(listing.PublishedAt),
+		listing.SellerName, listing.SellerType, listing.SellerURL,
+		string(data), listing.Fingerprint(), now, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving listing %s: %w", listing.ID, err)
+	}
+
+	if err := saveImages(tx, listing); err != nil {
+		return err
+	}
+	if err := saveAttributes(tx, listing); err != nil {
+		return err
+	}
+	return savePrice(tx, listing, now)
+}
+
+// saveImages replaces the stored image URLs of a listing
+func saveImages(tx *sql.Tx, listing models.Listing) error {
+	if _, err := tx.Exec("DELETE FROM images WHERE listing_id = ?", listing.ID); err != nil {
+		return fmt.Errorf("error clearing images of listing %s: %w", listing.ID, err)
+	}
+
+	for i, imageURL := range listing.ImageURLs {
+		_, err := tx.Exec("INSERT INTO images (listing_id, position, url) VALUES (?, ?, ?)", listing.ID, i, imageURL)
+		if err != nil {
+			return fmt.Errorf("error saving images of listing %s: %w", listing.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// saveAttributes replaces the stored attributes of a listing, in page order when known
+func saveAttributes(tx *sql.Tx, listing models.Listing) error {
+	if _, err := tx.Exec("DELETE FROM attributes WHERE listing_id = ?", listing.ID); err != nil {
+		return fmt.Errorf("error clearing attributes of listing %s: %w", listing.ID, err)
+	}
+
+	attributes := listing.AttributesList
+	if len(attributes) == 0 {
+		keys := make([]string, 0, len(listing.Attributes))
+		for key := range listing.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			attributes = append(attributes, models.KeyValue{Key: key, Value: listing.Attributes[key]})
+		}
+	}
+
+	for i, attribute := range attributes {
+		_, err := tx.Exec(
+			"INSERT INTO attributes (listing_id, position, key, value) VALUES (?, ?, ?, ?)",
+			listing.ID, i, attribute.Key, attribute.Value,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving attributes of listing %s: %w", listing.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// savePrice appends the listing's price to its history unless it matches the last recorded price
+func savePrice(tx *sql.Tx, listing models.Listing, observedAt string) error {
+	var lastValue float64
+	var lastCurrency string
+	err := tx.QueryRow(
+		"SELECT value, currency FROM price_history WHERE listing_id = ? ORDER BY observed_at DESC, rowid DESC LIMIT 1",
+		listing.ID,
+	).Scan(&lastValue, &lastCurrency)
+	switch {
+	case err == nil && lastValue == listing.Price.Value && lastCurrency == listing.Price.Currency:
+		return nil
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("error reading price history of listing %s: %w", listing.ID, err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO price_history (listing_id, value, currency, text, observed_at) VALUES (?, ?, ?, ?, ?)",
+		listing.ID, listing.Price.Value, listing.Price.Currency, listing.Price.Text, observedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving price of listing %s: %w", listing.ID, err)
+	}
+
+	return nil
+}
+
+// GetPriceHistory returns the recorded prices of a listing, oldest first
+func (s *Store) GetPriceHistory(id string) ([]models.PriceHistory, error) {
+	rows, err := s.db.Query(
+		"SELECT value, currency, observed_at FROM price_history WHERE listing_id = ? ORDER BY observed_at, rowid",
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error reading price history of listing %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	var history []models.PriceHistory
+	for rows.Next() {
+		entry := models.PriceHistory{ListingID: id}
+		var observedAt string
+		if err := rows.Scan(&entry.Value, &entry.Currency, &observedAt); err != nil {
+			return nil, fmt.Errorf("error reading price history of listing %s: %w", id, err)
+		}
+		if entry.ObservedAt, err = time.Parse(time.RFC3339Nano, observedAt); err != nil {
+			return nil, fmt.Errorf("invalid observation time %q for listing %s: %w", observedAt, id, err)
+		}
+		history = append(history, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading price history of listing %s: %w", id, err)
+	}
+
+	return history, nil
+}
+
+// formatTime stores times as sortable RFC 3339 text in UTC; the zero time is stored as ""
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
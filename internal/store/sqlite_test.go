@@ -0,0 +1,290 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/itcaat/avitolog/internal/models"
+)
+
+// openTestStore opens an in-memory Store that is closed with the test
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// testListings returns two listings as a scrape would find them
+func testListings() []models.Listing {
+	return []models.Listing{
+		{
+			ID:    "1111111111",
+			Title: "iPhone 15",
+			URL:   "https://www.avito.ru/moskva/telefony/iphone_15_1111111111",
+			Price: models.Price{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+		},
+		{
+			ID:    "2222222222",
+			Title: "Samsung Galaxy S24",
+			URL:   "https://www.avito.ru/moskva/telefony/samsung_s24_2222222222",
+			Price: models.Price{Value: 54000, Currency: "RUB", Text: "54 000 ₽"},
+		},
+	}
+}
+
+// storedTimes returns the updated-at and changed-at times of a stored listing
+func storedTimes(t *testing.T, s *Store, id string) (updatedAt, changedAt string) {
+	t.Helper()
+
+	err := s.db.QueryRow("SELECT updated_at, changed_at FROM listings WHERE id = ?", id).Scan(&updatedAt, &changedAt)
+	if err != nil {
+		t.Fatalf("reading listing %s: %v", id, err)
+	}
+	return updatedAt, changedAt
+}
+
+// changedIDs returns the IDs of listings
+func changedIDs(listings []models.Listing) []string {
+	var ids []string
+	for _, listing := range listings {
+		ids = append(ids, listing.ID)
+	}
+	return ids
+}
+
+func TestUpsertChanged(t *testing.T) {
+	s := openTestStore(t)
+	listings := testListings()
+
+	changed, err := s.UpsertChanged(listings)
+	if err != nil {
+		t.Fatalf("first UpsertChanged: %v", err)
+	}
+	if got := changedIDs(changed); len(got) != 2 {
+		t.Fatalf("first UpsertChanged returned %v, want both new listings", got)
+	}
+	updatedAt, changedAt := storedTimes(t, s, "2222222222")
+	if changedAt == "" {
+		t.Error("new listing has no changed-at time")
+	}
+
+	// A rescrape with nothing new writes nothing
+	changed, err = s.UpsertChanged(testListings())
+	if err != nil {
+		t.Fatalf("second UpsertChanged: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("unchanged listings reported as changed: %v", changedIDs(changed))
+	}
+	if gotUpdated, gotChanged := storedTimes(t, s, "2222222222"); gotUpdated != updatedAt || gotChanged != changedAt {
+		t.Errorf("unchanged listing was rewritten: updated %s -> %s, changed %s -> %s", updatedAt, gotUpdated, changedAt, gotChanged)
+	}
+
+	// A price drop changes the fingerprint of that listing only
+	listings = testListings()
+	listings[1].Price = models.Price{Value: 49000, Currency: "RUB", Text: "49 000 ₽"}
+	changed, err = s.UpsertChanged(listings)
+	if err != nil {
+		t.Fatalf("third UpsertChanged: %v", err)
+	}
+	if got := changedIDs(changed); len(got) != 1 || got[0] != "2222222222" {
+		t.Errorf("UpsertChanged returned %v, want [2222222222]", got)
+	}
+	if _, gotChanged := storedTimes(t, s, "2222222222"); gotChanged <= changedAt {
+		t.Errorf("changed-at time %s didn't move past %s", gotChanged, changedAt)
+	}
+
+	history, err := s.GetPriceHistory("2222222222")
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	if len(history) != 2 || history[1].Value != 49000 {
+		t.Errorf("price history = %+v, want 54000 then 49000", history)
+	}
+}
+
+func TestUpsertChangedRejectsMissingID(t *testing.T) {
+	s := openTestStore(t)
+	listings := append(testListings(), models.Listing{Title: "No ID"})
+
+	if _, err := s.UpsertChanged(listings); !errors.Is(err, ErrMissingID) {
+		t.Fatalf("UpsertChanged = %v, want ErrMissingID", err)
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM listings").Scan(&count); err != nil {
+		t.Fatalf("counting listings: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d listings were saved from a rejected batch", count)
+	}
+}
+
+func TestOpenSQLiteMigratesOldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listings.db")
+
+	// The listings table as it was before fingerprints were stored
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE listings (
+		id TEXT PRIMARY KEY, title TEXT NOT NULL, description TEXT NOT NULL, url TEXT NOT NULL,
+		price_value REAL NOT NULL, price_currency TEXT NOT NULL, price_text TEXT NOT NULL,
+		location TEXT NOT NULL, latitude REAL NOT NULL, longitude REAL NOT NULL,
+		category_id TEXT NOT NULL, category_url TEXT NOT NULL, published_at TEXT NOT NULL,
+		seller_name TEXT NOT NULL, seller_type TEXT NOT NULL, seller_url TEXT NOT NULL,
+		data TEXT NOT NULL, first_seen_at TEXT NOT NULL, updated_at TEXT NOT NULL
+	)`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("creating old schema: %v", err)
+	}
+
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer s.Close()
+
+	changed, err := s.UpsertChanged(testListings())
+	if err != nil {
+		t.Fatalf("UpsertChanged on migrated database: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("UpsertChanged returned %d listings, want 2", len(changed))
+	}
+}
+
+// storedStrings returns the first column of the rows query selects for listing id
+func storedStrings(t *testing.T, s *Store, query, id string) []string {
+	t.Helper()
+
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		t.Fatalf("querying listing %s: %v", id, err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatalf("scanning listing %s: %v", id, err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("reading listing %s: %v", id, err)
+	}
+	return values
+}
+
+func TestSave(t *testing.T) {
+	s := openTestStore(t)
+
+	listing := testListings()[0]
+	listing.ImageURLs = []string{"https://img.avito.st/1.jpg", "https://img.avito.st/2.jpg"}
+	listing.AttributesList = []models.KeyValue{{Key: "Состояние", Value: "Б/у"}, {Key: "Память", Value: "128 ГБ"}}
+	if err := s.Save(listing); err != nil {
+		t.Fatalf("first Save: %v", err)
+	}
+
+	// Saving it again updates the row in place and replaces its images and attributes
+	listing.Title = "iPhone 15, 128 ГБ"
+	listing.Price = models.Price{Value: 60000, Currency: "RUB", Text: "60 000 ₽"}
+	listing.ImageURLs = []string{"https://img.avito.st/3.jpg"}
+	listing.AttributesList = []models.KeyValue{{Key: "Состояние", Value: "Отличное"}}
+	if err := s.Save(listing); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM listings").Scan(&count); err != nil {
+		t.Fatalf("counting listings: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("%d listings stored, want 1", count)
+	}
+
+	var title string
+	var price float64
+	err := s.db.QueryRow("SELECT title, price_value FROM listings WHERE id = ?", listing.ID).Scan(&title, &price)
+	if err != nil {
+		t.Fatalf("reading listing: %v", err)
+	}
+	if title != listing.Title || price != 60000 {
+		t.Errorf("stored title %q and price %v, want %q and 60000", title, price, listing.Title)
+	}
+
+	images := storedStrings(t, s, "SELECT url FROM images WHERE listing_id = ? ORDER BY position", listing.ID)
+	if len(images) != 1 || images[0] != "https://img.avito.st/3.jpg" {
+		t.Errorf("stored images = %v, want only the new one", images)
+	}
+	attributes := storedStrings(t, s, "SELECT key || ': ' || value FROM attributes WHERE listing_id = ? ORDER BY position", listing.ID)
+	if len(attributes) != 1 || attributes[0] != "Состояние: Отличное" {
+		t.Errorf("stored attributes = %v, want only the new one", attributes)
+	}
+
+	history, err := s.GetPriceHistory(listing.ID)
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("price history has %d rows, want one per price", len(history))
+	}
+}
+
+func TestSaveRejectsMissingID(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.Save(models.Listing{Title: "No ID"}); !errors.Is(err, ErrMissingID) {
+		t.Fatalf("Save = %v, want ErrMissingID", err)
+	}
+}
+
+func TestGetPriceHistory(t *testing.T) {
+	s := openTestStore(t)
+
+	// Three scrapes of the same listing: the price holds, then drops
+	prices := []models.Price{
+		{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+		{Value: 65000, Currency: "RUB", Text: "65 000 ₽"},
+		{Value: 59990, Currency: "RUB", Text: "59 990 ₽"},
+	}
+	for i, price := range prices {
+		listing := testListings()[0]
+		listing.Price = price
+		if err := s.Save(listing); err != nil {
+			t.Fatalf("Save %d: %v", i+1, err)
+		}
+	}
+
+	history, err := s.GetPriceHistory("1111111111")
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("price history = %+v, want 2 rows", history)
+	}
+	for i, want := range []float64{65000, 59990} {
+		entry := history[i]
+		if entry.ListingID != "1111111111" || entry.Value != want || entry.Currency != "RUB" {
+			t.Errorf("history[%d] = %+v, want %v RUB", i, entry, want)
+		}
+	}
+	if history[1].ObservedAt.Before(history[0].ObservedAt) {
+		t.Errorf("history isn't oldest first: %v then %v", history[0].ObservedAt, history[1].ObservedAt)
+	}
+
+	if history, err := s.GetPriceHistory("9999999999"); err != nil || len(history) != 0 {
+		t.Errorf("GetPriceHistory of an unknown listing = %+v, %v, want nothing", history, err)
+	}
+}